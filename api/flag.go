@@ -3,6 +3,8 @@ package api
 type FlagRequest struct {
 	Token string `json:"token" form:"token"`
 	Task  string `json:"task" form:"task"`
+	// GitLab login of the student, flag can be submitted only by them
+	Login string `json:"login,omitempty" form:"login"`
 }
 
 type FlagResponse struct {
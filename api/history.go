@@ -0,0 +1,39 @@
+package api
+
+import "time"
+
+type ScorePoint struct {
+	Time     time.Time `json:"time"`
+	Score    int       `json:"score"`
+	MaxScore int       `json:"max_score"`
+}
+
+type ScoresHistory struct {
+	Login    string       `json:"login"`
+	Subgroup string       `json:"subgroup,omitempty"`
+	Points   []ScorePoint `json:"points"`
+}
+
+type ScoresHistoryRequest struct {
+	Login string `json:"login" form:"login"`
+	// Whole history is returned if omitted
+	Since time.Time `json:"since" form:"since" time_format:"2006-01-02"`
+}
+
+type ScoresHistoryResponse struct {
+	Status
+
+	History *ScoresHistory `json:"history,omitempty"`
+}
+
+type StandingsHistoryRequest struct {
+	Group    string    `json:"group" form:"group"`
+	Subgroup string    `json:"subgroup" form:"subgroup"`
+	Since    time.Time `json:"since" form:"since" time_format:"2006-01-02"`
+}
+
+type StandingsHistoryResponse struct {
+	Status
+
+	Users []*ScoresHistory `json:"users,omitempty"`
+}
@@ -3,6 +3,7 @@ package api
 import "github.com/bigredeye/notmanytask/internal/scorer"
 
 type UserScoresRequest struct {
+	// Scores of other users are available to admins only
 	Login string `json:"login" form:"login"`
 }
 
@@ -11,3 +12,14 @@ type UserScoresResponse struct {
 
 	Scores *scorer.UserScores `json:"Scores,omitempty"`
 }
+
+type StandingsRequest struct {
+	Group    string `json:"group" form:"group"`
+	Subgroup string `json:"subgroup" form:"subgroup"`
+}
+
+type StandingsResponse struct {
+	Status
+
+	Standings *scorer.Standings `json:"Standings,omitempty"`
+}
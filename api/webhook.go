@@ -0,0 +1,7 @@
+package api
+
+type WebhookResponse struct {
+	Status
+	// Reason to skip the event, GitLab does not need to retry it
+	Ignored string `json:"ignored,omitempty"`
+}
@@ -15,6 +15,7 @@ import (
 	"os"
 	"os/exec"
 	"path"
+	"regexp"
 	"strings"
 	"sync"
 	"syscall"
@@ -53,10 +54,11 @@ type flagFetcher struct {
 	token string
 }
 
-func (f flagFetcher) doFetchFlag(task string) (string, error) {
+func (f flagFetcher) doFetchFlag(task string, login string) (string, error) {
 	buf, err := json.Marshal(&api.FlagRequest{
 		Token: f.token,
 		Task:  task,
+		Login: login,
 	})
 	if err != nil {
 		return "", err
@@ -86,14 +88,14 @@ func (f flagFetcher) doFetchFlag(task string) (string, error) {
 	return response.Flag, nil
 }
 
-func (f flagFetcher) fetchFlag(task string) (string, error) {
+func (f flagFetcher) fetchFlag(task string, login string) (string, error) {
 	flag := ""
 
 	backoffPolicy := backoff.NewExponentialBackOff()
 	backoffPolicy.MaxElapsedTime = time.Second * 15
 	err := backoff.Retry(func() error {
 		var err error
-		flag, err = f.doFetchFlag(task)
+		flag, err = f.doFetchFlag(task, login)
 		log.Printf("Failed to fetch flag: %+v\n", err)
 		return err
 	}, backoffPolicy)
@@ -144,6 +146,9 @@ func main() {
 const MAX_INPUT_SIZE = 10 * 1024 * 1024 // 10MiB
 const MAX_FIRST_LINE_SIZE = 100
 
+// GitLab usernames
+var loginRe = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
 type checker struct {
 	binariesDirectory string
 	submitsDirectory  string
@@ -234,6 +239,16 @@ func (c *checker) doHandleConnection(ctx context.Context, conn net.Conn) error {
 		return fmt.Errorf("unknown task %s", task)
 	}
 
+	// Flags are bound to the student, so ask who is going to submit it
+	io.WriteString(conn, "Enter GitLab login: ")
+	login, err := slowReadFirstLine(io.LimitReader(reader, MAX_FIRST_LINE_SIZE))
+	if err != nil {
+		return fmt.Errorf("failed to read login: %w", err)
+	}
+	if !loginRe.MatchString(login) {
+		return fmt.Errorf("invalid login %s", login)
+	}
+
 	inputPath := path.Join(c.submitsDirectory, task+"_"+time.Now().Format("2006-01-02T15:04:05.000"))
 	submitFile, err := os.Create(inputPath)
 	if err != nil {
@@ -272,7 +287,7 @@ func (c *checker) doHandleConnection(ctx context.Context, conn net.Conn) error {
 				return fmt.Errorf("failed to write to the connection: %w", err)
 			}
 
-			flag, err := c.flagFetcher.fetchFlag(task)
+			flag, err := c.flagFetcher.fetchFlag(task, login)
 			if err != nil {
 				log.Printf("Failed to fetch flag for failed task: %+v\n", err)
 				return fmt.Errorf("failed to fetch flag, try again a few minutes later")
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"reflect"
+	"strings"
 	"time"
 
 	"github.com/bigredeye/notmanytask/pkg/conf"
@@ -8,6 +10,23 @@ import (
 	"github.com/pkg/errors"
 )
 
+// ProjectConfig describes student projects created in the course group
+type ProjectConfig struct {
+	// Repository imported into new projects, either it or ForkFrom is required
+	ImportURL string
+	// ID of the template project forked into new projects instead of importing ImportURL.
+	// Template changes may be merged into projects only if it is set.
+	ForkFrom int
+	// "main" by default
+	DefaultBranch string
+	// "private" by default
+	Visibility string
+	// Shared runners are disabled by default
+	SharedRunners *bool
+	// Access level of the student, "developer" by default
+	MemberRole string
+}
+
 type GitLabConfig struct {
 	BaseURL string
 	Group   struct {
@@ -16,6 +35,10 @@ type GitLabConfig struct {
 	}
 	DefaultReadme string
 	TaskUrlPrefix string
+	// Default settings of student projects, may be overridden per group
+	Project ProjectConfig
+	// Merge template updates without conflicts instead of waiting for students
+	AutoMergeTemplate bool
 
 	Application struct {
 		ClientID string
@@ -24,24 +47,62 @@ type GitLabConfig struct {
 	Api struct {
 		Token string
 	}
+	// Secret token configured for the group webhook in GitLab
+	Webhook struct {
+		Secret string
+	}
+	// Limits all API calls, unlimited if RequestsPerSecond is zero
+	RateLimit struct {
+		RequestsPerSecond float64
+		Burst             int
+	}
+	// Number of projects processed concurrently by pollers
+	Workers   int
 	ReviewTtl time.Duration
 }
 
 type EndpointsConfig struct {
-	HostName       string
-	Home           string
-	Flag           string
-	Login          string
-	Logout         string
-	Signup         string
-	Standings      string
-	GroupStandings string
+	HostName          string
+	Home              string
+	Flag              string
+	Login             string
+	Logout            string
+	Signup            string
+	Standings         string
+	GroupStandings    string
 	SubgroupStandings string
-	OauthCallback  string
+	OauthCallback     string
+	ApiToken          string
+	Sessions          string
+	TimeZone          string
+	Task              string
+	Calendar          string
+	// Feeds authenticated by the token query parameter
+	CalendarFeed      string
+	GroupCalendarFeed string
 
 	Api struct {
-		Report string
-		Flag   string
+		Report           string
+		Flag             string
+		Scores           string
+		Standings        string
+		Webhook          string
+		ScoresHistory    string
+		StandingsHistory string
+	}
+
+	Admin struct {
+		Users           string
+		User            string
+		Standings       string
+		Extensions      string
+		Overrides       string
+		Export          string
+		Flags           string
+		SyncTemplate    string
+		TemplateUpdates string
+		Provisioning    string
+		Deadlines       string
 	}
 }
 
@@ -51,6 +112,12 @@ type ServerConfig struct {
 		AuthenticationKey string
 		EncryptionKey     string
 	}
+	Sessions struct {
+		// Sessions unused for Ttl expire, 30 days by default
+		Ttl time.Duration
+		// Interval of expired sessions cleanup, one hour by default
+		SweepInterval time.Duration
+	}
 }
 
 type DataBaseConfig struct {
@@ -65,35 +132,357 @@ type TestingConfig struct {
 	Tokens []string
 }
 
+type FlagsConfig struct {
+	// Hex encoded HMAC key of the flags bound to students, at least 16 bytes.
+	// Legacy flags which may be submitted by anyone are issued if empty
+	Key string
+	// Mint legacy flags which may be submitted by anyone if crashme does not send login
+	AllowUnbound bool
+
+	// Wrong flags allowed per user in the window, 10 per hour by default
+	MaxWrongAttempts    int
+	WrongAttemptsWindow time.Duration
+}
+
 type SubgroupConfig struct {
 	Name   string
 	Secret string
 }
 
+type DeadlinesSourceConfig struct {
+	// One of http, file or gitlab, inferred from the set fields if empty
+	Type string
+
+	// Public URL of the deadlines, fetched with conditional requests
+	URL string
+	// Request timeout, 30 seconds by default
+	Timeout time.Duration
+
+	// Local file reloaded on change
+	Path string
+
+	// File of the repository read with the API token, the default branch if Ref is empty
+	Project string
+	File    string
+	Ref     string
+}
+
 type GroupConfig struct {
-	Name         string
+	Name string
+	// Shorthand for the http deadlines source
 	DeadlinesURL string
+	Deadlines    *DeadlinesSourceConfig
 	Subgroups    []SubgroupConfig
+	// Time zone of the deadlines, the course time zone by default
+	TimeZone string
+
+	// Final grade formula, final grades are not computed if omitted
+	Grading *GradingConfig
+	// Overrides non-empty fields of the course project settings
+	Project *ProjectConfig
 }
 
 type GroupsConfig = []GroupConfig
 
+type GradingComponentConfig struct {
+	// Name of the task group from deadlines
+	TaskGroup string
+	// Relative weight of the task group score ratio
+	Weight float64
+	// Minimal number of solved tasks required to pass the course
+	MinTasks int
+	// Maximal score ratio counted, 1 by default
+	Cap *float64
+}
+
+type GradeThresholdConfig struct {
+	// Minimal weighted score ratio in [0, 1] required for the grade
+	MinScore float64
+	Grade    int
+}
+
+type GradingConfig struct {
+	// Task groups missing here do not affect the grade.
+	// Ratio of total score is used if no components are listed.
+	Components []GradingComponentConfig
+	// Ratio to grade mapping, ratio is scaled to MaxGrade and rounded if omitted
+	Scale []GradeThresholdConfig
+	// Grade used if some MinTasks requirement is not met
+	FailGrade int
+	// 10 by default
+	MaxGrade int
+}
+
+type ScoringStepConfig struct {
+	// Submits made at most Delay after the deadline get Percent of the task score
+	Delay   time.Duration
+	Percent int
+}
+
+type ScoringConfig struct {
+	// One of "hard", "linear", "exponential" or "step", exponential is used by default
+	Policy string
+
+	// Linear policy: time after deadline when score reaches Floor
+	Grace time.Duration
+	// Exponential policy: time after deadline when score is halved
+	HalfLife time.Duration
+	// Minimal fraction of score for late submits
+	Floor *float64
+	// Step policy: late submit brackets sorted by delay
+	Steps []ScoringStepConfig
+}
+
 type PullIntervalsConfig struct {
 	Projects      time.Duration
 	Deadlines     time.Duration
 	Pipelines     time.Duration
 	MergeRequests time.Duration
+
+	// Interval of full pipelines resync, only updated pipelines are fetched in between.
+	// Every iteration is a full resync if zero.
+	PipelinesResync time.Duration
+	// Template updates are published only on admin request if zero
+	TemplateUpdates time.Duration
+	// Interval of score snapshots used by progress charts, 6 hours by default
+	ScoreSnapshots time.Duration
 }
 
+type CourseGitLabConfig struct {
+	Group struct {
+		Name string
+		ID   int
+	}
+	DefaultReadme string
+	TaskUrlPrefix string
+	Project       *ProjectConfig
+	Webhook       struct {
+		Secret string
+	}
+}
+
+// CourseConfig overrides course specific parts of the top level config
+type CourseConfig struct {
+	// Unique course identifier, stored with every database row of the course
+	Name  string
+	Title string
+	// Course endpoints are served under the prefix, e.g. /cpp
+	PathPrefix string
+	// Top level time zone is used if omitted
+	TimeZone string
+
+	GitLab  CourseGitLabConfig
+	Testing TestingConfig
+	Groups  GroupsConfig
+	// Top level scoring is used if omitted
+	Scoring *ScoringConfig
+
+	// Course admins in addition to the top level ones
+	Admins []string
+}
+
+const defaultTitle = "HSE Basic C++"
+
 type Config struct {
+	// Course of the config, empty for the single course deployment
+	Course     string
+	Title      string
+	PathPrefix string
+	// IANA name of the course time zone, Europe/Moscow by default
+	TimeZone string
+
 	Log           log.Config
 	GitLab        GitLabConfig
 	Endpoints     EndpointsConfig
 	Server        ServerConfig
 	DataBase      DataBaseConfig
 	Testing       TestingConfig
+	Flags         FlagsConfig
 	Groups        GroupsConfig
 	PullIntervals PullIntervalsConfig
+	Scoring       ScoringConfig
+
+	// GitLab logins of teachers, they are granted admin role on login and lose it once removed
+	Admins []string
+
+	// Courses served by the deployment, the top level config describes the only course if empty
+	Courses []CourseConfig
+}
+
+func (c *Config) IsAdmin(gitlabLogin string) bool {
+	for _, admin := range c.Admins {
+		if admin == gitlabLogin {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultTimeZone is used if the course does not specify one
+const DefaultTimeZone = "Europe/Moscow"
+
+const (
+	defaultBranch     = "main"
+	defaultVisibility = "private"
+	defaultMemberRole = "developer"
+)
+
+// merge fills empty fields of the override from base
+func (p ProjectConfig) merge(base ProjectConfig) ProjectConfig {
+	if p.ImportURL == "" && p.ForkFrom == 0 {
+		p.ImportURL = base.ImportURL
+		p.ForkFrom = base.ForkFrom
+	}
+	if p.DefaultBranch == "" {
+		p.DefaultBranch = base.DefaultBranch
+	}
+	if p.Visibility == "" {
+		p.Visibility = base.Visibility
+	}
+	if p.SharedRunners == nil {
+		p.SharedRunners = base.SharedRunners
+	}
+	if p.MemberRole == "" {
+		p.MemberRole = base.MemberRole
+	}
+	return p
+}
+
+// ProjectFor returns settings of projects of the group students with defaults applied
+func (c *Config) ProjectFor(group string) ProjectConfig {
+	project := c.GitLab.Project
+	if g := c.FindGroup(group); g != nil && g.Project != nil {
+		project = g.Project.merge(project)
+	}
+	return project.merge(ProjectConfig{
+		DefaultBranch: defaultBranch,
+		Visibility:    defaultVisibility,
+		MemberRole:    defaultMemberRole,
+	})
+}
+
+// Location returns the time zone of the group deadlines
+func (c *Config) Location(group string) (*time.Location, error) {
+	name := c.TimeZone
+	if g := c.FindGroup(group); g != nil && g.TimeZone != "" {
+		name = g.TimeZone
+	}
+	if name == "" {
+		name = DefaultTimeZone
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Invalid time zone %q", name)
+	}
+	return loc, nil
+}
+
+// DeadlinesSource returns the source of the group deadlines
+func (g *GroupConfig) DeadlinesSource() DeadlinesSourceConfig {
+	if g.Deadlines != nil {
+		return *g.Deadlines
+	}
+	return DeadlinesSourceConfig{URL: g.DeadlinesURL}
+}
+
+func (c *Config) FindGroup(name string) *GroupConfig {
+	for i := range c.Groups {
+		if c.Groups[i].Name == name {
+			return &c.Groups[i]
+		}
+	}
+	return nil
+}
+
+// CourseViews returns a config for every course served with prefixed endpoints
+func (c *Config) CourseViews() ([]*Config, error) {
+	if len(c.Courses) == 0 {
+		view := *c
+		if view.Title == "" {
+			view.Title = defaultTitle
+		}
+		view.Endpoints = c.Endpoints.WithPrefix(c.PathPrefix)
+		return []*Config{&view}, nil
+	}
+
+	names := make(map[string]bool)
+	prefixes := make(map[string]bool)
+	views := make([]*Config, 0, len(c.Courses))
+	for _, course := range c.Courses {
+		if course.Name == "" {
+			return nil, errors.New("Course name is required")
+		}
+		if names[course.Name] {
+			return nil, errors.Errorf("Duplicate course %s", course.Name)
+		}
+		if prefixes[course.PathPrefix] {
+			return nil, errors.Errorf("Course %s path prefix %q is already used", course.Name, course.PathPrefix)
+		}
+		if course.PathPrefix != "" && (!strings.HasPrefix(course.PathPrefix, "/") || strings.HasSuffix(course.PathPrefix, "/")) {
+			return nil, errors.Errorf("Course %s path prefix must start with / and must not end with /", course.Name)
+		}
+		names[course.Name] = true
+		prefixes[course.PathPrefix] = true
+
+		view := *c
+		view.Courses = nil
+		view.Course = course.Name
+		view.Title = course.Title
+		if view.Title == "" {
+			view.Title = course.Name
+		}
+		view.PathPrefix = course.PathPrefix
+		if course.TimeZone != "" {
+			view.TimeZone = course.TimeZone
+		}
+		view.Endpoints = c.Endpoints.WithPrefix(course.PathPrefix)
+
+		view.GitLab.Group = course.GitLab.Group
+		if course.GitLab.DefaultReadme != "" {
+			view.GitLab.DefaultReadme = course.GitLab.DefaultReadme
+		}
+		if course.GitLab.TaskUrlPrefix != "" {
+			view.GitLab.TaskUrlPrefix = course.GitLab.TaskUrlPrefix
+		}
+		if course.GitLab.Project != nil {
+			view.GitLab.Project = course.GitLab.Project.merge(c.GitLab.Project)
+		}
+		if course.GitLab.Webhook.Secret != "" {
+			view.GitLab.Webhook.Secret = course.GitLab.Webhook.Secret
+		}
+		if len(course.Testing.Tokens) > 0 {
+			view.Testing = course.Testing
+		}
+		view.Groups = course.Groups
+		if course.Scoring != nil {
+			view.Scoring = *course.Scoring
+		}
+		view.Admins = append(append([]string{}, c.Admins...), course.Admins...)
+
+		views = append(views, &view)
+	}
+	return views, nil
+}
+
+// WithPrefix prepends the prefix to every endpoint path
+func (e EndpointsConfig) WithPrefix(prefix string) EndpointsConfig {
+	if prefix != "" {
+		prefixStrings(reflect.ValueOf(&e).Elem(), prefix)
+	}
+	return e
+}
+
+func prefixStrings(value reflect.Value, prefix string) {
+	for i := 0; i < value.NumField(); i++ {
+		field := value.Field(i)
+		switch {
+		case value.Type().Field(i).Name == "HostName":
+		case field.Kind() == reflect.Struct:
+			prefixStrings(field, prefix)
+		case field.Kind() == reflect.String && field.String() != "":
+			field.SetString(prefix + field.String())
+		}
+	}
 }
 
 func ParseConfig() (*Config, error) {
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCourseViews(t *testing.T) {
+	config := &Config{Admins: []string{"root"}}
+	config.Endpoints.HostName = "https://example.com"
+	config.Endpoints.Home = "/"
+	config.Endpoints.Api.Report = "/api/report"
+	config.Testing.Tokens = []string{"shared"}
+
+	views, err := config.CourseViews()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(views) != 1 || views[0].Course != "" || views[0].Title != defaultTitle || views[0].Endpoints.Home != "/" {
+		t.Errorf("Unexpected single course view %+v", views)
+	}
+
+	config.Courses = []CourseConfig{
+		{Name: "cpp", Title: "C++", PathPrefix: "/cpp", Admins: []string{"teacher"}},
+		{Name: "go", PathPrefix: "/go", Testing: TestingConfig{Tokens: []string{"go"}}},
+	}
+	views, err = config.CourseViews()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(views) != 2 {
+		t.Fatalf("Expected 2 views, got %d", len(views))
+	}
+
+	cpp, golang := views[0], views[1]
+	if cpp.Course != "cpp" || cpp.Title != "C++" {
+		t.Errorf("Unexpected course %q titled %q", cpp.Course, cpp.Title)
+	}
+	if cpp.Endpoints.HostName != "https://example.com" || cpp.Endpoints.Home != "/cpp/" || cpp.Endpoints.Api.Report != "/cpp/api/report" {
+		t.Errorf("Unexpected endpoints %+v", cpp.Endpoints)
+	}
+	if !reflect.DeepEqual(cpp.Testing.Tokens, []string{"shared"}) || !reflect.DeepEqual(cpp.Admins, []string{"root", "teacher"}) {
+		t.Errorf("Unexpected tokens %v or admins %v", cpp.Testing.Tokens, cpp.Admins)
+	}
+	if golang.Title != "go" || !reflect.DeepEqual(golang.Testing.Tokens, []string{"go"}) || !reflect.DeepEqual(golang.Admins, []string{"root"}) {
+		t.Errorf("Unexpected course %+v", golang)
+	}
+	if config.Endpoints.Home != "/" {
+		t.Errorf("Top level endpoints were modified")
+	}
+
+	config.Courses[1].PathPrefix = "/cpp"
+	if _, err = config.CourseViews(); err == nil {
+		t.Errorf("Duplicate path prefix is accepted")
+	}
+}
+
+func TestProjectFor(t *testing.T) {
+	enabled := true
+	config := &Config{}
+	config.GitLab.Project = ProjectConfig{ImportURL: "https://example.com/template.git", Visibility: "internal"}
+	config.Groups = GroupsConfig{
+		{Name: "students", Project: &ProjectConfig{ForkFrom: 42, SharedRunners: &enabled}},
+		{Name: "staff", Project: &ProjectConfig{MemberRole: "maintainer"}},
+	}
+
+	students := config.ProjectFor("students")
+	if students.ForkFrom != 42 || students.ImportURL != "" || students.SharedRunners == nil || !*students.SharedRunners {
+		t.Errorf("Group settings are not applied: %+v", students)
+	}
+	if students.Visibility != "internal" || students.DefaultBranch != "main" || students.MemberRole != "developer" {
+		t.Errorf("Defaults are not applied: %+v", students)
+	}
+
+	staff := config.ProjectFor("staff")
+	if staff.ImportURL != "https://example.com/template.git" || staff.MemberRole != "maintainer" || staff.SharedRunners != nil {
+		t.Errorf("Unexpected staff settings: %+v", staff)
+	}
+	if unknown := config.ProjectFor("unknown"); unknown.ImportURL != "https://example.com/template.git" || unknown.MemberRole != "developer" {
+		t.Errorf("Unexpected default settings: %+v", unknown)
+	}
+}
+
+func TestLocation(t *testing.T) {
+	config := &Config{Groups: []GroupConfig{{Name: "ekb", TimeZone: "Asia/Yekaterinburg"}, {Name: "msk"}}}
+	for group, expected := range map[string]string{"ekb": "Asia/Yekaterinburg", "msk": DefaultTimeZone, "": DefaultTimeZone} {
+		loc, err := config.Location(group)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if loc.String() != expected {
+			t.Errorf("Unexpected time zone of group %q: %s", group, loc)
+		}
+	}
+
+	config.TimeZone = "Mars/Olympus"
+	if _, err := config.Location("msk"); err == nil {
+		t.Errorf("Unknown time zone is accepted")
+	}
+}
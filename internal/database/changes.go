@@ -0,0 +1,92 @@
+package database
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// Change describes rows written by a single committed statement
+type Change struct {
+	// Empty if the statement was not scoped to a course
+	Course string
+	Table  string
+	// Written models, may be partially filled for updates
+	Rows []interface{}
+}
+
+type ChangeListener = func(change *Change)
+
+type changeFeed struct {
+	mu        sync.RWMutex
+	listeners []ChangeListener
+}
+
+// Subscribe registers listener called after every successful create, update or delete
+func (db *DataBase) Subscribe(listener ChangeListener) {
+	db.changes.mu.Lock()
+	defer db.changes.mu.Unlock()
+	db.changes.listeners = append(db.changes.listeners, listener)
+}
+
+func registerChangeCallbacks(db *gorm.DB, feed *changeFeed) error {
+	callbacks := db.Callback()
+	if err := callbacks.Create().After("gorm:commit_or_rollback_transaction").Register("notmanytask:changes_create", feed.notify); err != nil {
+		return errors.Wrap(err, "Failed to register create callback")
+	}
+	if err := callbacks.Update().After("gorm:commit_or_rollback_transaction").Register("notmanytask:changes_update", feed.notify); err != nil {
+		return errors.Wrap(err, "Failed to register update callback")
+	}
+	if err := callbacks.Delete().After("gorm:commit_or_rollback_transaction").Register("notmanytask:changes_delete", feed.notify); err != nil {
+		return errors.Wrap(err, "Failed to register delete callback")
+	}
+	return nil
+}
+
+func (f *changeFeed) notify(db *gorm.DB) {
+	if db.Error != nil || db.Statement.RowsAffected == 0 {
+		return
+	}
+
+	f.mu.RLock()
+	listeners := f.listeners
+	f.mu.RUnlock()
+	if len(listeners) == 0 {
+		return
+	}
+
+	change := &Change{
+		Table: db.Statement.Table,
+		Rows:  statementRows(db.Statement.ReflectValue),
+	}
+	change.Course, _ = statementCourse(db)
+	for _, listener := range listeners {
+		listener(change)
+	}
+}
+
+func statementRows(value reflect.Value) []interface{} {
+	rows := make([]interface{}, 0, 1)
+	addRow := func(row reflect.Value) {
+		row = reflect.Indirect(row)
+		if row.Kind() != reflect.Struct {
+			return
+		}
+		if row.CanAddr() {
+			row = row.Addr()
+		}
+		rows = append(rows, row.Interface())
+	}
+
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			addRow(value.Index(i))
+		}
+	case reflect.Struct, reflect.Ptr:
+		addRow(value)
+	}
+	return rows
+}
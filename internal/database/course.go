@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+	"reflect"
+
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type courseKey struct{}
+
+const courseField = "Course"
+
+// ForCourse returns a handle which sees and creates rows of the single course only.
+// Models without the Course field are not scoped.
+func (db *DataBase) ForCourse(course string) *DataBase {
+	ctx := context.WithValue(context.Background(), courseKey{}, course)
+	return &DataBase{db.DB.WithContext(ctx), db.changes}
+}
+
+func registerCourseCallbacks(db *gorm.DB) error {
+	callbacks := db.Callback()
+	if err := callbacks.Create().Before("gorm:create").Register("notmanytask:course_create", setCourse); err != nil {
+		return errors.Wrap(err, "Failed to register create callback")
+	}
+	if err := callbacks.Query().Before("gorm:query").Register("notmanytask:course_query", filterCourse); err != nil {
+		return errors.Wrap(err, "Failed to register query callback")
+	}
+	if err := callbacks.Update().Before("gorm:update").Register("notmanytask:course_update", filterCourse); err != nil {
+		return errors.Wrap(err, "Failed to register update callback")
+	}
+	if err := callbacks.Delete().Before("gorm:delete").Register("notmanytask:course_delete", filterCourse); err != nil {
+		return errors.Wrap(err, "Failed to register delete callback")
+	}
+	return nil
+}
+
+func statementCourse(db *gorm.DB) (string, bool) {
+	stmt := db.Statement
+	if stmt.Context == nil || stmt.Schema == nil || stmt.Schema.LookUpField(courseField) == nil {
+		return "", false
+	}
+	course, ok := stmt.Context.Value(courseKey{}).(string)
+	return course, ok
+}
+
+func filterCourse(db *gorm.DB) {
+	course, ok := statementCourse(db)
+	if !ok {
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: "course"}, Value: course},
+	}})
+}
+
+func setCourse(db *gorm.DB) {
+	course, ok := statementCourse(db)
+	if !ok {
+		return
+	}
+	field := db.Statement.Schema.LookUpField(courseField)
+	value := db.Statement.ReflectValue
+	switch value.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < value.Len(); i++ {
+			if err := field.Set(reflect.Indirect(value.Index(i)), course); err != nil {
+				_ = db.AddError(err)
+			}
+		}
+	case reflect.Struct:
+		if err := field.Set(value, course); err != nil {
+			_ = db.AddError(err)
+		}
+	}
+}
@@ -19,6 +19,7 @@ import (
 
 type DataBase struct {
 	*gorm.DB
+	changes *changeFeed
 }
 
 type DuplicateKey struct {
@@ -58,12 +59,49 @@ func OpenDataBase(logger *zap.Logger, dsn string) (*DataBase, error) {
 		return nil, err
 	}
 
-	err = db.AutoMigrate(&models.User{}, &models.Pipeline{}, &models.Session{}, &models.Flag{}, &models.MergeRequest{})
+	if err = registerCourseCallbacks(db); err != nil {
+		return nil, err
+	}
+
+	changes := &changeFeed{}
+	if err = registerChangeCallbacks(db, changes); err != nil {
+		return nil, err
+	}
+
+	if err = migrateCourses(db); err != nil {
+		return nil, errors.Wrap(err, "Failed to migrate to per-course schema")
+	}
+
+	err = db.AutoMigrate(&models.User{}, &models.Pipeline{}, &models.Session{}, &models.Flag{}, &models.MergeRequest{}, &models.Extension{}, &models.ScoreOverride{}, &models.SyncCursor{}, &models.FlagSubmission{}, &models.TemplateUpdate{}, &models.ProvisioningJob{}, &models.ScoreSnapshot{}, &models.DeadlinesRevision{})
 	if err != nil {
 		return nil, err
 	}
 
-	return &DataBase{db}, nil
+	return &DataBase{db, changes}, nil
+}
+
+// migrateCourses drops the constraints of the single course schema,
+// AutoMigrate then recreates them including the course column
+func migrateCourses(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&models.User{}) {
+		return nil
+	}
+	for _, index := range []string{"idx_users_gitlab_id", "idx_users_gitlab_login", "idx_name"} {
+		if !migrator.HasIndex(&models.User{}, index) {
+			continue
+		}
+		if err := migrator.DropIndex(&models.User{}, index); err != nil {
+			return errors.Wrapf(err, "Failed to drop index %s", index)
+		}
+	}
+	// Primary key can not be altered by AutoMigrate, cursors are just a cache
+	if migrator.HasTable(&models.SyncCursor{}) && !migrator.HasColumn(&models.SyncCursor{}, "course") {
+		if err := migrator.DropTable(&models.SyncCursor{}); err != nil {
+			return errors.Wrap(err, "Failed to drop sync cursors")
+		}
+	}
+	return nil
 }
 
 func (db *DataBase) AddUser(user *models.User) (*models.User, error) {
@@ -105,6 +143,48 @@ func (db *DataBase) FindUserByGitlabID(id int) (*models.User, error) {
 	return &user, nil
 }
 
+func (db *DataBase) FindUserByApiToken(token string) (*models.User, error) {
+	var user models.User
+	err := db.First(&user, "api_token = ?", token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (db *DataBase) ResetUserApiToken(uid uint) (string, error) {
+	token := uuid.New().String()
+	res := db.Model(&models.User{}).Where("id = ?", uid).Update("api_token", token)
+	if res.Error != nil {
+		return "", res.Error
+	}
+	if res.RowsAffected < 1 {
+		return "", errors.Errorf("Unknown user %d", uid)
+	}
+	return token, nil
+}
+
+func (db *DataBase) FindUserByFeedToken(token string) (*models.User, error) {
+	var user models.User
+	err := db.First(&user, "feed_token = ?", token).Error
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (db *DataBase) ResetUserFeedToken(uid uint) (string, error) {
+	token := uuid.New().String()
+	res := db.Model(&models.User{}).Where("id = ?", uid).Update("feed_token", token)
+	if res.Error != nil {
+		return "", res.Error
+	}
+	if res.RowsAffected < 1 {
+		return "", errors.Errorf("Unknown user %d", uid)
+	}
+	return token, nil
+}
+
 func (db *DataBase) ListUsersWithoutRepos() ([]*models.User, error) {
 	var users []*models.User
 	err := db.Find(&users, "repository IS NULL AND gitlab_id IS NOT NULL AND gitlab_login IS NOT NULL").Error
@@ -127,10 +207,62 @@ func (db *DataBase) ListGroupUsers(groupName string, subgroupName string) ([]*mo
 			return nil, err
 		}
 	}
-	
+
+	return users, nil
+}
+
+// ListUsers lists all registered users, empty groupName or subgroupName matches any group
+func (db *DataBase) ListUsers(groupName string, subgroupName string) ([]*models.User, error) {
+	var users []*models.User
+	query := db.Order("group_name, subgroup_name, last_name, first_name")
+	if groupName != "" {
+		query = query.Where("group_name = ?", groupName)
+	}
+	if subgroupName != "" {
+		query = query.Where("subgroup_name = ?", subgroupName)
+	}
+	err := query.Find(&users).Error
+	if err != nil {
+		return nil, err
+	}
 	return users, nil
 }
 
+func (db *DataBase) SetUserAdmin(uid uint, isAdmin bool) error {
+	res := db.Model(&models.User{}).Where("id = ?", uid).Update("is_admin", isAdmin)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected < 1 {
+		return errors.Errorf("Unknown user %d", uid)
+	}
+	return nil
+}
+
+func (db *DataBase) SetUserTimeZone(uid uint, timeZone string) error {
+	return db.Model(&models.User{}).Where("id = ?", uid).Update("time_zone", timeZone).Error
+}
+
+// SyncAdmins grants admin role to the listed users and revokes it from everyone else
+func (db *DataBase) SyncAdmins(gitlabLogins []string) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		demote := tx.Model(&models.User{}).Where("is_admin")
+		if len(gitlabLogins) > 0 {
+			demote = demote.Where("gitlab_login IS NULL OR gitlab_login NOT IN ?", gitlabLogins)
+		}
+		if err := demote.Update("is_admin", false).Error; err != nil {
+			return errors.Wrap(err, "Failed to demote admins")
+		}
+		if len(gitlabLogins) == 0 {
+			return nil
+		}
+		if err := tx.Model(&models.User{}).Where("gitlab_login IN ?", gitlabLogins).Update("is_admin", true).Error; err != nil {
+			return errors.Wrap(err, "Failed to promote admins")
+		}
+		return nil
+	})
+}
+
 func (db *DataBase) SetUserGitlabAccount(uid uint, user *models.GitlabUser) error {
 	res := db.Model(&models.User{}).
 		Where("id = ? AND (gitlab_id IS NULL OR gitlab_login IS NULL)", uid).
@@ -170,6 +302,244 @@ func (db *DataBase) AddPipeline(pipeline *models.Pipeline) error {
 	}).Create(pipeline).Error
 }
 
+// EnqueueProvisioningJob schedules the immediate attempt unless the job is already running
+func (db *DataBase) EnqueueProvisioningJob(user uint, now time.Time) error {
+	return db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"state":           models.ProvisioningJobPending,
+			"attempts":        0,
+			"next_attempt_at": now,
+			"updated_at":      now,
+		}),
+		Where: clause.Where{Exprs: []clause.Expression{
+			clause.Neq{Column: clause.Column{Table: "provisioning_jobs", Name: "state"}, Value: models.ProvisioningJobRunning},
+		}},
+	}).Create(&models.ProvisioningJob{
+		UserID:        user,
+		State:         models.ProvisioningJobPending,
+		NextAttemptAt: now,
+	}).Error
+}
+
+// EnqueueMissingProvisioningJobs creates jobs for users without repository and job
+func (db *DataBase) EnqueueMissingProvisioningJobs(now time.Time) (int, error) {
+	users, err := db.ListUsersWithoutRepos()
+	if err != nil {
+		return 0, err
+	}
+	var known []uint
+	if err = db.Model(&models.ProvisioningJob{}).Pluck("user_id", &known).Error; err != nil {
+		return 0, err
+	}
+	queued := make(map[uint]bool, len(known))
+	for _, id := range known {
+		queued[id] = true
+	}
+
+	enqueued := 0
+	for _, user := range users {
+		if queued[user.ID] {
+			continue
+		}
+		if err = db.EnqueueProvisioningJob(user.ID, now); err != nil {
+			return enqueued, err
+		}
+		enqueued++
+	}
+	return enqueued, nil
+}
+
+func (db *DataBase) ListDueProvisioningJobs(now time.Time, limit int) (jobs []models.ProvisioningJob, err error) {
+	jobs = make([]models.ProvisioningJob, 0)
+	err = db.Where("state = ? AND next_attempt_at <= ?", models.ProvisioningJobPending, now).
+		Order("next_attempt_at").
+		Limit(limit).
+		Find(&jobs).Error
+	if err != nil {
+		jobs = nil
+	}
+	return
+}
+
+// ClaimProvisioningJob marks the pending job as running, returns false if it was claimed by someone else
+func (db *DataBase) ClaimProvisioningJob(job *models.ProvisioningJob) (bool, error) {
+	res := db.Model(job).
+		Where("state = ?", models.ProvisioningJobPending).
+		Updates(map[string]interface{}{
+			"state":    models.ProvisioningJobRunning,
+			"attempts": gorm.Expr("attempts + 1"),
+		})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	if res.RowsAffected < 1 {
+		return false, nil
+	}
+	job.State = models.ProvisioningJobRunning
+	job.Attempts++
+	return true, nil
+}
+
+func (db *DataBase) FinishProvisioningJob(job *models.ProvisioningJob) error {
+	return db.Model(job).Updates(map[string]interface{}{
+		"state":           job.State,
+		"next_attempt_at": job.NextAttemptAt,
+		"last_error":      job.LastError,
+	}).Error
+}
+
+// ResetRunningProvisioningJobs requeues jobs interrupted by the restart
+func (db *DataBase) ResetRunningProvisioningJobs(now time.Time) (int64, error) {
+	res := db.Model(&models.ProvisioningJob{}).
+		Where("state = ?", models.ProvisioningJobRunning).
+		Updates(map[string]interface{}{
+			"state":           models.ProvisioningJobPending,
+			"next_attempt_at": now,
+		})
+	return res.RowsAffected, res.Error
+}
+
+func (db *DataBase) RetryProvisioningJob(id uint, now time.Time) error {
+	res := db.Model(&models.ProvisioningJob{}).
+		Where("id = ? AND state = ?", id, models.ProvisioningJobFailed).
+		Updates(map[string]interface{}{
+			"state":           models.ProvisioningJobPending,
+			"attempts":        0,
+			"next_attempt_at": now,
+		})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected < 1 {
+		return errors.Errorf("Unknown failed job %d", id)
+	}
+	return nil
+}
+
+func (db *DataBase) ListProvisioningJobs() (jobs []models.ProvisioningJob, err error) {
+	jobs = make([]models.ProvisioningJob, 0)
+	err = db.Order("updated_at DESC").Find(&jobs).Error
+	if err != nil {
+		jobs = nil
+	}
+	return
+}
+
+func (db *DataBase) AddScoreSnapshots(snapshots []models.ScoreSnapshot) error {
+	if len(snapshots) == 0 {
+		return nil
+	}
+	return db.CreateInBatches(snapshots, 100).Error
+}
+
+// FindLatestScoreSnapshot returns nil if no snapshots were saved yet
+func (db *DataBase) FindLatestScoreSnapshot() (*models.ScoreSnapshot, error) {
+	var snapshot models.ScoreSnapshot
+	res := db.Order("created_at DESC").Take(&snapshot)
+	if res.Error == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	return &snapshot, nil
+}
+
+func (db *DataBase) ListUserScoreSnapshots(gitlabLogin string, since time.Time) (snapshots []models.ScoreSnapshot, err error) {
+	snapshots = make([]models.ScoreSnapshot, 0)
+	err = db.Where("gitlab_login = ? AND created_at >= ?", gitlabLogin, since).Order("created_at").Find(&snapshots).Error
+	if err != nil {
+		snapshots = nil
+	}
+	return
+}
+
+func (db *DataBase) ListGroupScoreSnapshots(groupName string, subgroupName string, since time.Time) (snapshots []models.ScoreSnapshot, err error) {
+	snapshots = make([]models.ScoreSnapshot, 0)
+	query := db.Where("group_name = ? AND created_at >= ?", groupName, since)
+	if subgroupName != "" {
+		query = query.Where("subgroup = ?", subgroupName)
+	}
+	err = query.Order("created_at").Find(&snapshots).Error
+	if err != nil {
+		snapshots = nil
+	}
+	return
+}
+
+func (db *DataBase) AddDeadlinesRevision(revision *models.DeadlinesRevision) error {
+	return db.Create(revision).Error
+}
+
+func (db *DataBase) FindLatestDeadlinesRevision(groupName string) (*models.DeadlinesRevision, error) {
+	var revision models.DeadlinesRevision
+	res := db.Where("group_name = ?", groupName).Order("id DESC").Take(&revision)
+	if res.Error == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	return &revision, nil
+}
+
+func (db *DataBase) ListDeadlinesRevisions(limit int) (revisions []models.DeadlinesRevision, err error) {
+	revisions = make([]models.DeadlinesRevision, 0)
+	err = db.Order("id DESC").Limit(limit).Find(&revisions).Error
+	if err != nil {
+		revisions = nil
+	}
+	return
+}
+
+func (db *DataBase) FindTemplateUpdate(project string) (*models.TemplateUpdate, error) {
+	var update models.TemplateUpdate
+	res := db.DB.Where("project = ?", project).Take(&update)
+	if res.Error == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	return &update, nil
+}
+
+func (db *DataBase) SaveTemplateUpdate(update *models.TemplateUpdate) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "course"}, {Name: "project"}},
+		DoUpdates: clause.AssignmentColumns([]string{"template_commit", "status", "merge_request_iid", "merge_request_url", "error", "updated_at"}),
+	}).Create(update).Error
+}
+
+func (db *DataBase) ListTemplateUpdates() (updates []models.TemplateUpdate, err error) {
+	updates = make([]models.TemplateUpdate, 0)
+	err = db.Order("project").Find(&updates).Error
+	if err != nil {
+		updates = nil
+	}
+	return
+}
+
+func (db *DataBase) FindSyncCursor(project string) (*models.SyncCursor, error) {
+	var cursor models.SyncCursor
+	res := db.DB.Where("project = ?", project).Take(&cursor)
+	if res.Error == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	return &cursor, nil
+}
+
+func (db *DataBase) UpdateSyncCursor(cursor *models.SyncCursor) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "course"}, {Name: "project"}},
+		DoUpdates: clause.AssignmentColumns([]string{"pipelines_updated_at", "last_pipeline_id"}),
+	}).Create(cursor).Error
+}
+
 func (db *DataBase) ListProjectPipelines(project string) (pipelines []models.Pipeline, err error) {
 	pipelines = make([]models.Pipeline, 0)
 	err = db.Find(&pipelines, "project = ?", project).Error
@@ -197,10 +567,16 @@ func (db *DataBase) FindLatestPipeline(project string, task string) (*models.Pip
 	return &pipelines[0], err
 }
 
-func (db *DataBase) CreateSession(user uint) (*models.Session, error) {
+func (db *DataBase) CreateSession(user uint, userAgent string, ip string, ttl time.Duration) (*models.Session, error) {
+	now := time.Now()
 	session := &models.Session{
-		Token:  uuid.Must(uuid.NewUUID()).String(),
-		UserID: user,
+		Token:      uuid.Must(uuid.NewUUID()).String(),
+		UserID:     user,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(ttl),
+		UserAgent:  userAgent,
+		IP:         ip,
 	}
 	res := db.DB.Create(session)
 	if res.Error != nil {
@@ -209,6 +585,63 @@ func (db *DataBase) CreateSession(user uint) (*models.Session, error) {
 	return session, nil
 }
 
+// TouchSession prolongs session lifetime
+func (db *DataBase) TouchSession(session *models.Session, ip string, ttl time.Duration) error {
+	now := time.Now()
+	session.LastSeenAt = now
+	session.ExpiresAt = now.Add(ttl)
+	session.IP = ip
+	return db.Model(session).Updates(map[string]interface{}{
+		"last_seen_at": session.LastSeenAt,
+		"expires_at":   session.ExpiresAt,
+		"ip":           session.IP,
+	}).Error
+}
+
+func (db *DataBase) ListUserSessions(user uint) (sessions []models.Session, err error) {
+	sessions = make([]models.Session, 0)
+	err = db.Where("user_id = ? AND expires_at > ?", user, time.Now()).Order("last_seen_at DESC").Find(&sessions).Error
+	if err != nil {
+		sessions = nil
+	}
+	return
+}
+
+// DeleteUserSession revokes session only if it belongs to the user
+func (db *DataBase) DeleteUserSession(user uint, id uint) error {
+	res := db.Where("user_id = ? AND id = ?", user, id).Delete(&models.Session{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return errors.New("Unknown session")
+	}
+	return nil
+}
+
+func (db *DataBase) DeleteUserSessions(user uint) error {
+	return db.Where("user_id = ?", user).Delete(&models.Session{}).Error
+}
+
+func (db *DataBase) DeleteSessionByToken(token string) error {
+	return db.Where("token = ?", token).Delete(&models.Session{}).Error
+}
+
+// BackfillSessions gives sessions created before expiration was introduced the full ttl,
+// so they are neither swept nor treated as expired
+func (db *DataBase) BackfillSessions(now time.Time, ttl time.Duration) (int64, error) {
+	res := db.Model(&models.Session{}).Where("expires_at IS NULL").Updates(map[string]interface{}{
+		"last_seen_at": gorm.Expr("COALESCE(last_seen_at, created_at)"),
+		"expires_at":   now.Add(ttl),
+	})
+	return res.RowsAffected, res.Error
+}
+
+func (db *DataBase) DeleteExpiredSessions(now time.Time) (int64, error) {
+	res := db.Where("expires_at <= ?", now).Delete(&models.Session{})
+	return res.RowsAffected, res.Error
+}
+
 func (db *DataBase) FindSession(token string) (*models.Session, error) {
 	var session models.Session
 	res := db.DB.Where("token", token).Take(&session)
@@ -233,6 +666,49 @@ func (db *DataBase) FindUserBySession(token string) (*models.User, *models.Sessi
 	return user, session, nil
 }
 
+func (db *DataBase) FindFlag(id string) (*models.Flag, error) {
+	var flag models.Flag
+	res := db.DB.Where("id = ?", id).Take(&flag)
+	if res.Error == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	return &flag, nil
+}
+
+func (db *DataBase) AddFlagSubmission(submission *models.FlagSubmission) error {
+	return db.Create(submission).Error
+}
+
+func (db *DataBase) ListUserFlagSubmissions(user uint, limit int) (submissions []models.FlagSubmission, err error) {
+	submissions = make([]models.FlagSubmission, 0)
+	err = db.Where("user_id = ?", user).Order("created_at DESC").Limit(limit).Find(&submissions).Error
+	if err != nil {
+		submissions = nil
+	}
+	return
+}
+
+// CountWrongFlagSubmissions counts attempts with outcomes of models.WrongFlagSubmissionOutcomes
+func (db *DataBase) CountWrongFlagSubmissions(user uint, since time.Time) (count int64, err error) {
+	err = db.Model(&models.FlagSubmission{}).
+		Where("user_id = ? AND created_at >= ? AND outcome IN ?", user, since, models.WrongFlagSubmissionOutcomes).
+		Count(&count).Error
+	return
+}
+
+// ListForeignFlagSubmissions lists submissions of flags issued for another student
+func (db *DataBase) ListForeignFlagSubmissions() (submissions []models.FlagSubmission, err error) {
+	submissions = make([]models.FlagSubmission, 0)
+	err = db.Where("flag_owner IS NOT NULL AND flag_owner <> gitlab_login").Order("created_at DESC").Find(&submissions).Error
+	if err != nil {
+		submissions = nil
+	}
+	return
+}
+
 func (db *DataBase) CreateFlag(task string) (*models.Flag, error) {
 	flag := &models.Flag{
 		ID:        fmt.Sprintf("{FLAG-%s-%s}", task, uuid.New().String()),
@@ -246,8 +722,15 @@ func (db *DataBase) CreateFlag(task string) (*models.Flag, error) {
 	return flag, nil
 }
 
+// AddFlag keeps the stored flag on conflict, flags signed for the same task, login and second are identical
+func (db *DataBase) AddFlag(flag *models.Flag) error {
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(flag).Error
+}
+
 func (db *DataBase) SubmitFlag(id string, gitlabLogin string) error {
-	result := db.Model(&models.Flag{}).Where("id = ? AND gitlab_login IS NULL", id).Update("gitlab_login", gitlabLogin)
+	result := db.Model(&models.Flag{}).
+		Where("id = ? AND gitlab_login IS NULL AND (owner IS NULL OR owner = ?)", id, gitlabLogin).
+		Update("gitlab_login", gitlabLogin)
 	if goerrors.Is(result.Error, gorm.ErrRecordNotFound) {
 		return errors.New("Unknown flag")
 	}
@@ -314,3 +797,55 @@ func (db *DataBase) ListAllMergeRequests() (mergeRequests []models.MergeRequest,
 	}
 	return
 }
+
+func (db *DataBase) AddExtension(extension *models.Extension) error {
+	if (extension.Task == "") == (extension.TaskGroup == "") {
+		return errors.New("Extension should be granted either for task or for task group")
+	}
+	return db.Create(extension).Error
+}
+
+func (db *DataBase) ListUserExtensions(userID uint) (extensions []models.Extension, err error) {
+	extensions = make([]models.Extension, 0)
+	err = db.Order("created_at").Find(&extensions, "user_id = ?", userID).Error
+	if err != nil {
+		extensions = nil
+	}
+	return
+}
+
+func (db *DataBase) ListAllExtensions() (extensions []models.Extension, err error) {
+	extensions = make([]models.Extension, 0)
+	err = db.Order("created_at").Find(&extensions).Error
+	if err != nil {
+		extensions = nil
+	}
+	return
+}
+
+func (db *DataBase) AddScoreOverride(override *models.ScoreOverride) error {
+	switch override.Kind {
+	case models.ScoreOverrideSet, models.ScoreOverrideDelta, models.ScoreOverrideReset:
+	default:
+		return errors.Errorf("Unknown score override kind %q", override.Kind)
+	}
+	return db.Create(override).Error
+}
+
+func (db *DataBase) ListUserScoreOverrides(userID uint) (overrides []models.ScoreOverride, err error) {
+	overrides = make([]models.ScoreOverride, 0)
+	err = db.Order("created_at").Find(&overrides, "user_id = ?", userID).Error
+	if err != nil {
+		overrides = nil
+	}
+	return
+}
+
+func (db *DataBase) ListAllScoreOverrides() (overrides []models.ScoreOverride, err error) {
+	overrides = make([]models.ScoreOverride, 0)
+	err = db.Order("created_at").Find(&overrides).Error
+	if err != nil {
+		overrides = nil
+	}
+	return
+}
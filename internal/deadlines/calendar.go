@@ -0,0 +1,138 @@
+package deadlines
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const calendarTimeFormat = "20060102T150405Z"
+
+// CalendarParams describes the iCalendar feed of the deadlines
+type CalendarParams struct {
+	Name string
+	// Host part of event UIDs, UIDs must not change for calendar apps to update events
+	Domain string
+	// Namespace of event UIDs, task group names are unique only within it
+	Namespace string
+	// Link shown in every event
+	URL string
+
+	// Personal deadlines of the feed owner by task group and by task
+	GroupExtensions map[string]time.Time
+	TaskExtensions  map[string]time.Time
+
+	// Task groups not matched are skipped
+	Filter func(group *TaskGroup) bool
+	Now    time.Time
+}
+
+// WriteCalendar writes an event per task group lasting from its start to its deadline
+func WriteCalendar(w io.Writer, deadlines Deadlines, params CalendarParams) error {
+	cal := &calendarWriter{w: w}
+	cal.line("BEGIN:VCALENDAR")
+	cal.line("VERSION:2.0")
+	cal.line("PRODID:-//notmanytask//deadlines//EN")
+	cal.line("CALSCALE:GREGORIAN")
+	cal.line("METHOD:PUBLISH")
+	if params.Name != "" {
+		cal.line("X-WR-CALNAME:" + escapeText(params.Name))
+	}
+
+	for i := range deadlines {
+		group := &deadlines[i]
+		if params.Filter != nil && !params.Filter(group) {
+			continue
+		}
+
+		deadline := group.Deadline.Time
+		if extended, found := params.GroupExtensions[group.Group]; found {
+			deadline = extended
+		}
+		start := deadline
+		if !group.Start.IsZero() && group.Start.Before(deadline) {
+			start = group.Start.Time
+		}
+
+		cal.line("BEGIN:VEVENT")
+		cal.line(fmt.Sprintf("UID:%s@%s", escapeText(params.Namespace+"-"+group.Group), params.Domain))
+		cal.line("DTSTAMP:" + formatCalendarTime(params.Now))
+		cal.line("DTSTART:" + formatCalendarTime(start))
+		cal.line("DTEND:" + formatCalendarTime(deadline))
+		cal.line("SUMMARY:" + escapeText("Deadline: "+group.Group))
+		cal.line("DESCRIPTION:" + escapeText(describeGroup(group, params.TaskExtensions)))
+		if params.URL != "" {
+			cal.line("URL:" + params.URL)
+		}
+		cal.line("TRANSP:TRANSPARENT")
+		cal.line("BEGIN:VALARM")
+		cal.line("ACTION:DISPLAY")
+		cal.line("DESCRIPTION:" + escapeText("Deadline: "+group.Group))
+		cal.line("TRIGGER;RELATED=END:-P1D")
+		cal.line("END:VALARM")
+		cal.line("END:VEVENT")
+	}
+
+	cal.line("END:VCALENDAR")
+	return cal.err
+}
+
+func describeGroup(group *TaskGroup, extensions map[string]time.Time) string {
+	var b strings.Builder
+	maxScore := 0
+	for _, task := range group.Tasks {
+		maxScore += task.Score
+		fmt.Fprintf(&b, "%s: %d", task.Task, task.Score)
+		if extended, found := extensions[task.Task]; found {
+			fmt.Fprintf(&b, " (extended until %s)", extended.In(group.Deadline.Location()).Format(displayFormat))
+		}
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "Max score: %d", maxScore)
+	return b.String()
+}
+
+func formatCalendarTime(t time.Time) string {
+	return t.UTC().Format(calendarTimeFormat)
+}
+
+// escapeText escapes TEXT values as defined in RFC 5545
+func escapeText(text string) string {
+	return strings.NewReplacer(
+		`\`, `\\`,
+		";", `\;`,
+		",", `\,`,
+		"\r\n", `\n`,
+		"\n", `\n`,
+	).Replace(text)
+}
+
+type calendarWriter struct {
+	w   io.Writer
+	err error
+}
+
+// line folds content lines longer than 75 octets without breaking UTF-8 sequences
+func (c *calendarWriter) line(text string) {
+	if c.err != nil {
+		return
+	}
+
+	const maxLine = 75
+	var b strings.Builder
+	width := 0
+	for _, r := range text {
+		size := utf8.RuneLen(r)
+		if width+size > maxLine {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += size
+	}
+	b.WriteString("\r\n")
+
+	_, c.err = io.WriteString(c.w, b.String())
+}
@@ -0,0 +1,53 @@
+package deadlines
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWriteCalendar(t *testing.T) {
+	now := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	deadlines := Deadlines{
+		{Group: "1-intro", Start: Date{now.Add(-24 * time.Hour)}, Deadline: Date{now.Add(7 * 24 * time.Hour)}, Tasks: []Task{{Task: "hello, world", Score: 100}, {Task: "sum", Score: 200}}},
+		{Group: "2-hidden", Start: Date{now.Add(24 * time.Hour)}, Deadline: Date{now.Add(14 * 24 * time.Hour)}},
+	}
+
+	var buf bytes.Buffer
+	err := WriteCalendar(&buf, deadlines, CalendarParams{
+		Name:            "Course deadlines " + strings.Repeat("long ", 20),
+		Domain:          "example.com",
+		Namespace:       "course-students",
+		GroupExtensions: map[string]time.Time{"1-intro": now.Add(10 * 24 * time.Hour)},
+		Filter: func(group *TaskGroup) bool {
+			return group.Released(now)
+		},
+		Now: now,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cal := buf.String()
+
+	for _, expected := range []string{
+		"BEGIN:VCALENDAR\r\n",
+		"UID:course-students-1-intro@example.com\r\n",
+		"DTSTART:20210228T120000Z\r\n",
+		"DTEND:20210311T120000Z\r\n",
+		`DESCRIPTION:hello\, world: 100\nsum: 200\nMax score: 300` + "\r\n",
+		"END:VCALENDAR\r\n",
+	} {
+		if !strings.Contains(cal, expected) {
+			t.Errorf("Calendar does not contain %q:\n%s", expected, cal)
+		}
+	}
+	if strings.Contains(cal, "2-hidden") {
+		t.Errorf("Calendar contains filtered task group")
+	}
+	for _, line := range strings.Split(cal, "\r\n") {
+		if len(line) > 75 {
+			t.Errorf("Line is not folded: %q", line)
+		}
+	}
+}
@@ -1,21 +1,42 @@
 package deadlines
 
 import (
+	"encoding/json"
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v2"
+
+	"github.com/bigredeye/notmanytask/internal/config"
 )
 
 type Date struct {
 	time.Time
 }
 
-// FIXME(BigRedEye): Do not hardcode Moscow
-
-const (
-	defaultTimeZone = "Europe/Moscow"
-	dateFormat      = "02-01-2006 15:04"
-)
+const displayFormat = "02-01-2006 15:04"
+
+// Layouts accepted in deadlines, dates without offset are in the group time zone
+var dateLayouts = []string{
+	displayFormat,
+	"02-01-2006 15:04:05",
+	"02-01-2006 15:04 Z07:00",
+	"02-01-2006 15:04 -0700",
+	"02.01.2006 15:04",
+	time.RFC3339,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04Z07:00",
+	"2006-01-02 15:04 Z07:00",
+	"2006-01-02 15:04 -0700",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+}
 
 var defaultLoc *time.Location
 var defaultLocOnce sync.Once
@@ -23,7 +44,7 @@ var defaultLocOnce sync.Once
 func getDefaultLocation() *time.Location {
 	defaultLocOnce.Do(func() {
 		var err error
-		defaultLoc, err = time.LoadLocation(defaultTimeZone)
+		defaultLoc, err = time.LoadLocation(config.DefaultTimeZone)
 		if err != nil {
 			panic(err)
 		}
@@ -32,25 +53,46 @@ func getDefaultLocation() *time.Location {
 	return defaultLoc
 }
 
+// DefaultLocation is used for deadlines parsed without a configured time zone
+func DefaultLocation() *time.Location {
+	return getDefaultLocation()
+}
+
+// ParseDate parses any of the supported layouts, dates without offset are interpreted in loc
+func ParseDate(text string, loc *time.Location) (Date, error) {
+	text = strings.TrimSpace(text)
+	for _, layout := range dateLayouts {
+		if t, err := time.ParseInLocation(layout, text, loc); err == nil {
+			return Date{t}, nil
+		}
+	}
+	return Date{}, errors.Errorf("Unknown date format %q", text)
+}
+
 func (t *Date) String() string {
-	return t.Time.Format(dateFormat)
+	return t.Time.Format(displayFormat)
 }
 
 func (t *Date) UnmarshalText(buf []byte) error {
-	tt, err := time.ParseInLocation(dateFormat, strings.TrimSpace(string(buf)), getDefaultLocation())
+	date, err := ParseDate(string(buf), getDefaultLocation())
 	if err != nil {
 		return err
 	}
-	t.Time = tt
+	*t = date
 	return nil
 }
 
+// MarshalText keeps the offset, so the date is restored exactly
 func (t Date) MarshalText() ([]byte, error) {
-	return []byte(t.String()), nil
+	return []byte(t.Time.Format(time.RFC3339)), nil
 }
 
 func (t *Date) UnmarshalJSON(buf []byte) error {
-	return t.UnmarshalText(buf[1 : len(buf)-2])
+	var text string
+	if err := json.Unmarshal(buf, &text); err != nil {
+		return err
+	}
+	return t.UnmarshalText([]byte(text))
 }
 
 func (t Date) MarshalJSON() ([]byte, error) {
@@ -58,16 +100,15 @@ func (t Date) MarshalJSON() ([]byte, error) {
 	if err != nil {
 		return text, err
 	}
-	res := make([]byte, 0, len(text)+2)
-	res = append(res, '"')
-	res = append(res, text...)
-	res = append(res, '"')
-	return res, nil
+	return json.Marshal(string(text))
 }
 
 type Task struct {
 	Task  string
 	Score int
+
+	// Overrides group scoring policy
+	Scoring *config.ScoringConfig
 }
 
 type TaskGroup struct {
@@ -76,7 +117,83 @@ type TaskGroup struct {
 	Deadline Date
 	Start    Date
 
+	// Overrides course-wide scoring policy
+	Scoring *config.ScoringConfig
+
 	Tasks []Task
 }
 
 type Deadlines = []TaskGroup
+
+// Parse decodes deadlines, dates without explicit offset are interpreted in loc
+func Parse(body []byte, loc *time.Location) (Deadlines, error) {
+	deadlines := Deadlines{}
+	if err := yaml.Unmarshal(body, &deadlines); err != nil {
+		return nil, errors.Wrap(err, "Failed to unmarshal deadlines")
+	}
+
+	// Plain unmarshalling uses the default location, so parse the dates again
+	dates := []struct {
+		Start    string
+		Deadline string
+	}{}
+	if err := yaml.Unmarshal(body, &dates); err != nil {
+		return nil, errors.Wrap(err, "Failed to unmarshal deadline dates")
+	}
+	for i := range deadlines {
+		group := &deadlines[i]
+		var err error
+		if dates[i].Start != "" {
+			if group.Start, err = ParseDate(dates[i].Start, loc); err != nil {
+				return nil, errors.Wrapf(err, "Invalid start of group %s", group.Group)
+			}
+		}
+		if dates[i].Deadline != "" {
+			if group.Deadline, err = ParseDate(dates[i].Deadline, loc); err != nil {
+				return nil, errors.Wrapf(err, "Invalid deadline of group %s", group.Group)
+			}
+		}
+	}
+	return deadlines, nil
+}
+
+// Released reports whether tasks of the group are available at the given moment,
+// groups without start date are always available
+func (g *TaskGroup) Released(now time.Time) bool {
+	return !now.Before(g.Start.Time)
+}
+
+// NextRelease returns the earliest start of a group which is not released yet
+func NextRelease(deadlines *Deadlines, now time.Time) (time.Time, bool) {
+	var next time.Time
+	for i := range *deadlines {
+		group := &(*deadlines)[i]
+		if !group.Released(now) && (next.IsZero() || group.Start.Before(next)) {
+			next = group.Start.Time
+		}
+	}
+	return next, !next.IsZero()
+}
+
+// FindGroup returns the task group by name, nil if there is no such group
+func FindGroup(deadlines *Deadlines, name string) *TaskGroup {
+	for i := range *deadlines {
+		if (*deadlines)[i].Group == name {
+			return &(*deadlines)[i]
+		}
+	}
+	return nil
+}
+
+// FindTask returns the task and its group by task name, nils if there is no such task
+func FindTask(deadlines *Deadlines, name string) (*TaskGroup, *Task) {
+	for i := range *deadlines {
+		group := &(*deadlines)[i]
+		for j := range group.Tasks {
+			if group.Tasks[j].Task == name {
+				return group, &group.Tasks[j]
+			}
+		}
+	}
+	return nil, nil
+}
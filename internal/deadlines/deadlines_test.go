@@ -1,12 +1,15 @@
 package deadlines
 
 import (
+	"encoding/json"
 	"fmt"
 	"testing"
 	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"gopkg.in/yaml.v2"
+
+	"github.com/bigredeye/notmanytask/internal/config"
 )
 
 const smallConcurrencyYaml = `
@@ -97,3 +100,132 @@ func TestDeadlinesParsing(t *testing.T) {
 		t.Fatal("Not equal")
 	}
 }
+
+const zonedYaml = `
+- group:    Intro
+  start:    2021-01-01T00:00:00Z
+  deadline: 28-02-2021 23:59
+  tasks:
+    - task: intro/aplusb
+      score: 0
+
+- group:    Lock-free
+  start:    2021-01-01 00:00 +0300
+  deadline: 08.06.2021 23:59
+  tasks: []
+`
+
+func TestParseInLocation(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	deadlines, err := Parse([]byte(zonedYaml), berlin)
+	if err != nil {
+		t.Fatal("Failed to parse deadlines:", err)
+	}
+
+	for i, expected := range []struct{ start, deadline time.Time }{
+		{time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2021, 2, 28, 23, 59, 0, 0, berlin)},
+		{time.Date(2020, 12, 31, 21, 0, 0, 0, time.UTC), time.Date(2021, 6, 8, 23, 59, 0, 0, berlin)},
+	} {
+		if !deadlines[i].Start.Equal(expected.start) || !deadlines[i].Deadline.Equal(expected.deadline) {
+			t.Errorf("Unexpected dates of %s: %v, %v", deadlines[i].Group, deadlines[i].Start, deadlines[i].Deadline)
+		}
+	}
+
+	if _, err = Parse([]byte("- group: x\n  deadline: tomorrow\n"), berlin); err == nil {
+		t.Errorf("Invalid date is accepted")
+	}
+}
+
+func TestDateJSON(t *testing.T) {
+	date, err := ParseDate("2021-03-20 23:59 +0500", getDefaultLocation())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	buf, err := json.Marshal(date)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(buf) != `"2021-03-20T23:59:00+05:00"` {
+		t.Errorf("Unexpected JSON %s", buf)
+	}
+
+	var decoded Date
+	if err = json.Unmarshal(buf, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if !decoded.Equal(date.Time) {
+		t.Errorf("Date changed after round trip: %v != %v", decoded, date)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	day := func(d int) Date {
+		return Date{time.Date(2021, 3, d, 0, 0, 0, 0, time.UTC)}
+	}
+
+	valid := Deadlines{
+		{Group: "a", Start: day(1), Deadline: day(10), Tasks: []Task{{Task: "x", Score: 1}, {Task: "y", Score: 100}}},
+	}
+	if err := Validate(valid, nil); err != nil {
+		t.Errorf("Valid deadlines are rejected: %v", err)
+	}
+
+	for _, invalid := range []Deadlines{
+		{{Group: "a", Deadline: day(10), Tasks: []Task{{Task: "x", Score: 100}, {Task: "x", Score: 100}}}},
+		{{Group: "a", Deadline: day(10)}, {Group: "b", Deadline: day(10), Tasks: []Task{{Task: "x", Score: 100}}}, {Group: "c", Deadline: day(10), Tasks: []Task{{Task: "x", Score: 100}}}},
+		{{Group: "a", Deadline: day(10), Tasks: []Task{{Task: "x", Score: -1}}}},
+		{{Group: "a", Deadline: day(10), Tasks: []Task{{Task: "x", Score: 0}}}},
+		{{Group: "a", Start: day(10), Deadline: day(1)}},
+		{{Group: "a"}},
+		{{Group: "a", Deadline: day(10)}, {Group: "a", Deadline: day(10)}},
+	} {
+		if err := Validate(invalid, nil); err == nil {
+			t.Errorf("Invalid deadlines are accepted: %+v", invalid)
+		}
+	}
+
+	unknownPolicy := fmt.Errorf("unknown policy")
+	withScoring := Deadlines{{Group: "a", Deadline: day(10), Scoring: &config.ScoringConfig{Policy: "magic"}}}
+	if err := Validate(withScoring, func(*config.ScoringConfig) error { return unknownPolicy }); err == nil {
+		t.Errorf("Unknown scoring policy is accepted")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	day := func(d int) Date {
+		return Date{time.Date(2021, 3, d, 0, 0, 0, 0, time.UTC)}
+	}
+
+	from := Deadlines{
+		{Group: "a", Deadline: day(10), Tasks: []Task{{Task: "x", Score: 100}, {Task: "y", Score: 100}}},
+		{Group: "b", Deadline: day(20), Tasks: []Task{{Task: "z", Score: 100}}},
+	}
+	to := Deadlines{
+		{Group: "a", Deadline: day(12), Tasks: []Task{{Task: "x", Score: 200}, {Task: "z", Score: 100}, {Task: "w", Score: 50}}},
+	}
+
+	kinds := make(map[string]int)
+	for _, change := range Compare(from, to) {
+		kinds[change.Kind]++
+	}
+	expected := map[string]int{
+		ChangeDeadlineMoved: 1,
+		ChangeScoreChanged:  1,
+		ChangeTaskMoved:     1,
+		ChangeTaskAdded:     1,
+		ChangeTaskRemoved:   1,
+		ChangeGroupRemoved:  1,
+	}
+	if diff := cmp.Diff(expected, kinds); diff != "" {
+		t.Errorf("Unexpected changes (-want +got):\n%s", diff)
+	}
+
+	if changes := Compare(to, to); len(changes) != 0 {
+		t.Errorf("Equal deadlines differ: %v", changes)
+	}
+}
@@ -0,0 +1,127 @@
+package deadlines
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+)
+
+const (
+	ChangeGroupAdded     = "group_added"
+	ChangeGroupRemoved   = "group_removed"
+	ChangeStartMoved     = "start_moved"
+	ChangeDeadlineMoved  = "deadline_moved"
+	ChangeScoringChanged = "scoring_changed"
+	ChangeTaskAdded      = "task_added"
+	ChangeTaskRemoved    = "task_removed"
+	ChangeTaskMoved      = "task_moved"
+	ChangeScoreChanged   = "score_changed"
+)
+
+// Change is a single difference between two versions of deadlines
+type Change struct {
+	Kind string
+	// Task group of the change, the new one for moved tasks
+	Group string
+	// Empty for group changes
+	Task string
+	Old  string
+	New  string
+}
+
+func (c Change) String() string {
+	target := c.Group
+	if c.Task != "" {
+		target = c.Task
+	}
+	switch {
+	case c.Old != "" && c.New != "":
+		return fmt.Sprintf("%s %s: %s -> %s", c.Kind, target, c.Old, c.New)
+	case c.New != "":
+		return fmt.Sprintf("%s %s: %s", c.Kind, target, c.New)
+	case c.Old != "":
+		return fmt.Sprintf("%s %s: %s", c.Kind, target, c.Old)
+	default:
+		return fmt.Sprintf("%s %s", c.Kind, target)
+	}
+}
+
+func formatDiffDate(date Date) string {
+	if date.IsZero() {
+		return "none"
+	}
+	return date.Format(time.RFC3339)
+}
+
+// Compare lists changes of groups and tasks between two versions of deadlines
+func Compare(from Deadlines, to Deadlines) []Change {
+	changes := make([]Change, 0)
+
+	oldGroups := make(map[string]*TaskGroup, len(from))
+	oldTasks := make(map[string]*Task)
+	oldTaskGroups := make(map[string]string)
+	for i := range from {
+		group := &from[i]
+		oldGroups[group.Group] = group
+		for j := range group.Tasks {
+			oldTasks[group.Tasks[j].Task] = &group.Tasks[j]
+			oldTaskGroups[group.Tasks[j].Task] = group.Group
+		}
+	}
+
+	newGroups := make(map[string]bool, len(to))
+	newTasks := make(map[string]bool)
+	for i := range to {
+		group := &to[i]
+		newGroups[group.Group] = true
+
+		prev, found := oldGroups[group.Group]
+		if !found {
+			changes = append(changes, Change{Kind: ChangeGroupAdded, Group: group.Group, New: formatDiffDate(group.Deadline)})
+		} else {
+			if !prev.Start.Equal(group.Start.Time) {
+				changes = append(changes, Change{Kind: ChangeStartMoved, Group: group.Group, Old: formatDiffDate(prev.Start), New: formatDiffDate(group.Start)})
+			}
+			if !prev.Deadline.Equal(group.Deadline.Time) {
+				changes = append(changes, Change{Kind: ChangeDeadlineMoved, Group: group.Group, Old: formatDiffDate(prev.Deadline), New: formatDiffDate(group.Deadline)})
+			}
+			if !reflect.DeepEqual(prev.Scoring, group.Scoring) {
+				changes = append(changes, Change{Kind: ChangeScoringChanged, Group: group.Group})
+			}
+		}
+
+		for j := range group.Tasks {
+			task := &group.Tasks[j]
+			newTasks[task.Task] = true
+
+			prevTask, found := oldTasks[task.Task]
+			if !found {
+				changes = append(changes, Change{Kind: ChangeTaskAdded, Group: group.Group, Task: task.Task, New: fmt.Sprint(task.Score)})
+				continue
+			}
+			if prevGroup := oldTaskGroups[task.Task]; prevGroup != group.Group {
+				changes = append(changes, Change{Kind: ChangeTaskMoved, Group: group.Group, Task: task.Task, Old: prevGroup, New: group.Group})
+			}
+			if prevTask.Score != task.Score {
+				changes = append(changes, Change{Kind: ChangeScoreChanged, Group: group.Group, Task: task.Task, Old: fmt.Sprint(prevTask.Score), New: fmt.Sprint(task.Score)})
+			}
+			if !reflect.DeepEqual(prevTask.Scoring, task.Scoring) {
+				changes = append(changes, Change{Kind: ChangeScoringChanged, Group: group.Group, Task: task.Task})
+			}
+		}
+	}
+
+	for i := range from {
+		group := &from[i]
+		if !newGroups[group.Group] {
+			changes = append(changes, Change{Kind: ChangeGroupRemoved, Group: group.Group})
+		}
+		for _, task := range group.Tasks {
+			if !newTasks[task.Task] {
+				changes = append(changes, Change{Kind: ChangeTaskRemoved, Group: group.Group, Task: task.Task})
+			}
+		}
+	}
+
+	return changes
+}
@@ -2,63 +2,79 @@ package deadlines
 
 import (
 	"context"
-	"io/ioutil"
-	"net/http"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/pkg/errors"
 	"go.uber.org/zap"
-	"gopkg.in/yaml.v2"
 
 	"github.com/bigredeye/notmanytask/internal/config"
+	"github.com/bigredeye/notmanytask/internal/database"
+	"github.com/bigredeye/notmanytask/internal/models"
 )
 
-func fetch(url string) (Deadlines, error) {
-	resp, err := http.Get(url)
+func fetch(ctx context.Context, source Source, loc *time.Location) (Deadlines, error) {
+	body, err := source.Read(ctx)
 	if err != nil {
-		return nil, errors.Wrap(err, "Failed to fetch deadlines")
+		return nil, err
 	}
+	return Parse(body, loc)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, errors.Errorf("Failed to fetch deadlines: %s", resp.Status)
-	}
+// GroupStatus describes the last reload of the group deadlines
+type GroupStatus struct {
+	Group  string
+	Source string
+	Tasks  int
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return nil, errors.Wrap(err, "Failed to read response")
-	}
-
-	deadlines := Deadlines{}
-	err = yaml.Unmarshal(body, &deadlines)
-	if err != nil {
-		return nil, errors.New("Failed to unmarshal deadlines")
-	}
-
-	return deadlines, nil
+	// Zero if deadlines were never fetched by this process
+	LoadedAt  time.Time
+	CheckedAt time.Time
+	// Error of the last reload, the last good deadlines are served meanwhile
+	Error string
 }
 
 type Fetcher struct {
 	current atomic.Value
+	sources map[string]Source
+	wakeup  chan struct{}
+
+	statusMutex sync.Mutex
+	status      map[string]*GroupStatus
 
-	config *config.Config
-	logger *zap.Logger
+	config          *config.Config
+	logger          *zap.Logger
+	db              *database.DataBase
+	validateScoring ScoringValidator
 }
 
-func NewFetcher(config *config.Config, logger *zap.Logger) (*Fetcher, error) {
-	fetcher := &Fetcher{
-		config: config,
-		logger: logger,
+func NewFetcher(config *config.Config, logger *zap.Logger, db *database.DataBase, validateScoring ScoringValidator, files RawFileReader) (*Fetcher, error) {
+	sources := make(map[string]Source, len(config.Groups))
+	for i := range config.Groups {
+		group := &config.Groups[i]
+		source, err := NewSource(group.DeadlinesSource(), files)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid deadlines source of group %s", group.Name)
+		}
+		sources[group.Name] = source
 	}
 
-	err := fetcher.reload()
-	if err != nil {
-		return nil, err
+	fetcher := &Fetcher{
+		sources:         sources,
+		wakeup:          make(chan struct{}, 1),
+		status:          make(map[string]*GroupStatus),
+		config:          config,
+		logger:          logger,
+		db:              db,
+		validateScoring: validateScoring,
 	}
 
-	if fetcher.current.Load() == nil {
-		panic("No deadlines found after reload")
-	}
+	fetcher.reload(context.Background())
 
 	return fetcher, nil
 }
@@ -66,36 +82,170 @@ func NewFetcher(config *config.Config, logger *zap.Logger) (*Fetcher, error) {
 func (f *Fetcher) Run(ctx context.Context) {
 	tick := time.Tick(f.config.PullIntervals.Deadlines)
 
+	for name, source := range f.sources {
+		if watcher, ok := source.(Watcher); ok {
+			go watchSource(ctx, watcher, f.notify, f.logger.With(zap.String("group", name)))
+		}
+	}
+
 	for {
 		select {
 		case <-ctx.Done():
 			return
 		case <-tick:
-			f.reload()
+			f.reload(ctx)
+		case <-f.wakeup:
+			f.reload(ctx)
 		}
 	}
 }
 
+// notify schedules reload, changes arriving during reload are coalesced
+func (f *Fetcher) notify() {
+	select {
+	case f.wakeup <- struct{}{}:
+	default:
+	}
+}
+
 type deadlinesMap = map[string]*Deadlines
 
-func (f *Fetcher) reload() error {
+// reload fetches every group independently, failed groups keep their last good deadlines
+func (f *Fetcher) reload(ctx context.Context) {
 	f.logger.Info("Start deadlines fetcher iteration")
 	defer f.logger.Info("Finish deadlines fetcher iteration")
 
 	groupDeadlines := make(deadlinesMap)
-	for _, group := range f.config.Groups {
-		deadlines, err := fetch(group.DeadlinesURL)
+	for i := range f.config.Groups {
+		group := &f.config.Groups[i]
+		log := f.logger.With(zap.String("group", group.Name))
+
+		deadlines, err := f.reloadGroup(ctx, group, log)
 		if err != nil {
-			f.logger.Error("Failed to reload deadlines", zap.Error(err))
-			return errors.Wrap(err, "Failed to reload deadlines")
+			log.Error("Failed to reload deadlines", zap.Error(err), zap.Bool("has_last_good", deadlines != nil))
+		}
+		if deadlines != nil {
+			groupDeadlines[group.Name] = deadlines
 		}
-		groupDeadlines[group.Name] = &deadlines
-		f.logger.Info("Sucessfully fetched deadlines", zap.Int("num_task_groups", len(deadlines)), zap.String("group", group.Name))
+		f.setStatus(group, deadlines, err)
 	}
-	f.logger.Info("Sucessfully fetched all deadlines")
 
 	f.current.Store(groupDeadlines)
-	return nil
+}
+
+// reloadGroup returns the deadlines to serve, the last good ones in case of error
+func (f *Fetcher) reloadGroup(ctx context.Context, group *config.GroupConfig, log *zap.Logger) (*Deadlines, error) {
+	prev := f.GroupDeadlines(group.Name)
+	stored := prev
+	if stored == nil {
+		var err error
+		if stored, err = f.loadRevision(group.Name); err != nil {
+			log.Error("Failed to load the last deadlines revision", zap.Error(err))
+		}
+	}
+
+	loc, err := f.config.Location(group.Name)
+	if err != nil {
+		return stored, err
+	}
+	deadlines, err := fetch(ctx, f.sources[group.Name], loc)
+	if err != nil {
+		return stored, err
+	}
+	if err = Validate(deadlines, f.validateScoring); err != nil {
+		return stored, err
+	}
+
+	// Unchanged deadlines keep their identity, so consumers can cache by pointer
+	if prev != nil && reflect.DeepEqual(*prev, deadlines) {
+		return prev, nil
+	}
+
+	var changes []Change
+	if stored != nil {
+		changes = Compare(*stored, deadlines)
+	} else {
+		changes = Compare(nil, deadlines)
+	}
+	if stored == nil || len(changes) > 0 {
+		for _, change := range changes {
+			log.Info("Deadlines changed", zap.String("change", change.String()))
+		}
+		if err = f.saveRevision(group.Name, deadlines, changes); err != nil {
+			log.Error("Failed to save deadlines revision", zap.Error(err))
+		}
+	}
+
+	log.Info("Sucessfully fetched deadlines", zap.Int("num_task_groups", len(deadlines)), zap.Int("num_changes", len(changes)))
+	return &deadlines, nil
+}
+
+func (f *Fetcher) loadRevision(group string) (*Deadlines, error) {
+	revision, err := f.db.FindLatestDeadlinesRevision(group)
+	if err != nil || revision == nil {
+		return nil, err
+	}
+	deadlines := Deadlines{}
+	if err = json.Unmarshal([]byte(revision.Deadlines), &deadlines); err != nil {
+		return nil, errors.Wrapf(err, "Failed to decode deadlines revision %d", revision.ID)
+	}
+	return &deadlines, nil
+}
+
+func (f *Fetcher) saveRevision(group string, deadlines Deadlines, changes []Change) error {
+	encoded, err := json.Marshal(deadlines)
+	if err != nil {
+		return errors.Wrap(err, "Failed to encode deadlines")
+	}
+	lines := make([]string, len(changes))
+	for i, change := range changes {
+		lines[i] = change.String()
+	}
+	return f.db.AddDeadlinesRevision(&models.DeadlinesRevision{
+		GroupName: group,
+		Deadlines: string(encoded),
+		Changes:   strings.Join(lines, "\n"),
+	})
+}
+
+func (f *Fetcher) setStatus(group *config.GroupConfig, deadlines *Deadlines, err error) {
+	f.statusMutex.Lock()
+	defer f.statusMutex.Unlock()
+
+	status, found := f.status[group.Name]
+	if !found {
+		status = &GroupStatus{Group: group.Name}
+		f.status[group.Name] = status
+	}
+	status.Source = f.sources[group.Name].String()
+	status.CheckedAt = time.Now()
+	status.Error = ""
+	if err != nil {
+		status.Error = err.Error()
+	} else {
+		status.LoadedAt = status.CheckedAt
+	}
+	status.Tasks = 0
+	if deadlines != nil {
+		for _, taskGroup := range *deadlines {
+			status.Tasks += len(taskGroup.Tasks)
+		}
+	}
+}
+
+// Status returns the reload state of every group
+func (f *Fetcher) Status() []GroupStatus {
+	f.statusMutex.Lock()
+	defer f.statusMutex.Unlock()
+
+	statuses := make([]GroupStatus, 0, len(f.status))
+	for _, status := range f.status {
+		statuses = append(statuses, *status)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Group < statuses[j].Group
+	})
+	return statuses
 }
 
 func (f *Fetcher) GroupDeadlines(group string) *Deadlines {
@@ -0,0 +1,216 @@
+package deadlines
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
+	"github.com/bigredeye/notmanytask/internal/config"
+)
+
+const (
+	SourceHTTP   = "http"
+	SourceFile   = "file"
+	SourceGitLab = "gitlab"
+
+	defaultSourceTimeout = 30 * time.Second
+)
+
+// Source reads the raw deadlines of the group
+type Source interface {
+	Read(ctx context.Context) ([]byte, error)
+	// Shown on the admin page, must not contain secrets
+	String() string
+}
+
+// Watcher is a source which knows when the deadlines change
+type Watcher interface {
+	// Watch calls notify on every change until ctx is cancelled
+	Watch(ctx context.Context, notify func()) error
+}
+
+// RawFileReader reads files of private repositories
+type RawFileReader interface {
+	GetRawFile(ctx context.Context, project string, file string, ref string) ([]byte, error)
+}
+
+// NewSource creates the source described in the group config
+func NewSource(params config.DeadlinesSourceConfig, files RawFileReader) (Source, error) {
+	kind := params.Type
+	if kind == "" {
+		switch {
+		case params.Path != "":
+			kind = SourceFile
+		case params.Project != "":
+			kind = SourceGitLab
+		default:
+			kind = SourceHTTP
+		}
+	}
+
+	timeout := params.Timeout
+	if timeout == 0 {
+		timeout = defaultSourceTimeout
+	}
+
+	switch kind {
+	case SourceHTTP:
+		if params.URL == "" {
+			return nil, errors.New("Deadlines url is required")
+		}
+		return &httpSource{url: params.URL, client: &http.Client{Timeout: timeout}}, nil
+	case SourceFile:
+		if params.Path == "" {
+			return nil, errors.New("Deadlines path is required")
+		}
+		return &fileSource{path: params.Path}, nil
+	case SourceGitLab:
+		if params.Project == "" || params.File == "" {
+			return nil, errors.New("Deadlines project and file are required")
+		}
+		if files == nil {
+			return nil, errors.New("GitLab client is not configured")
+		}
+		return &gitlabSource{project: params.Project, file: params.File, ref: params.Ref, timeout: timeout, files: files}, nil
+	}
+	return nil, errors.Errorf("Unknown deadlines source %q", kind)
+}
+
+// httpSource sends conditional requests and returns the cached body if nothing changed
+type httpSource struct {
+	url    string
+	client *http.Client
+
+	etag         string
+	lastModified string
+	body         []byte
+}
+
+func (s *httpSource) Read(ctx context.Context) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create request")
+	}
+	if s.body != nil {
+		if s.etag != "" {
+			req.Header.Set("If-None-Match", s.etag)
+		}
+		if s.lastModified != "" {
+			req.Header.Set("If-Modified-Since", s.lastModified)
+		}
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to fetch deadlines")
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		if s.body != nil {
+			return s.body, nil
+		}
+		return nil, errors.New("Failed to fetch deadlines: unexpected 304 Not Modified")
+	case http.StatusOK:
+	default:
+		return nil, errors.Errorf("Failed to fetch deadlines: %s", resp.Status)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read response")
+	}
+	s.etag = resp.Header.Get("ETag")
+	s.lastModified = resp.Header.Get("Last-Modified")
+	s.body = body
+	return body, nil
+}
+
+func (s *httpSource) String() string {
+	return s.url
+}
+
+type fileSource struct {
+	path string
+}
+
+func (s *fileSource) Read(ctx context.Context) ([]byte, error) {
+	body, err := ioutil.ReadFile(s.path)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to read deadlines")
+	}
+	return body, nil
+}
+
+func (s *fileSource) String() string {
+	return "file " + s.path
+}
+
+// Watch follows the directory as editors and deploys replace the file instead of writing it
+func (s *fileSource) Watch(ctx context.Context, notify func()) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.Wrap(err, "Failed to create watcher")
+	}
+	defer watcher.Close()
+
+	path := filepath.Clean(s.path)
+	if err = watcher.Add(filepath.Dir(path)); err != nil {
+		return errors.Wrapf(err, "Failed to watch %s", path)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Clean(event.Name) == path && event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) != 0 {
+				notify()
+			}
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			return errors.Wrapf(err, "Failed to watch %s", path)
+		}
+	}
+}
+
+type gitlabSource struct {
+	project string
+	file    string
+	ref     string
+	timeout time.Duration
+	files   RawFileReader
+}
+
+func (s *gitlabSource) Read(ctx context.Context) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+	return s.files.GetRawFile(ctx, s.project, s.file, s.ref)
+}
+
+func (s *gitlabSource) String() string {
+	if s.ref == "" {
+		return fmt.Sprintf("gitlab %s/%s", s.project, s.file)
+	}
+	return fmt.Sprintf("gitlab %s/%s@%s", s.project, s.file, s.ref)
+}
+
+// watchSource reloads deadlines on every change of the source
+func watchSource(ctx context.Context, watcher Watcher, notify func(), logger *zap.Logger) {
+	if err := watcher.Watch(ctx, notify); err != nil {
+		logger.Error("Deadlines watcher failed, falling back to polling", zap.Error(err))
+	}
+}
@@ -0,0 +1,94 @@
+package deadlines
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/bigredeye/notmanytask/internal/config"
+)
+
+func TestHTTPSourceConditional(t *testing.T) {
+	requests, fullResponses := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fullResponses++
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = w.Write([]byte("- group: a\n"))
+	}))
+	defer server.Close()
+
+	source, err := NewSource(config.DeadlinesSourceConfig{URL: server.URL}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		body, err := source.Read(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(body) != "- group: a\n" {
+			t.Errorf("Unexpected body %q", body)
+		}
+	}
+	if requests != 3 || fullResponses != 1 {
+		t.Errorf("Expected 3 requests and 1 full response, got %d and %d", requests, fullResponses)
+	}
+}
+
+type fakeFiles map[string]string
+
+func (f fakeFiles) GetRawFile(ctx context.Context, project string, file string, ref string) ([]byte, error) {
+	return []byte(f[project+"/"+file+"@"+ref]), nil
+}
+
+func TestNewSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "deadlines")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "deadlines.yml")
+	if err = ioutil.WriteFile(path, []byte("local"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	files := fakeFiles{"course/tasks/deadlines.yml@main": "remote"}
+	for params, expected := range map[config.DeadlinesSourceConfig]string{
+		{Path: path}: "local",
+		{Project: "course/tasks", File: "deadlines.yml", Ref: "main"}:                     "remote",
+		{Type: SourceGitLab, Project: "course/tasks", File: "deadlines.yml", Ref: "main"}: "remote",
+	} {
+		source, err := NewSource(params, files)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body, err := source.Read(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(body) != expected {
+			t.Errorf("Unexpected content of %s: %q", source, body)
+		}
+	}
+
+	for _, params := range []config.DeadlinesSourceConfig{
+		{},
+		{Type: "ftp", URL: "ftp://example.com"},
+		{Type: SourceGitLab, Project: "course/tasks"},
+		{Type: SourceFile},
+	} {
+		if _, err := NewSource(params, files); err == nil {
+			t.Errorf("Invalid source %+v is accepted", params)
+		}
+	}
+}
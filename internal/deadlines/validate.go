@@ -0,0 +1,69 @@
+package deadlines
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/bigredeye/notmanytask/internal/config"
+)
+
+// ScoringValidator checks the scoring policy declared in deadlines
+type ScoringValidator = func(params *config.ScoringConfig) error
+
+// Validate reports all problems of the deadlines at once
+func Validate(deadlines Deadlines, validateScoring ScoringValidator) error {
+	problems := make([]string, 0)
+	addProblem := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	groups := make(map[string]bool)
+	tasks := make(map[string]string)
+	for _, group := range deadlines {
+		switch {
+		case group.Group == "":
+			addProblem("group without name")
+		case groups[group.Group]:
+			addProblem("duplicate group %s", group.Group)
+		}
+		groups[group.Group] = true
+
+		if group.Deadline.IsZero() {
+			addProblem("group %s has no deadline", group.Group)
+		} else if !group.Start.IsZero() && !group.Start.Before(group.Deadline.Time) {
+			addProblem("group %s starts after its deadline", group.Group)
+		}
+		if group.Scoring != nil && validateScoring != nil {
+			if err := validateScoring(group.Scoring); err != nil {
+				addProblem("group %s: %v", group.Group, err)
+			}
+		}
+
+		for _, task := range group.Tasks {
+			if task.Task == "" {
+				addProblem("task without name in group %s", group.Group)
+				continue
+			}
+			if other, found := tasks[task.Task]; found {
+				addProblem("duplicate task %s in groups %s and %s", task.Task, other, group.Group)
+			}
+			tasks[task.Task] = group.Group
+
+			if task.Score <= 0 {
+				addProblem("task %s has non-positive score %d", task.Task, task.Score)
+			}
+			if task.Scoring != nil && validateScoring != nil {
+				if err := validateScoring(task.Scoring); err != nil {
+					addProblem("task %s: %v", task.Task, err)
+				}
+			}
+		}
+	}
+
+	if len(problems) > 0 {
+		return errors.Errorf("Invalid deadlines: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"github.com/pkg/errors"
+
+	"github.com/bigredeye/notmanytask/internal/scorer"
+)
+
+const (
+	FormatCSV  = "csv"
+	FormatXLSX = "xlsx"
+)
+
+// Table is a format-agnostic representation of standings.
+// Cells are either strings or ints.
+type Table struct {
+	Header []string
+	Rows   [][]interface{}
+}
+
+func NewTable(standings *scorer.Standings) *Table {
+	table := &Table{
+		Header: []string{"Login", "First name", "Last name", "Group", "Subgroup"},
+	}
+
+	for _, group := range *standings.Deadlines {
+		for _, task := range group.Tasks {
+			table.Header = append(table.Header, task.Task)
+		}
+		table.Header = append(table.Header, group.Group+" total")
+	}
+	table.Header = append(table.Header, "Total", "Max")
+	if standings.Graded {
+		table.Header = append(table.Header, "Grade")
+	}
+
+	for _, user := range standings.Users {
+		row := []interface{}{
+			user.User.GitlabLogin,
+			user.User.FirstName,
+			user.User.LastName,
+			user.User.Group,
+			user.User.Subgroup,
+		}
+
+		groups := make(map[string]*scorer.ScoredTaskGroup, len(user.Groups))
+		for i := range user.Groups {
+			groups[user.Groups[i].Title] = &user.Groups[i]
+		}
+
+		for _, group := range *standings.Deadlines {
+			scored := groups[group.Group]
+			tasks := make(map[string]int)
+			total := 0
+			if scored != nil {
+				for _, task := range scored.Tasks {
+					tasks[task.Task] = task.Score
+				}
+				total = scored.Score
+			}
+
+			for _, task := range group.Tasks {
+				row = append(row, tasks[task.Task])
+			}
+			row = append(row, total)
+		}
+		row = append(row, user.Score, user.MaxScore)
+		if standings.Graded {
+			if user.FinalGrade != nil {
+				row = append(row, user.FinalGrade.Grade)
+			} else {
+				row = append(row, "")
+			}
+		}
+
+		table.Rows = append(table.Rows, row)
+	}
+
+	return table
+}
+
+func formatCell(cell interface{}) string {
+	switch v := cell.(type) {
+	case int:
+		return strconv.Itoa(v)
+	case string:
+		return escapeFormula(v)
+	default:
+		return ""
+	}
+}
+
+// escapeFormula keeps spreadsheets from evaluating names of students as formulas
+func escapeFormula(text string) string {
+	if text == "" {
+		return text
+	}
+	switch text[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		return "'" + text
+	}
+	return text
+}
+
+func (t *Table) WriteCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	header := make([]string, len(t.Header))
+	for i, h := range t.Header {
+		header[i] = escapeFormula(h)
+	}
+	if err := writer.Write(header); err != nil {
+		return errors.Wrap(err, "Failed to write header")
+	}
+
+	record := make([]string, len(t.Header))
+	for _, row := range t.Rows {
+		for i, cell := range row {
+			record[i] = formatCell(cell)
+		}
+		if err := writer.Write(record[:len(row)]); err != nil {
+			return errors.Wrap(err, "Failed to write row")
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func (t *Table) Write(w io.Writer, format string) error {
+	switch format {
+	case FormatCSV:
+		return t.WriteCSV(w)
+	case FormatXLSX:
+		return t.WriteXLSX(w)
+	default:
+		return errors.Errorf("Unknown export format %q", format)
+	}
+}
+
+func ContentType(format string) string {
+	switch format {
+	case FormatCSV:
+		return "text/csv; charset=utf-8"
+	case FormatXLSX:
+		return "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+	default:
+		return "application/octet-stream"
+	}
+}
@@ -0,0 +1,128 @@
+package export
+
+import (
+	"archive/zip"
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/bigredeye/notmanytask/internal/deadlines"
+	"github.com/bigredeye/notmanytask/internal/scorer"
+)
+
+func makeStandings() *scorer.Standings {
+	groups := deadlines.Deadlines{
+		{Group: "intro", Tasks: []deadlines.Task{{Task: "hello", Score: 100}, {Task: "sum", Score: 200}}},
+		{Group: "stl", Tasks: []deadlines.Task{{Task: "vector", Score: 300}}},
+	}
+
+	return &scorer.Standings{
+		Deadlines: &groups,
+		Users: []*scorer.UserScores{
+			{
+				User:     scorer.User{FirstName: "Ada", LastName: "Lovelace, Countess", Group: "hse", Subgroup: "1", GitlabLogin: "ada"},
+				Score:    350,
+				MaxScore: 600,
+				Groups: []scorer.ScoredTaskGroup{
+					{Title: "intro", Score: 250, Tasks: []scorer.ScoredTask{{Task: "hello", Score: 100}, {Task: "sum", Score: 150}}},
+					{Title: "stl", Score: 100, Tasks: []scorer.ScoredTask{{Task: "vector", Score: 100}}},
+				},
+			},
+		},
+	}
+}
+
+func TestCSV(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := NewTable(makeStandings()).Write(buf, FormatCSV); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := "Login,First name,Last name,Group,Subgroup,hello,sum,intro total,vector,stl total,Total,Max\n" +
+		"ada,Ada,\"Lovelace, Countess\",hse,1,100,150,250,100,100,350,600\n"
+	if buf.String() != expected {
+		t.Fatalf("Invalid csv:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+}
+
+func TestXLSX(t *testing.T) {
+	buf := &bytes.Buffer{}
+	if err := NewTable(makeStandings()).Write(buf, FormatXLSX); err != nil {
+		t.Fatal(err)
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var sheet *zip.File
+	for _, f := range archive.File {
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			sheet = f
+		}
+	}
+	if sheet == nil {
+		t.Fatal("No worksheet in xlsx archive")
+	}
+
+	r, err := sheet.Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	content := &bytes.Buffer{}
+	if _, err = content.ReadFrom(r); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, cell := range []string{`<c r="L2"><v>600</v></c>`, `<c r="C2" t="inlineStr">`} {
+		if !strings.Contains(content.String(), cell) {
+			t.Fatalf("Cell %s not found in sheet %s", cell, content.String())
+		}
+	}
+}
+
+func TestFormulaEscaping(t *testing.T) {
+	standings := makeStandings()
+	standings.Users[0].User.FirstName = "=HYPERLINK(\"http://evil.com\")"
+	standings.Users[0].User.LastName = "-1+1"
+	standings.Users[0].User.Subgroup = "@SUM(A1)"
+
+	buf := &bytes.Buffer{}
+	if err := NewTable(standings).Write(buf, FormatCSV); err != nil {
+		t.Fatal(err)
+	}
+	expected := "ada,\"'=HYPERLINK(\"\"http://evil.com\"\")\",'-1+1,hse,'@SUM(A1),100,150,250,100,100,350,600\n"
+	if !strings.HasSuffix(buf.String(), expected) {
+		t.Fatalf("Formulas are not escaped:\n%s\nexpected:\n%s", buf.String(), expected)
+	}
+
+	for text, escaped := range map[string]string{
+		"":        "",
+		"ada":     "ada",
+		"+7":      "'+7",
+		"\tcmd":   "'\tcmd",
+		"\rcmd":   "'\rcmd",
+		"a=b":     "a=b",
+		"Лавлейс": "Лавлейс",
+	} {
+		if got := escapeFormula(text); got != escaped {
+			t.Errorf("Invalid escaping of %q: %q, expected: %q", text, got, escaped)
+		}
+	}
+}
+
+func TestColumnName(t *testing.T) {
+	for col, name := range map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"} {
+		if got := columnName(col); got != name {
+			t.Fatalf("Invalid column name for %d: %s, expected: %s", col, got, name)
+		}
+	}
+}
+
+func TestUnknownFormat(t *testing.T) {
+	if err := NewTable(makeStandings()).Write(&bytes.Buffer{}, "ods"); err == nil {
+		t.Fatal("Expected error for unknown format")
+	}
+}
@@ -0,0 +1,125 @@
+package export
+
+import (
+	"archive/zip"
+	"encoding/xml"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Minimal SpreadsheetML package with a single sheet and inline strings.
+// Good enough for spreadsheet software and university grade uploads.
+
+const xlsxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>
+<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>
+</Types>`
+
+const xlsxRootRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>
+</Relationships>`
+
+const xlsxWorkbook = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">
+<sheets><sheet name="Standings" sheetId="1" r:id="rId1"/></sheets>
+</workbook>`
+
+const xlsxWorkbookRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>
+</Relationships>`
+
+// columnName converts zero-based column index to A, B, ..., Z, AA, ...
+func columnName(col int) string {
+	name := ""
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name
+}
+
+func writeXLSXCell(b *strings.Builder, ref string, cell interface{}) error {
+	switch v := cell.(type) {
+	case int:
+		b.WriteString(`<c r="` + ref + `"><v>` + strconv.Itoa(v) + `</v></c>`)
+	case string:
+		b.WriteString(`<c r="` + ref + `" t="inlineStr"><is><t xml:space="preserve">`)
+		if err := xml.EscapeText(b, []byte(escapeFormula(v))); err != nil {
+			return err
+		}
+		b.WriteString(`</t></is></c>`)
+	}
+	return nil
+}
+
+func (t *Table) sheetXML() (string, error) {
+	b := &strings.Builder{}
+	b.WriteString(`<?xml version="1.0" encoding="UTF-8" standalone="yes"?>`)
+	b.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`)
+
+	writeRow := func(idx int, cells []interface{}) error {
+		row := strconv.Itoa(idx + 1)
+		b.WriteString(`<row r="` + row + `">`)
+		for col, cell := range cells {
+			if err := writeXLSXCell(b, columnName(col)+row, cell); err != nil {
+				return err
+			}
+		}
+		b.WriteString(`</row>`)
+		return nil
+	}
+
+	header := make([]interface{}, len(t.Header))
+	for i, h := range t.Header {
+		header[i] = h
+	}
+	if err := writeRow(0, header); err != nil {
+		return "", err
+	}
+	for i, row := range t.Rows {
+		if err := writeRow(i+1, row); err != nil {
+			return "", err
+		}
+	}
+
+	b.WriteString(`</sheetData></worksheet>`)
+	return b.String(), nil
+}
+
+func (t *Table) WriteXLSX(w io.Writer) error {
+	sheet, err := t.sheetXML()
+	if err != nil {
+		return errors.Wrap(err, "Failed to render sheet")
+	}
+
+	files := []struct {
+		name    string
+		content string
+	}{
+		{"[Content_Types].xml", xlsxContentTypes},
+		{"_rels/.rels", xlsxRootRels},
+		{"xl/workbook.xml", xlsxWorkbook},
+		{"xl/_rels/workbook.xml.rels", xlsxWorkbookRels},
+		{"xl/worksheets/sheet1.xml", sheet},
+	}
+
+	archive := zip.NewWriter(w)
+	for _, file := range files {
+		f, err := archive.Create(file.name)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to create %s", file.name)
+		}
+		if _, err = io.WriteString(f, file.content); err != nil {
+			return errors.Wrapf(err, "Failed to write %s", file.name)
+		}
+	}
+
+	return errors.Wrap(archive.Close(), "Failed to finish xlsx archive")
+}
@@ -0,0 +1,104 @@
+package flags
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	flagPrefix = "{FLAG-"
+	flagSuffix = "}"
+
+	// Truncated HMAC-SHA256, long enough to distinguish from the legacy uuid flags
+	macSize = 16
+)
+
+var (
+	ErrMalformed = errors.New("Malformed flag")
+	ErrForeign   = errors.New("Flag was issued for another user")
+)
+
+// Signer mints flags bound to the student:
+// {FLAG-<task>-<unix time in hex>-<hmac(task, login, time) in hex>}
+type Signer struct {
+	key []byte
+}
+
+func NewSigner(hexKey string) (*Signer, error) {
+	key, err := hex.DecodeString(hexKey)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to decode hex flags key")
+	}
+	if len(key) < 16 {
+		return nil, errors.New("Flags key is too short, expected at least 16 bytes")
+	}
+	return &Signer{key}, nil
+}
+
+func (s *Signer) mac(task string, login string, timestamp int64) []byte {
+	h := hmac.New(sha256.New, s.key)
+	fmt.Fprintf(h, "%s\x00%s\x00%d", task, login, timestamp)
+	return h.Sum(nil)[:macSize]
+}
+
+func (s *Signer) Sign(task string, login string, now time.Time) string {
+	timestamp := now.Unix()
+	return fmt.Sprintf("%s%s-%x-%s%s", flagPrefix, task, timestamp, hex.EncodeToString(s.mac(task, login, timestamp)), flagSuffix)
+}
+
+// Verify checks that the signed flag was issued for the login and returns flag task
+func (s *Signer) Verify(flag string, login string) (string, error) {
+	signed, err := Parse(flag)
+	if err != nil {
+		return "", err
+	}
+	if !hmac.Equal(signed.MAC, s.mac(signed.Task, login, signed.Timestamp.Unix())) {
+		return "", ErrForeign
+	}
+	return signed.Task, nil
+}
+
+type SignedFlag struct {
+	Task      string
+	Timestamp time.Time
+	MAC       []byte
+}
+
+// IsSigned distinguishes signed flags from the legacy {FLAG-<task>-<uuid>} ones
+func IsSigned(flag string) bool {
+	_, err := Parse(flag)
+	return err == nil
+}
+
+func Parse(flag string) (*SignedFlag, error) {
+	if !strings.HasPrefix(flag, flagPrefix) || !strings.HasSuffix(flag, flagSuffix) {
+		return nil, ErrMalformed
+	}
+	parts := strings.Split(strings.TrimSuffix(strings.TrimPrefix(flag, flagPrefix), flagSuffix), "-")
+	if len(parts) < 3 {
+		return nil, ErrMalformed
+	}
+
+	mac, err := hex.DecodeString(parts[len(parts)-1])
+	if err != nil || len(mac) != macSize {
+		return nil, ErrMalformed
+	}
+
+	timestamp, err := strconv.ParseInt(parts[len(parts)-2], 16, 64)
+	if err != nil {
+		return nil, ErrMalformed
+	}
+
+	return &SignedFlag{
+		Task:      strings.Join(parts[:len(parts)-2], "-"),
+		Timestamp: time.Unix(timestamp, 0),
+		MAC:       mac,
+	}, nil
+}
@@ -0,0 +1,92 @@
+package flags
+
+import (
+	"regexp"
+	"testing"
+	"time"
+)
+
+const testKey = "000102030405060708090a0b0c0d0e0f"
+
+// Same as the flag form validation
+var flagRe = regexp.MustCompile(`^\{FLAG(-[a-z0-9_]+)+(-[0-9a-f]+)+\}$`)
+
+func mustSigner(t *testing.T, key string) *Signer {
+	signer, err := NewSigner(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signer
+}
+
+func TestSignVerify(t *testing.T) {
+	signer := mustSigner(t, testKey)
+	flag := signer.Sign("fly-me-to-the-moon", "neil", time.Date(2021, 7, 20, 20, 17, 0, 0, time.UTC))
+
+	if !flagRe.MatchString(flag) {
+		t.Fatalf("Flag %s does not match flag regexp", flag)
+	}
+	if !IsSigned(flag) {
+		t.Fatalf("Flag %s is not signed", flag)
+	}
+
+	task, err := signer.Verify(flag, "neil")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if task != "fly-me-to-the-moon" {
+		t.Fatalf("Invalid task: %s", task)
+	}
+
+	if _, err = signer.Verify(flag, "buzz"); err != ErrForeign {
+		t.Fatalf("Expected foreign flag error, got %v", err)
+	}
+	if _, err = mustSigner(t, "ff"+testKey[2:]).Verify(flag, "neil"); err != ErrForeign {
+		t.Fatalf("Expected foreign flag error for another key, got %v", err)
+	}
+}
+
+func TestTamperedFlag(t *testing.T) {
+	signer := mustSigner(t, testKey)
+	flag := signer.Sign("rewrite-in-rust", "neil", time.Now())
+
+	signed, err := Parse(flag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tampered := signer.Sign("rewrite-in-go", "neil", signed.Timestamp)
+	tampered = tampered[:len(tampered)-2*macSize-1] + flag[len(flag)-2*macSize-1:]
+	if _, err = signer.Verify(tampered, "neil"); err == nil {
+		t.Fatalf("Tampered flag %s was accepted", tampered)
+	}
+}
+
+func TestLegacyFlags(t *testing.T) {
+	for _, flag := range []string{
+		"{FLAG-rewrite-in-rust-0f8fad5b-d9cb-469f-a165-70867728950e}",
+		"{FLAG-rewrite-in-rust}",
+		"FLAG-rewrite-in-rust-1-00112233445566778899aabbccddeeff",
+	} {
+		if IsSigned(flag) {
+			t.Fatalf("Flag %s should not be signed", flag)
+		}
+	}
+}
+
+func TestShortKey(t *testing.T) {
+	if _, err := NewSigner("0001"); err == nil {
+		t.Fatal("Short key was accepted")
+	}
+}
+
+// Flags of the same second are identical, storage keeps the first one
+func TestSameSecondFlags(t *testing.T) {
+	signer := mustSigner(t, testKey)
+	now := time.Date(2021, 7, 20, 20, 17, 0, 0, time.UTC)
+	if signer.Sign("fly-me-to-the-moon", "neil", now) != signer.Sign("fly-me-to-the-moon", "neil", now.Add(time.Millisecond)) {
+		t.Fatal("Flags of the same second differ")
+	}
+	if signer.Sign("fly-me-to-the-moon", "neil", now) == signer.Sign("fly-me-to-the-moon", "buzz", now) {
+		t.Fatal("Flags of different users are equal")
+	}
+}
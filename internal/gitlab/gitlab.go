@@ -1,9 +1,13 @@
 package gitlab
 
 import (
+	"context"
 	"fmt"
 	"net/http"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/pkg/errors"
 	"github.com/xanzy/go-gitlab"
@@ -25,7 +29,12 @@ type Client struct {
 }
 
 func NewClient(config *config.Config, logger *zap.Logger) (*Client, error) {
-	client, err := gitlab.NewClient(config.GitLab.Api.Token, gitlab.WithBaseURL(config.GitLab.BaseURL))
+	client, err := gitlab.NewClient(
+		config.GitLab.Api.Token,
+		gitlab.WithBaseURL(config.GitLab.BaseURL),
+		gitlab.WithCustomLimiter(newRateLimiter(config.GitLab.RateLimit.RequestsPerSecond, config.GitLab.RateLimit.Burst)),
+		gitlab.WithCustomBackoff(retryAfterBackoff),
+	)
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to create gitlab client")
 	}
@@ -36,9 +45,64 @@ func NewClient(config *config.Config, logger *zap.Logger) (*Client, error) {
 	}, nil
 }
 
-const (
-	master = "main"
-)
+// ForCourse returns a client of the course group sharing API connection and rate limits
+func (c *Client) ForCourse(config *config.Config) *Client {
+	return &Client{
+		config: config,
+		gitlab: c.gitlab,
+		logger: c.logger.With(zap.String("course", config.Course)),
+	}
+}
+
+// GetRawFile reads the file of the course repository, the default branch if ref is empty
+func (c Client) GetRawFile(ctx context.Context, project string, file string, ref string) ([]byte, error) {
+	opts := &gitlab.GetRawFileOptions{}
+	if ref != "" {
+		opts.Ref = &ref
+	}
+	content, _, err := c.gitlab.RepositoryFiles.GetRawFile(project, file, opts, gitlab.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrapf(err, "Failed to read %s of project %s", file, project)
+	}
+	return content, nil
+}
+
+func (c Client) createProject(log *zap.Logger, projectName string, settings config.ProjectConfig) (*gitlab.Project, error) {
+	visibility, err := parseVisibility(settings.Visibility)
+	if err != nil {
+		return nil, err
+	}
+	sharedRunners := settings.SharedRunners != nil && *settings.SharedRunners
+
+	if settings.ForkFrom == 0 {
+		project, _, err := c.gitlab.Projects.CreateProject(&gitlab.CreateProjectOptions{
+			Name:                 &projectName,
+			NamespaceID:          &c.config.GitLab.Group.ID,
+			DefaultBranch:        &settings.DefaultBranch,
+			Visibility:           &visibility,
+			SharedRunnersEnabled: &sharedRunners,
+			ImportURL:            &settings.ImportURL,
+		})
+		return project, err
+	}
+
+	project, _, err := c.gitlab.Projects.ForkProject(settings.ForkFrom, &gitlab.ForkProjectOptions{
+		Namespace: gitlab.String(strconv.Itoa(c.config.GitLab.Group.ID)),
+		Name:      &projectName,
+		Path:      &projectName,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to fork template")
+	}
+	log.Info("Forked template", zap.Int("template_id", settings.ForkFrom), zap.Int("project_id", project.ID))
+
+	// Forks inherit settings of the template, default branch is set by ensureDefaultBranch once the fork is finished
+	project, _, err = c.gitlab.Projects.EditProject(project.ID, &gitlab.EditProjectOptions{
+		Visibility:           &visibility,
+		SharedRunnersEnabled: &sharedRunners,
+	})
+	return project, errors.Wrap(err, "Failed to configure forked project")
+}
 
 func (c Client) InitializeProject(user *models.User) error {
 	if user.GitlabID == nil || user.GitlabLogin == nil {
@@ -51,6 +115,11 @@ func (c Client) InitializeProject(user *models.User) error {
 
 	projectName := c.MakeProjectName(user)
 	log = log.With(lf.ProjectName(projectName))
+	settings := c.config.ProjectFor(user.GroupName)
+	memberRole, err := parseMemberRole(settings.MemberRole)
+	if err != nil {
+		return err
+	}
 
 	// Try to find existing project
 	project, resp, err := c.gitlab.Projects.GetProject(fmt.Sprintf("%s/%s", c.config.GitLab.Group.Name, projectName), &gitlab.GetProjectOptions{})
@@ -60,14 +129,7 @@ func (c Client) InitializeProject(user *models.User) error {
 	} else if resp.StatusCode == http.StatusNotFound {
 		log.Info("Project was not found", zap.String("escaped_project", fmt.Sprintf("%s/%s", c.config.GitLab.Group.Name, projectName)))
 		// Create project
-		project, _, err = c.gitlab.Projects.CreateProject(&gitlab.CreateProjectOptions{
-			Name:                 &projectName,
-			NamespaceID:          &c.config.GitLab.Group.ID,
-			DefaultBranch:        gitlab.String(master),
-			Visibility:           gitlab.Visibility(gitlab.PrivateVisibility),
-			SharedRunnersEnabled: gitlab.Bool(false),
-			ImportURL:            gitlab.String("https://gitlab.com/levanovd/cpp-base-hse-2022.git"),
-		})
+		project, err = c.createProject(log, projectName, settings)
 		if err != nil {
 			log.Error("Failed to create project", zap.Error(err))
 			return errors.Wrap(err, "Failed to create project")
@@ -115,7 +177,7 @@ func (c Client) InitializeProject(user *models.User) error {
 		// Add our dear user to the project
 		_, _, err = c.gitlab.ProjectMembers.AddProjectMember(project.ID, &gitlab.AddProjectMemberOptions{
 			UserID:      *user.GitlabID,
-			AccessLevel: gitlab.AccessLevel(gitlab.DeveloperPermissions),
+			AccessLevel: &memberRole,
 		})
 		if err != nil {
 			log.Error("Failed to add user to the project", zap.Error(err))
@@ -124,6 +186,29 @@ func (c Client) InitializeProject(user *models.User) error {
 		log.Info("Added user to the project")
 	}
 
+	return c.ensureDefaultBranch(log, project, settings)
+}
+
+// ensureDefaultBranch applies the configured default branch to forks, they inherit the one of the template.
+// It can not be changed until the fork is finished, provisioning retries until then.
+func (c Client) ensureDefaultBranch(log *zap.Logger, project *gitlab.Project, settings config.ProjectConfig) error {
+	if settings.ForkFrom == 0 || project.DefaultBranch == settings.DefaultBranch {
+		return nil
+	}
+	switch project.ImportStatus {
+	case "", "none", "finished":
+	default:
+		return errors.Errorf("Fork is not finished yet, import status %s", project.ImportStatus)
+	}
+
+	_, _, err := c.gitlab.Projects.EditProject(project.ID, &gitlab.EditProjectOptions{
+		DefaultBranch: &settings.DefaultBranch,
+	})
+	if err != nil {
+		log.Error("Failed to change default branch", zap.Error(err))
+		return errors.Wrap(err, "Failed to change default branch")
+	}
+	log.Info("Changed default branch", lf.BranchName(settings.DefaultBranch))
 	return nil
 }
 
@@ -135,6 +220,17 @@ func (c Client) MakeProjectName(user *models.User) string {
 	return fmt.Sprintf("%s-%s-%s-%s-%s", user.GroupName, user.SubgroupName, cleanupName(user.FirstName), cleanupName(user.LastName), *user.GitlabLogin)
 }
 
+// projectGroup finds the group of the student project by the name prefix, see MakeProjectName
+func (c Client) projectGroup(projectName string) string {
+	group := ""
+	for _, g := range c.config.Groups {
+		if strings.HasPrefix(projectName, g.Name+"-") && len(g.Name) > len(group) {
+			group = g.Name
+		}
+	}
+	return group
+}
+
 func (c Client) MakeProjectUrl(user *models.User) string {
 	name := c.MakeProjectName(user)
 	return fmt.Sprintf("%s/%s/%s", c.config.GitLab.BaseURL, c.config.GitLab.Group.Name, name)
@@ -163,21 +259,76 @@ func (c Client) MakeTaskUrl(task string) string {
 	return fmt.Sprintf("%s/%s", c.config.GitLab.TaskUrlPrefix, task)
 }
 
+// ProjectErrors holds failures of the project callbacks keyed by project name
+type ProjectErrors map[string]error
+
+func (e ProjectErrors) Error() string {
+	names := make([]string, 0, len(e))
+	for name := range e {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	messages := make([]string, len(names))
+	for i, name := range names {
+		messages[i] = fmt.Sprintf("%s: %v", name, e[name])
+	}
+	return fmt.Sprintf("%d projects failed: %s", len(e), strings.Join(messages, "; "))
+}
+
+// ForEachProject runs callback for each project of the group concurrently.
+// Failed callbacks do not stop the iteration, their errors are collected into ProjectErrors.
 func (c Client) ForEachProject(callback func(project *gitlab.Project) error) error {
+	workers := c.config.GitLab.Workers
+	if workers <= 0 {
+		workers = 1
+	}
+
+	projects := make(chan *gitlab.Project)
+	failures := ProjectErrors{}
+	mutex := sync.Mutex{}
+
+	wg := sync.WaitGroup{}
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for project := range projects {
+				if err := callback(project); err != nil {
+					c.logger.Error("Project callback failed", zap.Error(err), lf.ProjectName(project.Name))
+					mutex.Lock()
+					failures[project.Name] = err
+					mutex.Unlock()
+				}
+			}
+		}()
+	}
+
+	err := c.listProjects(projects)
+	close(projects)
+	wg.Wait()
+
+	if err != nil {
+		return err
+	}
+	if len(failures) > 0 {
+		return failures
+	}
+	return nil
+}
+
+func (c Client) listProjects(projects chan<- *gitlab.Project) error {
 	options := gitlab.ListGroupProjectsOptions{}
 
 	for {
-		projects, resp, err := c.gitlab.Groups.ListGroupProjects(c.config.GitLab.Group.ID, &options)
+		page, resp, err := c.gitlab.Groups.ListGroupProjects(c.config.GitLab.Group.ID, &options)
 		if err != nil {
 			c.logger.Error("Failed to list projects", zap.Error(err))
 			return err
 		}
 
-		for _, project := range projects {
-			if err = callback(project); err != nil {
-				c.logger.Error("Project callback failed", zap.Error(err))
-				return err
-			}
+		for _, project := range page {
+			projects <- project
 		}
 
 		if resp.CurrentPage >= resp.TotalPages {
@@ -0,0 +1,96 @@
+package gitlab
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+)
+
+// fakeProjectsAPI serves projects of the group 7 by two per page
+type fakeProjectsAPI struct {
+	projects []string
+}
+
+func (a *fakeProjectsAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/api/v4/groups/7/projects" {
+		http.NotFound(w, r)
+		return
+	}
+
+	const perPage = 2
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page == 0 {
+		page = 1
+	}
+	totalPages := (len(a.projects) + perPage - 1) / perPage
+	w.Header().Set("X-Page", strconv.Itoa(page))
+	w.Header().Set("X-Total-Pages", strconv.Itoa(totalPages))
+	if page < totalPages {
+		w.Header().Set("X-Next-Page", strconv.Itoa(page+1))
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	body := "["
+	for i := (page - 1) * perPage; i < page*perPage && i < len(a.projects); i++ {
+		if i > (page-1)*perPage {
+			body += ","
+		}
+		body += fmt.Sprintf(`{"id": %d, "name": %q}`, i+1, a.projects[i])
+	}
+	_, _ = w.Write([]byte(body + "]"))
+}
+
+func TestForEachProject(t *testing.T) {
+	api := &fakeProjectsAPI{projects: []string{"neil", "buzz", "michael", "pete", "alan"}}
+	client := newTestClient(t, api)
+	client.config.GitLab.Group.ID = 7
+	client.config.GitLab.Workers = 3
+
+	var mutex sync.Mutex
+	visited := make([]string, 0)
+	err := client.ForEachProject(func(project *gitlab.Project) error {
+		mutex.Lock()
+		visited = append(visited, project.Name)
+		mutex.Unlock()
+		if project.Name == "buzz" || project.Name == "pete" {
+			return errors.New("no luck")
+		}
+		return nil
+	})
+
+	sort.Strings(visited)
+	if expected := []string{"alan", "buzz", "michael", "neil", "pete"}; fmt.Sprint(visited) != fmt.Sprint(expected) {
+		t.Errorf("Unexpected visited projects %v, expected: %v", visited, expected)
+	}
+
+	failures, ok := err.(ProjectErrors)
+	if !ok {
+		t.Fatalf("Unexpected error %v, expected ProjectErrors", err)
+	}
+	if len(failures) != 2 || failures["buzz"] == nil || failures["pete"] == nil {
+		t.Errorf("Unexpected failures %v", failures)
+	}
+	if message := failures.Error(); message != "2 projects failed: buzz: no luck; pete: no luck" {
+		t.Errorf("Unexpected message %q", message)
+	}
+
+	if err = client.ForEachProject(func(project *gitlab.Project) error { return nil }); err != nil {
+		t.Errorf("Unexpected error of successful iteration: %v", err)
+	}
+
+	// Listing errors are returned as is
+	client.config.GitLab.Group.ID = 8
+	err = client.ForEachProject(func(project *gitlab.Project) error {
+		t.Errorf("Unexpected project %s", project.Name)
+		return nil
+	})
+	if _, ok := err.(ProjectErrors); err == nil || ok {
+		t.Errorf("Unexpected error of failed listing: %v", err)
+	}
+}
@@ -12,11 +12,18 @@ import (
 	"github.com/bigredeye/notmanytask/internal/models"
 )
 
+// mergeRequestsStorage is the part of the database used by the updater
+type mergeRequestsStorage interface {
+	AddMergeRequest(mergeRequest *models.MergeRequest) error
+	FindMergeRequest(project string, task string) (*models.MergeRequest, error)
+	FindLatestPipeline(project string, task string) (*models.Pipeline, error)
+}
+
 type MergeRequestsUpdater struct {
 	*Client
 
 	logger *zap.Logger
-	db     *database.DataBase
+	db     mergeRequestsStorage
 }
 
 func NewMergeRequestsUpdater(client *Client, db *database.DataBase) (*MergeRequestsUpdater, error) {
@@ -97,7 +104,7 @@ func (p MergeRequestsUpdater) updateMergeRequests() {
 					}
 					continue
 				}
-				mergeRequestCreated, err := p.createMergeRequest(project.ID, branch.Name)
+				mergeRequestCreated, err := p.createMergeRequest(project.ID, project.Name, project.DefaultBranch, branch.Name)
 				if err != nil {
 					p.logger.Error("Failed to create merge request", zap.Error(err), lf.ProjectName(project.Name), lf.BranchName(branch.Name))
 					continue
@@ -123,11 +130,14 @@ func (p MergeRequestsUpdater) updateMergeRequests() {
 	}
 }
 
-func (p MergeRequestsUpdater) createMergeRequest(project int, branch string) (*gitlab.MergeRequest, error) {
-	main := "main"
+// createMergeRequest opens merge request of the submit branch into the project default branch
+func (p MergeRequestsUpdater) createMergeRequest(project int, projectName string, defaultBranch string, branch string) (*gitlab.MergeRequest, error) {
+	if defaultBranch == "" {
+		defaultBranch = p.config.ProjectFor(p.projectGroup(projectName)).DefaultBranch
+	}
 	options := &gitlab.CreateMergeRequestOptions{
 		SourceBranch: &branch,
-		TargetBranch: &main,
+		TargetBranch: &defaultBranch,
 		Title:        &branch,
 	}
 	mergeRequest, _, err := p.gitlab.MergeRequests.CreateMergeRequest(project, options)
@@ -2,6 +2,7 @@ package gitlab
 
 import (
 	"context"
+	goerrors "errors"
 	"time"
 
 	"github.com/pkg/errors"
@@ -13,11 +14,18 @@ import (
 	"github.com/bigredeye/notmanytask/internal/models"
 )
 
+// pipelinesStorage is the part of the database used by the fetcher
+type pipelinesStorage interface {
+	AddPipeline(pipeline *models.Pipeline) error
+	FindSyncCursor(project string) (*models.SyncCursor, error)
+	UpdateSyncCursor(cursor *models.SyncCursor) error
+}
+
 type PipelinesFetcher struct {
 	*Client
 
 	logger *zap.Logger
-	db     *database.DataBase
+	db     pipelinesStorage
 }
 
 func NewPipelinesFetcher(client *Client, db *database.DataBase) (*PipelinesFetcher, error) {
@@ -30,11 +38,15 @@ func NewPipelinesFetcher(client *Client, db *database.DataBase) (*PipelinesFetch
 
 func (p PipelinesFetcher) Run(ctx context.Context) {
 	tick := time.Tick(p.config.PullIntervals.Pipelines)
+	lastFullSync := time.Time{}
 
 	for {
 		select {
 		case <-tick:
-			p.fetchAllPipelines()
+			full := time.Since(lastFullSync) >= p.config.PullIntervals.PipelinesResync
+			if p.fetchAllPipelines(full) && full {
+				lastFullSync = time.Now()
+			}
 		case <-ctx.Done():
 			p.logger.Info("Stopping pipelines fetcher")
 			return
@@ -75,39 +87,86 @@ func (p PipelinesFetcher) addPipeline(projectName string, pipeline *gitlab.Pipel
 	})
 }
 
-func (p PipelinesFetcher) fetchAllPipelines() {
-	p.logger.Info("Start pipelines fetcher iteration")
+func (p PipelinesFetcher) fetchAllPipelines(full bool) bool {
+	p.logger.Info("Start pipelines fetcher iteration", zap.Bool("full", full))
 	defer p.logger.Info("Finish pipelines fetcher iteration")
 
 	err := p.ForEachProject(func(project *gitlab.Project) error {
-		p.logger.Info("Found project", lf.ProjectName(project.Name))
-		options := &gitlab.ListProjectPipelinesOptions{}
-		for {
-			pipelines, resp, err := p.gitlab.Pipelines.ListProjectPipelines(project.ID, options)
-			if err != nil {
-				p.logger.Error("Failed to list projects", zap.Error(err))
-				return err
-			}
+		return p.fetchProjectPipelines(project, full)
+	})
 
-			for _, pipeline := range pipelines {
-				p.logger.Info("Found pipeline", lf.ProjectName(project.Name), lf.PipelineID(pipeline.ID), lf.PipelineStatus(pipeline.Status))
-				if err = p.addPipeline(project.Name, pipeline); err != nil {
-					p.logger.Error("Failed to add pipeline", zap.Error(err), lf.ProjectName(project.Name), lf.PipelineID(pipeline.ID))
-				}
+	if err == nil {
+		p.logger.Info("Sucessfully fetched pipelines")
+		return true
+	}
+
+	p.logger.Error("Failed to fetch pipelines", zap.Error(err))
+	// Cursors of the failed projects were not moved, so the iteration is still complete
+	var projectErrors ProjectErrors
+	return goerrors.As(err, &projectErrors)
+}
+
+func (p PipelinesFetcher) fetchProjectPipelines(project *gitlab.Project, full bool) error {
+	log := p.logger.With(lf.ProjectName(project.Name))
+	log.Info("Found project")
+
+	cursor, err := p.db.FindSyncCursor(project.Name)
+	if err != nil {
+		log.Error("Failed to find sync cursor", zap.Error(err))
+		return err
+	}
+	if cursor == nil {
+		cursor = &models.SyncCursor{Project: project.Name}
+	}
+
+	orderBy := "updated_at"
+	sortOrder := "asc"
+	options := &gitlab.ListProjectPipelinesOptions{
+		OrderBy: &orderBy,
+		Sort:    &sortOrder,
+	}
+	if !full && !cursor.PipelinesUpdatedAt.IsZero() {
+		// Pipelines are upserted, so overlapping with the previous sync is harmless
+		updatedAfter := cursor.PipelinesUpdatedAt.Add(-time.Second)
+		options.UpdatedAfter = &updatedAfter
+	}
+
+	next := *cursor
+	failed := false
+	for {
+		pipelines, resp, err := p.gitlab.Pipelines.ListProjectPipelines(project.ID, options)
+		if err != nil {
+			log.Error("Failed to list pipelines", zap.Error(err))
+			return err
+		}
+
+		for _, pipeline := range pipelines {
+			log.Info("Found pipeline", lf.PipelineID(pipeline.ID), lf.PipelineStatus(pipeline.Status))
+			if err = p.addPipeline(project.Name, pipeline); err != nil {
+				log.Error("Failed to add pipeline", zap.Error(err), lf.PipelineID(pipeline.ID))
+				failed = true
+				continue
 			}
 
-			if resp.CurrentPage >= resp.TotalPages {
-				break
+			if pipeline.UpdatedAt != nil && !pipeline.UpdatedAt.Before(next.PipelinesUpdatedAt) {
+				next.PipelinesUpdatedAt = *pipeline.UpdatedAt
+				next.LastPipelineID = pipeline.ID
 			}
-			options.Page = resp.NextPage
 		}
 
-		return nil
-	})
+		if resp.CurrentPage >= resp.TotalPages {
+			break
+		}
+		options.Page = resp.NextPage
+	}
 
-	if err == nil {
-		p.logger.Info("Sucessfully fetched pipelines")
-	} else {
-		p.logger.Error("Failed to fetch pipelines", zap.Error(err))
+	// Do not skip pipelines which failed to be stored
+	if !failed && next != *cursor {
+		if err = p.db.UpdateSyncCursor(&next); err != nil {
+			log.Error("Failed to update sync cursor", zap.Error(err))
+			return err
+		}
 	}
+
+	return nil
 }
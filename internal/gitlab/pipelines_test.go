@@ -0,0 +1,147 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+	"go.uber.org/zap"
+
+	"github.com/bigredeye/notmanytask/internal/models"
+)
+
+// fakePipelinesAPI serves pipelines of the project 42 by two per page and records updated_after
+type fakePipelinesAPI struct {
+	pipelines    []string
+	updatedAfter []string
+}
+
+func (a *fakePipelinesAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/api/v4/projects/42/pipelines" {
+		http.NotFound(w, r)
+		return
+	}
+	a.updatedAfter = append(a.updatedAfter, r.URL.Query().Get("updated_after"))
+
+	const perPage = 2
+	page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if page == 0 {
+		page = 1
+	}
+	totalPages := (len(a.pipelines) + perPage - 1) / perPage
+	w.Header().Set("X-Page", strconv.Itoa(page))
+	w.Header().Set("X-Total-Pages", strconv.Itoa(totalPages))
+	if page < totalPages {
+		w.Header().Set("X-Next-Page", strconv.Itoa(page+1))
+	}
+	w.Header().Set("Content-Type", "application/json")
+
+	body := "["
+	for i := (page - 1) * perPage; i < page*perPage && i < len(a.pipelines); i++ {
+		if i > (page-1)*perPage {
+			body += ","
+		}
+		body += a.pipelines[i]
+	}
+	_, _ = w.Write([]byte(body + "]"))
+}
+
+func makePipelineJSON(id int, updatedAt string) string {
+	return fmt.Sprintf(`{"id": %d, "ref": "submits/fly-me-to-the-moon", "status": "success", "created_at": "2021-09-10T10:00:00Z", "updated_at": %q}`, id, updatedAt)
+}
+
+func mustParseTime(value string) time.Time {
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestSyncCursor(t *testing.T) {
+	project := &gitlab.Project{ID: 42, Name: "neil"}
+	pipelines := []string{
+		makePipelineJSON(1, "2021-09-10T10:00:00Z"),
+		makePipelineJSON(2, "2021-09-10T11:00:00Z"),
+		makePipelineJSON(3, "2021-09-10T12:00:00Z"),
+	}
+	initial := &models.SyncCursor{Project: "neil", PipelinesUpdatedAt: mustParseTime("2021-09-10T09:00:00Z")}
+
+	for _, tc := range []struct {
+		name   string
+		cursor *models.SyncCursor
+		full   bool
+		broken []int
+
+		updatedAfter string
+		expected     *models.SyncCursor
+	}{
+		{
+			name:     "first sync",
+			expected: &models.SyncCursor{Project: "neil", PipelinesUpdatedAt: mustParseTime("2021-09-10T12:00:00Z"), LastPipelineID: 3},
+		},
+		{
+			name:   "incremental sync",
+			cursor: initial,
+			// One second overlap with the previous sync
+			updatedAfter: "2021-09-10T08:59:59Z",
+			expected:     &models.SyncCursor{Project: "neil", PipelinesUpdatedAt: mustParseTime("2021-09-10T12:00:00Z"), LastPipelineID: 3},
+		},
+		{
+			name:     "full resync",
+			cursor:   initial,
+			full:     true,
+			expected: &models.SyncCursor{Project: "neil", PipelinesUpdatedAt: mustParseTime("2021-09-10T12:00:00Z"), LastPipelineID: 3},
+		},
+		{
+			name:         "failed pipeline holds the cursor",
+			cursor:       initial,
+			broken:       []int{2},
+			updatedAfter: "2021-09-10T08:59:59Z",
+			expected:     initial,
+		},
+		{
+			name:     "failed first sync does not create the cursor",
+			broken:   []int{3},
+			expected: nil,
+		},
+	} {
+		storage := newFakeStorage()
+		if tc.cursor != nil {
+			storage.cursors["neil"] = *tc.cursor
+		}
+		for _, id := range tc.broken {
+			storage.brokenPipelines[id] = true
+		}
+		api := &fakePipelinesAPI{pipelines: pipelines}
+		fetcher := PipelinesFetcher{Client: newTestClient(t, api), logger: zap.NewNop(), db: storage}
+
+		if err := fetcher.fetchProjectPipelines(project, tc.full); err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+
+		if len(api.updatedAfter) != 2 {
+			t.Errorf("%s: expected 2 pages to be requested, got %d", tc.name, len(api.updatedAfter))
+		}
+		for _, updatedAfter := range api.updatedAfter {
+			if updatedAfter != tc.updatedAfter {
+				t.Errorf("%s: unexpected updated_after %q, expected: %q", tc.name, updatedAfter, tc.updatedAfter)
+			}
+		}
+
+		if stored := len(storage.pipelines); stored != len(pipelines)-len(tc.broken) {
+			t.Errorf("%s: %d pipelines stored, expected: %d", tc.name, stored, len(pipelines)-len(tc.broken))
+		}
+
+		cursor, _ := storage.FindSyncCursor("neil")
+		switch {
+		case tc.expected == nil && cursor != nil:
+			t.Errorf("%s: unexpected cursor %+v", tc.name, cursor)
+		case tc.expected != nil && (cursor == nil || !cursor.PipelinesUpdatedAt.Equal(tc.expected.PipelinesUpdatedAt) || cursor.LastPipelineID != tc.expected.LastPipelineID):
+			t.Errorf("%s: unexpected cursor %+v, expected: %+v", tc.name, cursor, tc.expected)
+		}
+	}
+}
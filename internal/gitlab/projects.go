@@ -2,45 +2,71 @@ package gitlab
 
 import (
 	"context"
+	"math"
 	"time"
 
+	"github.com/pkg/errors"
+	"go.uber.org/zap"
+
 	"github.com/bigredeye/notmanytask/internal/database"
 	"github.com/bigredeye/notmanytask/internal/models"
-	"go.uber.org/zap"
 )
 
+const (
+	provisioningBatchSize  = 16
+	provisioningMinBackoff = 10 * time.Second
+	provisioningMaxBackoff = time.Hour
+	// Jobs are failed after the attempts and wait for admin
+	provisioningMaxAttempts = 12
+)
+
+// ProjectsMaker processes persistent provisioning jobs creating GitLab projects of the users
 type ProjectsMaker struct {
 	*Client
 
 	logger *zap.Logger
 	db     *database.DataBase
-	users  chan *models.User
+	wakeup chan struct{}
 }
 
 func NewProjectsMaker(client *Client, db *database.DataBase) (*ProjectsMaker, error) {
-	return &ProjectsMaker{client, client.logger.Named("projects"), db, make(chan *models.User, 4)}, nil
+	return &ProjectsMaker{client, client.logger.Named("projects"), db, make(chan struct{}, 1)}, nil
 }
 
+// AsyncPrepareProject enqueues the provisioning job of the user and wakes the worker up
 func (p ProjectsMaker) AsyncPrepareProject(user *models.User) {
-	p.users <- user
+	if err := p.db.EnqueueProvisioningJob(user.ID, time.Now()); err != nil {
+		p.logger.Error("Failed to enqueue provisioning job", zap.Error(err), zap.Uint("user_id", user.ID))
+		return
+	}
+	p.Wakeup()
+}
+
+// Wakeup makes the worker process due jobs without waiting for the next tick
+func (p ProjectsMaker) Wakeup() {
+	select {
+	case p.wakeup <- struct{}{}:
+	default:
+	}
 }
 
 func (p ProjectsMaker) Run(ctx context.Context) {
-	p.initializeMissingProjects()
+	if reset, err := p.db.ResetRunningProvisioningJobs(time.Now()); err != nil {
+		p.logger.Error("Failed to reset interrupted provisioning jobs", zap.Error(err))
+	} else if reset > 0 {
+		p.logger.Info("Reset interrupted provisioning jobs", zap.Int64("num_jobs", reset))
+	}
+	p.enqueueMissingJobs()
+	p.processDueJobs(ctx)
 
 	tick := time.Tick(p.config.PullIntervals.Projects)
 	for {
 		select {
-		case user := <-p.users:
-			p.logger.Info("Got user from in-proc channel",
-				zap.Intp("gitlab_id", user.GitlabID),
-				zap.Stringp("gitlab_login", user.GitlabLogin),
-			)
-			if !p.maybeInitializeProject(user) {
-				p.users <- user
-			}
+		case <-p.wakeup:
+			p.processDueJobs(ctx)
 		case <-tick:
-			p.initializeMissingProjects()
+			p.enqueueMissingJobs()
+			p.processDueJobs(ctx)
 		case <-ctx.Done():
 			p.logger.Info("Stopping projects maker")
 			return
@@ -48,56 +74,99 @@ func (p ProjectsMaker) Run(ctx context.Context) {
 	}
 }
 
-func (p ProjectsMaker) initializeMissingProjects() {
-	p.logger.Info("Start projectsMaker iteration")
-	numProjectsInitialized := 0
-	defer p.logger.Info("Finish projectsMaker iteration", zap.Int("num_projects_initialized", numProjectsInitialized))
-
-	users, err := p.db.ListUsersWithoutRepos()
+func (p ProjectsMaker) enqueueMissingJobs() {
+	enqueued, err := p.db.EnqueueMissingProvisioningJobs(time.Now())
 	if err != nil {
-		p.logger.Error("Failed to list users without repos", zap.Error(err))
-		return
+		p.logger.Error("Failed to enqueue provisioning jobs", zap.Error(err))
 	}
+	if enqueued > 0 {
+		p.logger.Info("Enqueued provisioning jobs of users without repos", zap.Int("num_jobs", enqueued))
+	}
+}
 
-	for _, user := range users {
-		p.logger.Info("Got user without repo from database",
-			zap.Intp("gitlab_id", user.GitlabID),
-			zap.Stringp("gitlab_login", user.GitlabLogin),
-		)
-		ok := p.maybeInitializeProject(user)
-		if ok {
-			numProjectsInitialized++
+func (p ProjectsMaker) processDueJobs(ctx context.Context) {
+	for ctx.Err() == nil {
+		jobs, err := p.db.ListDueProvisioningJobs(time.Now(), provisioningBatchSize)
+		if err != nil {
+			p.logger.Error("Failed to list provisioning jobs", zap.Error(err))
+			return
+		}
+		if len(jobs) == 0 {
+			return
+		}
+
+		p.logger.Info("Start projectsMaker iteration", zap.Int("num_jobs", len(jobs)))
+		for i := range jobs {
+			if ctx.Err() != nil {
+				return
+			}
+			p.processJob(&jobs[i])
 		}
 	}
 }
 
-func (p ProjectsMaker) maybeInitializeProject(user *models.User) bool {
-	log := p.logger
-	if user.GitlabID == nil || user.GitlabLogin == nil {
-		log.Error("Trying to initialize repo for user without login, aborting", zap.Uint("user_id", user.ID))
-		return false
+func (p ProjectsMaker) processJob(job *models.ProvisioningJob) {
+	log := p.logger.With(zap.Uint("job_id", job.ID), zap.Uint("user_id", job.UserID))
+
+	claimed, err := p.db.ClaimProvisioningJob(job)
+	if err != nil {
+		log.Error("Failed to claim provisioning job", zap.Error(err))
+		return
+	}
+	if !claimed {
+		return
+	}
+	log = log.With(zap.Int("attempt", job.Attempts))
+
+	err = p.provision(job.UserID)
+	now := time.Now()
+	switch {
+	case err == nil:
+		job.State = models.ProvisioningJobDone
+		job.LastError = ""
+		log.Info("Provisioned project")
+	case job.Attempts >= provisioningMaxAttempts:
+		job.State = models.ProvisioningJobFailed
+		job.LastError = err.Error()
+		log.Error("Provisioning job failed, giving up", zap.Error(err))
+	default:
+		job.State = models.ProvisioningJobPending
+		job.LastError = err.Error()
+		job.NextAttemptAt = now.Add(provisioningBackoff(job.Attempts))
+		log.Warn("Provisioning attempt failed", zap.Error(err), zap.Time("next_attempt_at", job.NextAttemptAt))
+	}
+
+	if err = p.db.FinishProvisioningJob(job); err != nil {
+		log.Error("Failed to save provisioning job", zap.Error(err))
 	}
+}
 
-	log = log.With(zap.Intp("gitlab_id", user.GitlabID), zap.Stringp("gitlab_login", user.GitlabLogin))
+// provisioningBackoff doubles the delay after each failed attempt
+func provisioningBackoff(attempts int) time.Duration {
+	backoff := float64(provisioningMinBackoff) * math.Pow(2, float64(attempts-1))
+	if backoff > float64(provisioningMaxBackoff) {
+		return provisioningMaxBackoff
+	}
+	return time.Duration(backoff)
+}
 
-	err := p.InitializeProject(user)
+func (p ProjectsMaker) provision(userID uint) error {
+	user, err := p.db.FindUserByID(userID)
 	if err != nil {
-		log.Error("Failed to initialize project", zap.Error(err))
-		// TODO(BigRedEye): nice backoff
-		time.Sleep(time.Second * 1)
-		return false
+		return errors.Wrap(err, "Failed to find user")
+	}
+	if user.GitlabID == nil || user.GitlabLogin == nil {
+		return errors.New("User has no GitLab account")
 	}
 
-	project := p.MakeProjectUrl(user)
-	log = log.With(zap.String("project", project))
+	if err = p.InitializeProject(user); err != nil {
+		return errors.Wrap(err, "Failed to initialize project")
+	}
 
+	project := p.MakeProjectUrl(user)
 	user.Repository = &project
-	err = p.db.SetUserRepository(user)
-	if err != nil {
-		log.Error("Failed to set user repo", zap.Error(err))
-		return false
+	if err = p.db.SetUserRepository(user); err != nil {
+		return errors.Wrap(err, "Failed to set user repo")
 	}
-
-	log.Info("Sucessfully set user repo")
-	return true
+	return nil
 }
@@ -0,0 +1,106 @@
+package gitlab
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/xanzy/go-gitlab"
+	"go.uber.org/zap"
+
+	"github.com/bigredeye/notmanytask/internal/config"
+)
+
+func TestProvisioningBackoff(t *testing.T) {
+	for _, tc := range []struct {
+		attempts int
+		backoff  time.Duration
+	}{
+		{1, 10 * time.Second},
+		{2, 20 * time.Second},
+		{4, 80 * time.Second},
+		{9, 2560 * time.Second},
+		{10, time.Hour},
+		{100, time.Hour},
+	} {
+		if backoff := provisioningBackoff(tc.attempts); backoff != tc.backoff {
+			t.Errorf("Attempt %d: expected backoff %s, got %s", tc.attempts, tc.backoff, backoff)
+		}
+	}
+}
+
+func TestValidateProjects(t *testing.T) {
+	conf := &config.Config{}
+	conf.Groups = config.GroupsConfig{{Name: "hse"}, {Name: "ysda", Project: &config.ProjectConfig{ForkFrom: 42}}}
+	if err := ValidateProjects(conf); err == nil {
+		t.Error("Group without template is accepted")
+	}
+
+	conf.GitLab.Project = config.ProjectConfig{ImportURL: "https://example.com/template.git"}
+	if err := ValidateProjects(conf); err != nil {
+		t.Errorf("Valid config is rejected: %v", err)
+	}
+}
+
+func TestEnsureDefaultBranch(t *testing.T) {
+	forked := config.ProjectConfig{ForkFrom: 7, DefaultBranch: "main"}
+	imported := config.ProjectConfig{ImportURL: "https://example.com/template.git", DefaultBranch: "main"}
+
+	for _, tc := range []struct {
+		name     string
+		project  gitlab.Project
+		settings config.ProjectConfig
+		edited   bool
+		failed   bool
+	}{
+		{"finished fork", gitlab.Project{ID: 42, DefaultBranch: "master", ImportStatus: "finished"}, forked, true, false},
+		{"fork in progress", gitlab.Project{ID: 42, ImportStatus: "started"}, forked, false, true},
+		{"branch is already set", gitlab.Project{ID: 42, DefaultBranch: "main", ImportStatus: "finished"}, forked, false, false},
+		{"imported project", gitlab.Project{ID: 42, DefaultBranch: "master", ImportStatus: "finished"}, imported, false, false},
+	} {
+		edited := false
+		api := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPut || r.URL.Path != "/api/v4/projects/42" {
+				http.NotFound(w, r)
+				return
+			}
+			body, _ := ioutil.ReadAll(r.Body)
+			options := gitlab.EditProjectOptions{}
+			if err := json.Unmarshal(body, &options); err != nil || options.DefaultBranch == nil || *options.DefaultBranch != "main" {
+				http.Error(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			edited = true
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"id": 42, "default_branch": "main"}`))
+		})
+
+		client := newTestClient(t, api)
+		err := client.ensureDefaultBranch(zap.NewNop(), &tc.project, tc.settings)
+		if (err != nil) != tc.failed {
+			t.Errorf("%s: unexpected error %v", tc.name, err)
+		}
+		if edited != tc.edited {
+			t.Errorf("%s: default branch changed: %v, expected: %v", tc.name, edited, tc.edited)
+		}
+	}
+}
+
+func TestProjectGroup(t *testing.T) {
+	conf := &config.Config{}
+	conf.Groups = config.GroupsConfig{{Name: "hse"}, {Name: "hse-ami"}}
+	client := Client{config: conf}
+
+	for project, group := range map[string]string{
+		"hse-1-neil-armstrong-neil":        "hse",
+		"hse-ami-2-buzz-aldrin-buzz":       "hse-ami",
+		"ysda-1-michael-collins-collins":   "",
+		"hseami-1-michael-collins-collins": "",
+	} {
+		if got := client.projectGroup(project); got != group {
+			t.Errorf("Invalid group of %s: %q, expected: %q", project, got, group)
+		}
+	}
+}
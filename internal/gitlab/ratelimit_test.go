@@ -0,0 +1,79 @@
+package gitlab
+
+import (
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(1969, 7, 20, 20, 17, 0, 0, time.UTC)
+	for _, tc := range []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{value: "", ok: false},
+		{value: "0", expected: 0, ok: true},
+		{value: "120", expected: 2 * time.Minute, ok: true},
+		{value: now.Add(90 * time.Second).Format(http.TimeFormat), expected: 90 * time.Second, ok: true},
+		{value: "-5", ok: false},
+		{value: "soon", ok: false},
+	} {
+		wait, ok := parseRetryAfter(tc.value, now)
+		if ok != tc.ok || wait != tc.expected {
+			t.Errorf("Unexpected parseRetryAfter(%q): %v, %v, expected: %v, %v", tc.value, wait, ok, tc.expected, tc.ok)
+		}
+	}
+}
+
+func makeResponse(status int, headers map[string]string) *http.Response {
+	resp := &http.Response{StatusCode: status, Header: http.Header{}}
+	for key, value := range headers {
+		resp.Header.Set(key, value)
+	}
+	return resp
+}
+
+func TestRetryAfterBackoff(t *testing.T) {
+	const min, max = time.Second, 10 * time.Second
+
+	resp := makeResponse(http.StatusTooManyRequests, map[string]string{"Retry-After": "30"})
+	if wait := retryAfterBackoff(min, max, 1, resp); wait != 30*time.Second {
+		t.Errorf("Retry-After is not respected: %v", wait)
+	}
+
+	resp = makeResponse(http.StatusTooManyRequests, map[string]string{"Retry-After": "0"})
+	if wait := retryAfterBackoff(min, max, 1, resp); wait != min {
+		t.Errorf("Backoff is shorter than the minimum: %v", wait)
+	}
+
+	reset := time.Now().Add(time.Minute).Unix()
+	resp = makeResponse(http.StatusTooManyRequests, map[string]string{"RateLimit-Reset": strconv.FormatInt(reset, 10)})
+	if wait := retryAfterBackoff(min, max, 1, resp); wait < 55*time.Second || wait > time.Minute {
+		t.Errorf("RateLimit-Reset is not respected: %v", wait)
+	}
+
+	resp = makeResponse(http.StatusTooManyRequests, map[string]string{"RateLimit-Reset": strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10)})
+	if wait := retryAfterBackoff(min, max, 1, resp); wait != min {
+		t.Errorf("Past RateLimit-Reset is not clamped to the minimum: %v", wait)
+	}
+
+	// Headers of other responses are ignored
+	resp = makeResponse(http.StatusInternalServerError, map[string]string{"Retry-After": "30"})
+	if wait := retryAfterBackoff(min, max, 1, resp); wait < min || wait > max {
+		t.Errorf("Unexpected backoff of server error: %v", wait)
+	}
+}
+
+func TestRateLimiter(t *testing.T) {
+	if limiter := newRateLimiter(0, 0); limiter.Limit() != rate.Inf {
+		t.Errorf("Disabled rate limit limits requests: %v", limiter.Limit())
+	}
+	if limiter := newRateLimiter(5, 0); limiter.Limit() != 5 || limiter.Burst() != 1 {
+		t.Errorf("Unexpected limiter %v, burst %d", limiter.Limit(), limiter.Burst())
+	}
+}
@@ -0,0 +1,56 @@
+package gitlab
+
+import (
+	"github.com/pkg/errors"
+	"github.com/xanzy/go-gitlab"
+
+	"github.com/bigredeye/notmanytask/internal/config"
+)
+
+var memberRoles = map[string]gitlab.AccessLevelValue{
+	"guest":      gitlab.GuestPermissions,
+	"reporter":   gitlab.ReporterPermissions,
+	"developer":  gitlab.DeveloperPermissions,
+	"maintainer": gitlab.MaintainerPermissions,
+}
+
+func parseMemberRole(role string) (gitlab.AccessLevelValue, error) {
+	level, found := memberRoles[role]
+	if !found {
+		return gitlab.NoPermissions, errors.Errorf("Unknown member role %q", role)
+	}
+	return level, nil
+}
+
+func parseVisibility(visibility string) (gitlab.VisibilityValue, error) {
+	switch value := gitlab.VisibilityValue(visibility); value {
+	case gitlab.PrivateVisibility, gitlab.InternalVisibility, gitlab.PublicVisibility:
+		return value, nil
+	default:
+		return "", errors.Errorf("Unknown project visibility %q", visibility)
+	}
+}
+
+// ValidateProjects checks project settings of every group
+func ValidateProjects(c *config.Config) error {
+	for _, group := range c.Groups {
+		settings := c.ProjectFor(group.Name)
+		if _, err := parseVisibility(settings.Visibility); err != nil {
+			return errors.Wrapf(err, "Invalid project config of group %s", group.Name)
+		}
+		if _, err := parseMemberRole(settings.MemberRole); err != nil {
+			return errors.Wrapf(err, "Invalid project config of group %s", group.Name)
+		}
+		if settings.ImportURL == "" && settings.ForkFrom == 0 {
+			return errors.Errorf("Invalid project config of group %s: either importURL or forkFrom is required", group.Name)
+		}
+	}
+	return nil
+}
+
+func defaultBranchOf(project *gitlab.Project, settings config.ProjectConfig) string {
+	if project.DefaultBranch != "" {
+		return project.DefaultBranch
+	}
+	return settings.DefaultBranch
+}
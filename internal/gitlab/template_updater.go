@@ -0,0 +1,369 @@
+package gitlab
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/xanzy/go-gitlab"
+	"go.uber.org/zap"
+
+	"github.com/bigredeye/notmanytask/internal/config"
+	"github.com/bigredeye/notmanytask/internal/database"
+	lf "github.com/bigredeye/notmanytask/internal/logfield"
+	"github.com/bigredeye/notmanytask/internal/models"
+)
+
+const (
+	templateBranchPrefix      = "updates/"
+	templateMergeRequestTitle = "Update from template"
+)
+
+// TemplateUpdater merges new commits of the template projects into student projects
+type TemplateUpdater struct {
+	*Client
+
+	logger  *zap.Logger
+	db      *database.DataBase
+	trigger chan struct{}
+}
+
+func NewTemplateUpdater(client *Client, db *database.DataBase) (*TemplateUpdater, error) {
+	return &TemplateUpdater{
+		Client:  client,
+		logger:  client.logger.Named("templates"),
+		db:      db,
+		trigger: make(chan struct{}, 1),
+	}, nil
+}
+
+// Trigger schedules update of all projects, does nothing if one is already scheduled
+func (p TemplateUpdater) Trigger() {
+	select {
+	case p.trigger <- struct{}{}:
+	default:
+	}
+}
+
+func (p TemplateUpdater) Run(ctx context.Context) {
+	// Updates are triggered by admins only if the interval is not set
+	var tick <-chan time.Time
+	if p.config.PullIntervals.TemplateUpdates > 0 {
+		tick = time.Tick(p.config.PullIntervals.TemplateUpdates)
+	}
+
+	for {
+		select {
+		case <-tick:
+			p.updateAllProjects()
+		case <-p.trigger:
+			p.updateAllProjects()
+		case <-ctx.Done():
+			p.logger.Info("Stopping template updater")
+			return
+		}
+	}
+}
+
+// templateHead is the template commit published to student projects
+type templateHead struct {
+	project *gitlab.Project
+	branch  string
+	commit  string
+	shortID string
+}
+
+type templateHeads struct {
+	mutex sync.Mutex
+	heads map[int]*templateHead
+}
+
+func (p TemplateUpdater) updateAllProjects() {
+	p.logger.Info("Start template updater iteration")
+	defer p.logger.Info("Finish template updater iteration")
+
+	users, err := p.db.ListUsers("", "")
+	if err != nil {
+		p.logger.Error("Failed to list users", zap.Error(err))
+		return
+	}
+	projectUsers := make(map[string]*models.User, len(users))
+	for _, user := range users {
+		if user.GitlabLogin != nil {
+			projectUsers[p.MakeProjectName(user)] = user
+		}
+	}
+
+	heads := &templateHeads{heads: make(map[int]*templateHead)}
+	err = p.ForEachProject(func(project *gitlab.Project) error {
+		user := projectUsers[project.Name]
+		if user == nil {
+			return nil
+		}
+		settings := p.config.ProjectFor(user.GroupName)
+		if settings.ForkFrom == 0 {
+			return nil
+		}
+		head, err := heads.get(p, settings.ForkFrom)
+		if err != nil {
+			return err
+		}
+		_, err = p.updateProject(project, head, settings)
+		return err
+	})
+	if err != nil {
+		// Statuses of the failed projects are unknown, their update branches may still be needed
+		p.logger.Error("Failed to update projects from template", zap.Error(err))
+		return
+	}
+
+	updates, err := p.db.ListTemplateUpdates()
+	if err != nil {
+		p.logger.Error("Failed to list template updates", zap.Error(err))
+		return
+	}
+	pending := make(map[string]bool)
+	for _, update := range updates {
+		if update.Status == models.TemplateUpdateOpened || update.Status == models.TemplateUpdateConflict {
+			pending[update.TemplateCommit] = true
+		}
+	}
+	for _, head := range heads.heads {
+		if err = p.deleteStaleBranches(head, pending); err != nil {
+			p.logger.Error("Failed to delete stale template branches", zap.Error(err), zap.Int("template_id", head.project.ID))
+		}
+	}
+}
+
+// deleteStaleBranches deletes update branches which every project has merged or closed,
+// the branch of the current head is kept for new projects
+func (p TemplateUpdater) deleteStaleBranches(head *templateHead, pending map[string]bool) error {
+	stale := make([]string, 0)
+	options := &gitlab.ListBranchesOptions{Search: gitlab.String("^" + templateBranchPrefix)}
+	for {
+		branches, resp, err := p.gitlab.Branches.ListBranches(head.project.ID, options)
+		if err != nil {
+			return errors.Wrap(err, "Failed to list template branches")
+		}
+		for _, branch := range branches {
+			if !strings.HasPrefix(branch.Name, templateBranchPrefix) || branch.Name == head.branch {
+				continue
+			}
+			if branch.Commit != nil && pending[branch.Commit.ID] {
+				continue
+			}
+			stale = append(stale, branch.Name)
+		}
+
+		if resp.CurrentPage >= resp.TotalPages {
+			break
+		}
+		options.Page = resp.NextPage
+	}
+
+	for _, branch := range stale {
+		if _, err := p.gitlab.Branches.DeleteBranch(head.project.ID, branch); err != nil {
+			return errors.Wrapf(err, "Failed to delete branch %s", branch)
+		}
+		p.logger.Info("Deleted stale template update branch", zap.Int("template_id", head.project.ID), lf.BranchName(branch))
+	}
+	return nil
+}
+
+func (h *templateHeads) get(p TemplateUpdater, template int) (*templateHead, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	if head, found := h.heads[template]; found {
+		return head, nil
+	}
+	head, err := p.publishTemplateHead(template)
+	if err != nil {
+		return nil, err
+	}
+	h.heads[template] = head
+	return head, nil
+}
+
+// publishTemplateHead pins the latest template commit to the update branch,
+// merge requests of the update are not affected by further template commits
+func (p TemplateUpdater) publishTemplateHead(template int) (*templateHead, error) {
+	project, _, err := p.gitlab.Projects.GetProject(template, &gitlab.GetProjectOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get template project")
+	}
+	branch, _, err := p.gitlab.Branches.GetBranch(project.ID, project.DefaultBranch)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get template branch")
+	}
+
+	head := &templateHead{
+		project: project,
+		branch:  templateBranchPrefix + branch.Commit.ShortID,
+		commit:  branch.Commit.ID,
+		shortID: branch.Commit.ShortID,
+	}
+	_, resp, err := p.gitlab.Branches.GetBranch(project.ID, head.branch)
+	if resp != nil && resp.StatusCode == http.StatusNotFound {
+		_, _, err = p.gitlab.Branches.CreateBranch(project.ID, &gitlab.CreateBranchOptions{
+			Branch: &head.branch,
+			Ref:    &head.commit,
+		})
+		if err == nil {
+			p.logger.Info("Created template update branch", zap.Int("template_id", project.ID), lf.BranchName(head.branch))
+		}
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create template update branch")
+	}
+	return head, nil
+}
+
+// SyncProject updates the single student project from the template
+func (p TemplateUpdater) SyncProject(user *models.User) (*models.TemplateUpdate, error) {
+	settings := p.config.ProjectFor(user.GroupName)
+	if settings.ForkFrom == 0 {
+		return nil, errors.New("Template project is not configured, set forkFrom")
+	}
+	if user.GitlabLogin == nil {
+		return nil, errors.New("Empty gitlab user")
+	}
+
+	project, _, err := p.gitlab.Projects.GetProject(p.MakeProjectWithNamespace(p.MakeProjectName(user)), &gitlab.GetProjectOptions{})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to get project")
+	}
+	head, err := p.publishTemplateHead(settings.ForkFrom)
+	if err != nil {
+		return nil, err
+	}
+	return p.updateProject(project, head, settings)
+}
+
+func (p TemplateUpdater) updateProject(project *gitlab.Project, head *templateHead, settings config.ProjectConfig) (*models.TemplateUpdate, error) {
+	log := p.logger.With(lf.ProjectName(project.Name), zap.String("template_commit", head.commit))
+
+	previous, err := p.db.FindTemplateUpdate(project.Name)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to find template update")
+	}
+
+	update := &models.TemplateUpdate{
+		Project:        project.Name,
+		TemplateCommit: head.commit,
+	}
+	if previous != nil && previous.TemplateCommit == head.commit {
+		update.MergeRequestIID = previous.MergeRequestIID
+		update.MergeRequestURL = previous.MergeRequestURL
+	}
+
+	if err = p.syncProject(log, project, head, settings, update, previous); err != nil {
+		log.Error("Failed to update project from template", zap.Error(err))
+		update.Status = models.TemplateUpdateFailed
+		update.Error = err.Error()
+	}
+	if err := p.db.SaveTemplateUpdate(update); err != nil {
+		return nil, errors.Wrap(err, "Failed to save template update")
+	}
+	return update, nil
+}
+
+func (p TemplateUpdater) syncProject(log *zap.Logger, project *gitlab.Project, head *templateHead, settings config.ProjectConfig, update *models.TemplateUpdate, previous *models.TemplateUpdate) error {
+	merged, err := p.containsCommit(project, head.commit)
+	if err != nil {
+		return err
+	}
+	if merged {
+		update.Status = models.TemplateUpdateMerged
+		return nil
+	}
+
+	if update.MergeRequestIID == 0 {
+		if previous != nil && (previous.Status == models.TemplateUpdateOpened || previous.Status == models.TemplateUpdateConflict) {
+			p.closeMergeRequest(log, project, previous.MergeRequestIID)
+		}
+
+		mergeRequest, err := p.openMergeRequest(project, head, settings)
+		if err != nil {
+			return err
+		}
+		log.Info("Opened template merge request", lf.MergeRequestID(mergeRequest.IID))
+		update.MergeRequestIID = mergeRequest.IID
+		update.MergeRequestURL = mergeRequest.WebURL
+	}
+
+	mergeRequest, _, err := p.gitlab.MergeRequests.GetMergeRequest(project.ID, update.MergeRequestIID, &gitlab.GetMergeRequestsOptions{})
+	if err != nil {
+		return errors.Wrap(err, "Failed to get template merge request")
+	}
+
+	switch {
+	case mergeRequest.State == "merged":
+		update.Status = models.TemplateUpdateMerged
+	case mergeRequest.State == "closed":
+		update.Status = models.TemplateUpdateClosed
+	case mergeRequest.MergeStatus == "cannot_be_merged":
+		update.Status = models.TemplateUpdateConflict
+	case mergeRequest.MergeStatus == "can_be_merged" && p.config.GitLab.AutoMergeTemplate:
+		_, _, err = p.gitlab.MergeRequests.AcceptMergeRequest(project.ID, mergeRequest.IID, &gitlab.AcceptMergeRequestOptions{
+			SHA: &head.commit,
+		})
+		if err != nil {
+			return errors.Wrap(err, "Failed to merge template merge request")
+		}
+		log.Info("Merged template merge request", lf.MergeRequestID(mergeRequest.IID))
+		update.Status = models.TemplateUpdateMerged
+	default:
+		// Mergeability is checked asynchronously, the request is revisited on the next iteration
+		update.Status = models.TemplateUpdateOpened
+	}
+	return nil
+}
+
+// containsCommit checks if the commit is merged into the project default branch
+func (p TemplateUpdater) containsCommit(project *gitlab.Project, commit string) (bool, error) {
+	base, resp, err := p.gitlab.Repositories.MergeBase(project.ID, &gitlab.MergeBaseOptions{
+		Ref: []string{commit, project.DefaultBranch},
+	})
+	if err != nil {
+		// Unknown commit
+		if resp != nil && (resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusBadRequest) {
+			return false, nil
+		}
+		return false, errors.Wrap(err, "Failed to find merge base")
+	}
+	return base.ID == commit, nil
+}
+
+func (p TemplateUpdater) openMergeRequest(project *gitlab.Project, head *templateHead, settings config.ProjectConfig) (*gitlab.MergeRequest, error) {
+	// Merge requests between projects are allowed only inside the fork network
+	if project.ForkedFromProject == nil {
+		if _, _, err := p.gitlab.Projects.CreateProjectForkRelation(project.ID, head.project.ID); err != nil {
+			return nil, errors.Wrap(err, "Failed to create fork relation")
+		}
+	}
+
+	mergeRequest, _, err := p.gitlab.MergeRequests.CreateMergeRequest(head.project.ID, &gitlab.CreateMergeRequestOptions{
+		Title:           gitlab.String(fmt.Sprintf("%s %s", templateMergeRequestTitle, head.shortID)),
+		SourceBranch:    &head.branch,
+		TargetBranch:    gitlab.String(defaultBranchOf(project, settings)),
+		TargetProjectID: &project.ID,
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create template merge request")
+	}
+	return mergeRequest, nil
+}
+
+// closeMergeRequest closes merge request of the outdated template update
+func (p TemplateUpdater) closeMergeRequest(log *zap.Logger, project *gitlab.Project, iid int) {
+	_, _, err := p.gitlab.MergeRequests.UpdateMergeRequest(project.ID, iid, &gitlab.UpdateMergeRequestOptions{
+		StateEvent: gitlab.String("close"),
+	})
+	if err != nil {
+		log.Warn("Failed to close outdated template merge request", lf.MergeRequestID(iid), zap.Error(err))
+	}
+}
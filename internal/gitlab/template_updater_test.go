@@ -0,0 +1,50 @@
+package gitlab
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/xanzy/go-gitlab"
+	"go.uber.org/zap"
+)
+
+func TestDeleteStaleBranches(t *testing.T) {
+	var deleted []string
+	api := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		const branches = "/api/v4/projects/7/repository/branches"
+		switch {
+		case r.Method == http.MethodGet && r.URL.Path == branches:
+			if r.URL.Query().Get("search") != "^updates/" {
+				http.Error(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`[
+				{"name": "updates/aaaaaaa", "commit": {"id": "aaaaaaa111"}},
+				{"name": "updates/bbbbbbb", "commit": {"id": "bbbbbbb222"}},
+				{"name": "updates/ccccccc", "commit": {"id": "ccccccc333"}}
+			]`))
+		case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, branches+"/"):
+			deleted = append(deleted, strings.TrimPrefix(r.URL.Path, branches+"/"))
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			http.NotFound(w, r)
+		}
+	})
+
+	updater := TemplateUpdater{Client: newTestClient(t, api), logger: zap.NewNop()}
+	head := &templateHead{project: &gitlab.Project{ID: 7}, branch: "updates/ccccccc", commit: "ccccccc333"}
+	// Some project has not merged the update yet
+	pending := map[string]bool{"bbbbbbb222": true}
+
+	if err := updater.deleteStaleBranches(head, pending); err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(deleted)
+	if diff := cmp.Diff([]string{"updates/aaaaaaa"}, deleted); diff != "" {
+		t.Errorf("Unexpected deleted branches (-want +got):\n%s", diff)
+	}
+}
@@ -0,0 +1,191 @@
+package gitlab
+
+import (
+	goerrors "errors"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/xanzy/go-gitlab"
+	"go.uber.org/zap"
+
+	lf "github.com/bigredeye/notmanytask/internal/logfield"
+	"github.com/bigredeye/notmanytask/internal/models"
+)
+
+// GitLab webhooks use their own time format, see gitlab issue #21468
+const webhookTimeFormat = "2006-01-02 15:04:05 MST"
+
+// Push events for removed branches have zero "after" commit
+const deletedBranchSHA = "0000000000000000000000000000000000000000"
+
+func parseWebhookTime(value string) (time.Time, error) {
+	t, err := time.Parse(webhookTimeFormat, value)
+	if err == nil {
+		return t, nil
+	}
+	t, err = time.Parse(time.RFC3339, value)
+	if err != nil {
+		return t, errors.Wrapf(err, "Failed to parse webhook time %q", value)
+	}
+	return t, nil
+}
+
+// IgnoredEvent is returned for webhooks which do not concern the course
+type IgnoredEvent struct {
+	Reason string
+}
+
+func (e *IgnoredEvent) Error() string {
+	return "Ignored event: " + e.Reason
+}
+
+func IsIgnoredEvent(err error) bool {
+	ignored := &IgnoredEvent{}
+	return goerrors.As(err, &ignored)
+}
+
+func unknownProject(pathWithNamespace string) error {
+	return &IgnoredEvent{Reason: "unknown project " + pathWithNamespace}
+}
+
+// IsCourseProject checks that project belongs to the course group
+func (c Client) IsCourseProject(pathWithNamespace string) bool {
+	return strings.HasPrefix(pathWithNamespace, c.config.GitLab.Group.Name+"/")
+}
+
+func (p PipelinesFetcher) HandlePipelineEvent(event *gitlab.PipelineEvent) error {
+	if !p.IsCourseProject(event.Project.PathWithNamespace) {
+		return unknownProject(event.Project.PathWithNamespace)
+	}
+
+	attrs := &event.ObjectAttributes
+	log := p.logger.With(
+		lf.ProjectName(event.Project.Name),
+		lf.PipelineID(attrs.ID),
+		lf.PipelineStatus(attrs.Status),
+	)
+
+	createdAt, err := parseWebhookTime(attrs.CreatedAt)
+	if err != nil {
+		log.Warn("Invalid pipeline creation time, fetching pipeline", zap.Error(err))
+		return p.Fetch(attrs.ID, event.Project.Name)
+	}
+
+	log.Info("Received pipeline event")
+	return p.addPipeline(event.Project.Name, &gitlab.PipelineInfo{
+		ID:        attrs.ID,
+		Ref:       attrs.Ref,
+		Status:    attrs.Status,
+		CreatedAt: &createdAt,
+		ProjectID: event.Project.ID,
+	})
+}
+
+func (p MergeRequestsUpdater) HandleMergeEvent(event *gitlab.MergeEvent) error {
+	if !p.IsCourseProject(event.Project.PathWithNamespace) {
+		return unknownProject(event.Project.PathWithNamespace)
+	}
+
+	attrs := &event.ObjectAttributes
+	if !IsSubmitBranch(attrs.SourceBranch) {
+		return &IgnoredEvent{Reason: "not a submit branch " + attrs.SourceBranch}
+	}
+
+	project := event.Project.Name
+	task := ParseTaskFromBranch(attrs.SourceBranch)
+	log := p.logger.With(
+		lf.ProjectName(project),
+		lf.BranchName(attrs.SourceBranch),
+		lf.MergeRequestID(attrs.ID),
+		zap.String("state", attrs.State),
+	)
+	log.Info("Received merge request event")
+
+	createdAt, err := parseWebhookTime(attrs.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	// Merge request events do not carry notes count, so keep review status
+	status := models.MergeRequestPending
+	existing, err := p.db.FindMergeRequest(project, task)
+	if err != nil {
+		return errors.Wrap(err, "Failed to find merge request")
+	}
+	if existing != nil && existing.ID == attrs.ID {
+		status = existing.Status
+	}
+	if attrs.State == "merged" {
+		status = models.MergeRequestMerged
+	}
+
+	return p.db.AddMergeRequest(&models.MergeRequest{
+		ID:        attrs.ID,
+		Task:      task,
+		Status:    status,
+		Project:   project,
+		StartedAt: createdAt,
+		IID:       attrs.IID,
+	})
+}
+
+func (p MergeRequestsUpdater) HandleMergeCommentEvent(event *gitlab.MergeCommentEvent) error {
+	if !p.IsCourseProject(event.Project.PathWithNamespace) {
+		return unknownProject(event.Project.PathWithNamespace)
+	}
+
+	if !IsSubmitBranch(event.MergeRequest.SourceBranch) {
+		return &IgnoredEvent{Reason: "not a submit branch " + event.MergeRequest.SourceBranch}
+	}
+
+	project := event.Project.Name
+	task := ParseTaskFromBranch(event.MergeRequest.SourceBranch)
+
+	mergeRequest, err := p.db.FindMergeRequest(project, task)
+	if err != nil {
+		return errors.Wrap(err, "Failed to find merge request")
+	}
+	if mergeRequest == nil || mergeRequest.ID != event.MergeRequest.ID {
+		return &IgnoredEvent{Reason: "unknown merge request"}
+	}
+	if mergeRequest.Status == models.MergeRequestMerged {
+		return nil
+	}
+
+	p.logger.Info("Merge request is on review", lf.ProjectName(project), lf.BranchName(event.MergeRequest.SourceBranch))
+	mergeRequest.Status = models.MergeRequestOnReview
+	return p.db.AddMergeRequest(mergeRequest)
+}
+
+// HandlePushEvent opens merge request for the new submit branch without waiting for the poller
+func (p MergeRequestsUpdater) HandlePushEvent(event *gitlab.PushEvent) error {
+	if !p.IsCourseProject(event.Project.PathWithNamespace) {
+		return unknownProject(event.Project.PathWithNamespace)
+	}
+
+	branch := strings.TrimPrefix(event.Ref, "refs/heads/")
+	if !IsSubmitBranch(branch) {
+		return &IgnoredEvent{Reason: "not a submit branch " + branch}
+	}
+	if event.After == deletedBranchSHA {
+		return &IgnoredEvent{Reason: "deleted branch " + branch}
+	}
+
+	project := event.Project.Name
+	mergeRequest, err := p.db.FindMergeRequest(project, ParseTaskFromBranch(branch))
+	if err != nil {
+		return errors.Wrap(err, "Failed to find merge request")
+	}
+	if mergeRequest != nil {
+		return nil
+	}
+
+	mergeRequestCreated, err := p.createMergeRequest(event.ProjectID, project, event.Project.DefaultBranch, branch)
+	if err != nil {
+		return errors.Wrap(err, "Failed to create merge request")
+	}
+	p.logger.Info("Created merge request", lf.ProjectName(project), lf.BranchName(branch))
+
+	return p.addMergeRequest(project, mergeRequestCreated)
+}
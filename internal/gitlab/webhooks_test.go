@@ -0,0 +1,411 @@
+package gitlab
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/xanzy/go-gitlab"
+	"go.uber.org/zap"
+
+	"github.com/bigredeye/notmanytask/internal/config"
+	"github.com/bigredeye/notmanytask/internal/models"
+)
+
+// Payloads captured from GitLab, trimmed to the fields used by the handlers
+const (
+	pipelinePayload = `{
+  "object_kind": "pipeline",
+  "object_attributes": {
+    "id": 3141,
+    "ref": "submits/fly-me-to-the-moon",
+    "tag": false,
+    "sha": "b8a5c1f0f0b1bc0e8dfd5b5e0e1b0c1d6a0e9a7b",
+    "status": "success",
+    "stages": ["build", "test"],
+    "created_at": "2021-09-10 10:00:00 UTC",
+    "finished_at": "2021-09-10 10:05:00 UTC",
+    "duration": 300
+  },
+  "project": {
+    "id": 42,
+    "name": "neil",
+    "path_with_namespace": "hse-cpp/neil",
+    "default_branch": "master"
+  }
+}`
+
+	mergeRequestPayload = `{
+  "object_kind": "merge_request",
+  "event_type": "merge_request",
+  "project": {
+    "id": 42,
+    "name": "neil",
+    "path_with_namespace": "hse-cpp/neil",
+    "default_branch": "master"
+  },
+  "object_attributes": {
+    "id": 2718,
+    "iid": 7,
+    "target_branch": "master",
+    "source_branch": "submits/fly-me-to-the-moon",
+    "source_project_id": 42,
+    "target_project_id": 42,
+    "title": "submits/fly-me-to-the-moon",
+    "created_at": "2021-09-10 10:00:00 UTC",
+    "updated_at": "2021-09-11 12:00:00 UTC",
+    "state": "merged",
+    "merge_status": "can_be_merged",
+    "action": "merge"
+  }
+}`
+
+	mergeCommentPayload = `{
+  "object_kind": "note",
+  "event_type": "note",
+  "project_id": 42,
+  "project": {
+    "id": 42,
+    "name": "neil",
+    "path_with_namespace": "hse-cpp/neil",
+    "default_branch": "master"
+  },
+  "object_attributes": {
+    "id": 1244,
+    "note": "Why is this loop quadratic?",
+    "noteable_type": "MergeRequest",
+    "noteable_id": 2718,
+    "created_at": "2021-09-11 12:00:00 UTC"
+  },
+  "merge_request": {
+    "id": 2718,
+    "iid": 7,
+    "target_branch": "master",
+    "source_branch": "submits/fly-me-to-the-moon",
+    "source_project_id": 42,
+    "title": "submits/fly-me-to-the-moon",
+    "created_at": "2021-09-10 10:00:00 UTC",
+    "state": "opened"
+  }
+}`
+
+	pushPayload = `{
+  "object_kind": "push",
+  "event_name": "push",
+  "before": "0000000000000000000000000000000000000000",
+  "after": "b8a5c1f0f0b1bc0e8dfd5b5e0e1b0c1d6a0e9a7b",
+  "ref": "refs/heads/submits/fly-me-to-the-moon",
+  "checkout_sha": "b8a5c1f0f0b1bc0e8dfd5b5e0e1b0c1d6a0e9a7b",
+  "user_username": "neil",
+  "project_id": 42,
+  "project": {
+    "id": 42,
+    "name": "neil",
+    "path_with_namespace": "hse-cpp/neil",
+    "default_branch": "master"
+  },
+  "total_commits_count": 1
+}`
+)
+
+// fakeStorage keeps pipelines, merge requests and sync cursors in memory
+type fakeStorage struct {
+	pipelines     map[int]models.Pipeline
+	mergeRequests map[string]models.MergeRequest
+	cursors       map[string]models.SyncCursor
+
+	// Pipelines failing to be stored
+	brokenPipelines map[int]bool
+	err             error
+}
+
+func newFakeStorage() *fakeStorage {
+	return &fakeStorage{
+		pipelines:       make(map[int]models.Pipeline),
+		mergeRequests:   make(map[string]models.MergeRequest),
+		cursors:         make(map[string]models.SyncCursor),
+		brokenPipelines: make(map[int]bool),
+	}
+}
+
+func (s *fakeStorage) AddPipeline(pipeline *models.Pipeline) error {
+	if s.err != nil {
+		return s.err
+	}
+	if s.brokenPipelines[pipeline.ID] {
+		return errors.New("broken pipeline")
+	}
+	s.pipelines[pipeline.ID] = *pipeline
+	return nil
+}
+
+func (s *fakeStorage) FindSyncCursor(project string) (*models.SyncCursor, error) {
+	cursor, found := s.cursors[project]
+	if !found {
+		return nil, nil
+	}
+	return &cursor, nil
+}
+
+func (s *fakeStorage) UpdateSyncCursor(cursor *models.SyncCursor) error {
+	s.cursors[cursor.Project] = *cursor
+	return nil
+}
+
+func (s *fakeStorage) AddMergeRequest(mergeRequest *models.MergeRequest) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.mergeRequests[mergeRequest.Project+"/"+mergeRequest.Task] = *mergeRequest
+	return nil
+}
+
+func (s *fakeStorage) FindMergeRequest(project string, task string) (*models.MergeRequest, error) {
+	mergeRequest, found := s.mergeRequests[project+"/"+task]
+	if !found {
+		return nil, nil
+	}
+	return &mergeRequest, nil
+}
+
+func (s *fakeStorage) FindLatestPipeline(project string, task string) (*models.Pipeline, error) {
+	return nil, nil
+}
+
+// newTestClient makes client of the hse-cpp group talking to the fake GitLab API
+func newTestClient(t *testing.T, api http.Handler) *Client {
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+
+	client, err := gitlab.NewClient("token", gitlab.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := &config.Config{}
+	conf.GitLab.Group.Name = "hse-cpp"
+	return &Client{config: conf, gitlab: client, logger: zap.NewNop()}
+}
+
+func parseEvent(t *testing.T, eventType gitlab.EventType, payload string) interface{} {
+	event, err := gitlab.ParseWebhook(eventType, []byte(payload))
+	if err != nil {
+		t.Fatalf("Failed to parse %s: %v", eventType, err)
+	}
+	return event
+}
+
+// withField patches the payload, top level field if object is empty
+func withField(t *testing.T, payload string, object string, field string, value interface{}) string {
+	event := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(payload), &event); err != nil {
+		t.Fatal(err)
+	}
+	if object == "" {
+		event[field] = value
+	} else {
+		event[object].(map[string]interface{})[field] = value
+	}
+	patched, err := json.Marshal(event)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(patched)
+}
+
+type webhookOutcome int
+
+const (
+	webhookHandled webhookOutcome = iota
+	webhookIgnored
+	webhookFailed
+)
+
+func checkOutcome(t *testing.T, name string, err error, expected webhookOutcome) {
+	switch {
+	case expected == webhookHandled && err != nil:
+		t.Errorf("%s: unexpected error: %v", name, err)
+	case expected == webhookIgnored && !IsIgnoredEvent(err):
+		t.Errorf("%s: event is not ignored: %v", name, err)
+	case expected == webhookFailed && (err == nil || IsIgnoredEvent(err)):
+		t.Errorf("%s: expected storage failure, got: %v", name, err)
+	}
+}
+
+func TestHandlePipelineEvent(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		payload string
+		broken  bool
+		outcome webhookOutcome
+	}{
+		{"submit", pipelinePayload, false, webhookHandled},
+		{"foreign project", withField(t, pipelinePayload, "project", "path_with_namespace", "other-course/neil"), false, webhookIgnored},
+		{"storage failure", pipelinePayload, true, webhookFailed},
+	} {
+		storage := newFakeStorage()
+		if tc.broken {
+			storage.err = errors.New("connection refused")
+		}
+		fetcher := PipelinesFetcher{Client: newTestClient(t, http.NotFoundHandler()), logger: zap.NewNop(), db: storage}
+
+		err := fetcher.HandlePipelineEvent(parseEvent(t, gitlab.EventTypePipeline, tc.payload).(*gitlab.PipelineEvent))
+		checkOutcome(t, tc.name, err, tc.outcome)
+		if tc.outcome != webhookHandled {
+			continue
+		}
+
+		pipeline, found := storage.pipelines[3141]
+		if !found {
+			t.Fatalf("%s: pipeline is not stored", tc.name)
+		}
+		if pipeline.Task != "fly-me-to-the-moon" || pipeline.Project != "neil" || pipeline.Status != models.PipelineStatusSuccess {
+			t.Errorf("%s: unexpected pipeline %+v", tc.name, pipeline)
+		}
+		if pipeline.StartedAt.Format(webhookTimeFormat) != "2021-09-10 10:00:00 UTC" {
+			t.Errorf("%s: unexpected start time %s", tc.name, pipeline.StartedAt)
+		}
+	}
+}
+
+func TestHandleMergeEvent(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		payload  string
+		existing *models.MergeRequest
+		broken   bool
+		outcome  webhookOutcome
+		status   models.MergeRequestStatus
+	}{
+		{"merged", mergeRequestPayload, nil, false, webhookHandled, models.MergeRequestMerged},
+		{"opened", withField(t, mergeRequestPayload, "object_attributes", "state", "opened"), nil, false, webhookHandled, models.MergeRequestPending},
+		{
+			"review status is kept",
+			withField(t, mergeRequestPayload, "object_attributes", "state", "opened"),
+			&models.MergeRequest{ID: 2718, Task: "fly-me-to-the-moon", Project: "neil", Status: models.MergeRequestOnReview},
+			false, webhookHandled, models.MergeRequestOnReview,
+		},
+		{"foreign project", withField(t, mergeRequestPayload, "project", "path_with_namespace", "other-course/neil"), nil, false, webhookIgnored, ""},
+		{"not a submit", withField(t, mergeRequestPayload, "object_attributes", "source_branch", "master"), nil, false, webhookIgnored, ""},
+		{"storage failure", mergeRequestPayload, nil, true, webhookFailed, ""},
+	} {
+		storage := newFakeStorage()
+		if tc.existing != nil {
+			storage.mergeRequests["neil/fly-me-to-the-moon"] = *tc.existing
+		}
+		if tc.broken {
+			storage.err = errors.New("connection refused")
+		}
+		updater := MergeRequestsUpdater{Client: newTestClient(t, http.NotFoundHandler()), logger: zap.NewNop(), db: storage}
+
+		err := updater.HandleMergeEvent(parseEvent(t, gitlab.EventTypeMergeRequest, tc.payload).(*gitlab.MergeEvent))
+		checkOutcome(t, tc.name, err, tc.outcome)
+		if tc.outcome != webhookHandled {
+			continue
+		}
+
+		mergeRequest := storage.mergeRequests["neil/fly-me-to-the-moon"]
+		if mergeRequest.ID != 2718 || mergeRequest.IID != 7 || mergeRequest.Status != tc.status {
+			t.Errorf("%s: unexpected merge request %+v", tc.name, mergeRequest)
+		}
+	}
+}
+
+func TestHandleMergeCommentEvent(t *testing.T) {
+	tracked := &models.MergeRequest{ID: 2718, IID: 7, Task: "fly-me-to-the-moon", Project: "neil", Status: models.MergeRequestPending}
+	merged := &models.MergeRequest{ID: 2718, IID: 7, Task: "fly-me-to-the-moon", Project: "neil", Status: models.MergeRequestMerged}
+	outdated := &models.MergeRequest{ID: 1000, IID: 3, Task: "fly-me-to-the-moon", Project: "neil", Status: models.MergeRequestPending}
+
+	for _, tc := range []struct {
+		name     string
+		payload  string
+		existing *models.MergeRequest
+		broken   bool
+		outcome  webhookOutcome
+		status   models.MergeRequestStatus
+	}{
+		{"on review", mergeCommentPayload, tracked, false, webhookHandled, models.MergeRequestOnReview},
+		{"merged", mergeCommentPayload, merged, false, webhookHandled, models.MergeRequestMerged},
+		{"unknown merge request", mergeCommentPayload, nil, false, webhookIgnored, ""},
+		{"outdated merge request", mergeCommentPayload, outdated, false, webhookIgnored, ""},
+		{"foreign project", withField(t, mergeCommentPayload, "project", "path_with_namespace", "other-course/neil"), tracked, false, webhookIgnored, ""},
+		{"not a submit", withField(t, mergeCommentPayload, "merge_request", "source_branch", "fix-readme"), tracked, false, webhookIgnored, ""},
+		{"storage failure", mergeCommentPayload, tracked, true, webhookFailed, ""},
+	} {
+		storage := newFakeStorage()
+		if tc.existing != nil {
+			storage.mergeRequests["neil/fly-me-to-the-moon"] = *tc.existing
+		}
+		if tc.broken {
+			storage.err = errors.New("connection refused")
+		}
+		updater := MergeRequestsUpdater{Client: newTestClient(t, http.NotFoundHandler()), logger: zap.NewNop(), db: storage}
+
+		err := updater.HandleMergeCommentEvent(parseEvent(t, gitlab.EventTypeNote, tc.payload).(*gitlab.MergeCommentEvent))
+		checkOutcome(t, tc.name, err, tc.outcome)
+		if tc.outcome != webhookHandled {
+			continue
+		}
+
+		if status := storage.mergeRequests["neil/fly-me-to-the-moon"].Status; status != tc.status {
+			t.Errorf("%s: unexpected status %s, expected: %s", tc.name, status, tc.status)
+		}
+	}
+}
+
+func TestHandlePushEvent(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		payload  string
+		existing *models.MergeRequest
+		broken   bool
+		outcome  webhookOutcome
+		created  bool
+	}{
+		{"new submit", pushPayload, nil, false, webhookHandled, true},
+		{"submit with merge request", pushPayload, &models.MergeRequest{ID: 2718, Task: "fly-me-to-the-moon", Project: "neil"}, false, webhookHandled, false},
+		{"deleted branch", withField(t, pushPayload, "", "after", deletedBranchSHA), nil, false, webhookIgnored, false},
+		{"not a submit", withField(t, pushPayload, "", "ref", "refs/heads/master"), nil, false, webhookIgnored, false},
+		{"foreign project", withField(t, pushPayload, "project", "path_with_namespace", "other-course/neil"), nil, false, webhookIgnored, false},
+		{"storage failure", pushPayload, nil, true, webhookFailed, true},
+	} {
+		created := false
+		api := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost || r.URL.Path != "/api/v4/projects/42/merge_requests" {
+				http.NotFound(w, r)
+				return
+			}
+			body, _ := ioutil.ReadAll(r.Body)
+			options := gitlab.CreateMergeRequestOptions{}
+			if err := json.Unmarshal(body, &options); err != nil || *options.TargetBranch != "master" {
+				http.Error(w, "bad request", http.StatusBadRequest)
+				return
+			}
+			created = true
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"id": 2718, "iid": 7, "source_branch": "submits/fly-me-to-the-moon", "state": "opened", "created_at": "2021-09-10T10:00:00Z"}`))
+		})
+
+		storage := newFakeStorage()
+		if tc.existing != nil {
+			storage.mergeRequests["neil/fly-me-to-the-moon"] = *tc.existing
+		}
+		if tc.broken {
+			storage.err = errors.New("connection refused")
+		}
+		updater := MergeRequestsUpdater{Client: newTestClient(t, api), logger: zap.NewNop(), db: storage}
+
+		err := updater.HandlePushEvent(parseEvent(t, gitlab.EventTypePush, tc.payload).(*gitlab.PushEvent))
+		checkOutcome(t, tc.name, err, tc.outcome)
+		if created != tc.created {
+			t.Errorf("%s: merge request created: %v, expected: %v", tc.name, created, tc.created)
+		}
+		if tc.outcome == webhookHandled && tc.created {
+			if mergeRequest := storage.mergeRequests["neil/fly-me-to-the-moon"]; mergeRequest.ID != 2718 || mergeRequest.Status != models.MergeRequestPending {
+				t.Errorf("%s: unexpected merge request %+v", tc.name, mergeRequest)
+			}
+		}
+	}
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// DeadlinesRevision is the group deadlines accepted by the fetcher,
+// a new revision is stored every time the fetched deadlines change
+type DeadlinesRevision struct {
+	ID        uint   `gorm:"primaryKey"`
+	Course    string `gorm:"index;not null;default:''"`
+	GroupName string `gorm:"index"`
+
+	// JSON encoded deadlines
+	Deadlines string `gorm:"type:text"`
+	// Changes since the previous revision, one per line
+	Changes string `gorm:"type:text"`
+
+	CreatedAt time.Time `gorm:"index"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Extension moves the deadline of a single task or a whole task group for one user,
+// or exempts the user from it
+type Extension struct {
+	ID     uint   `gorm:"primaryKey"`
+	UserID uint   `gorm:"index"`
+	Course string `gorm:"index;not null;default:''"`
+
+	// Exactly one of Task and TaskGroup is set
+	Task      string
+	TaskGroup string
+
+	Deadline time.Time
+	// Exempt tasks do not count towards the maximal score, Deadline is ignored
+	Exempt    bool `gorm:"not null;default:false"`
+	Reason    string
+	GrantedBy string
+	CreatedAt time.Time
+}
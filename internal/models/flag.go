@@ -4,7 +4,11 @@ import "time"
 
 type Flag struct {
 	ID          string  `gorm:"primaryKey"`
+	Course      string  `gorm:"index;not null;default:''"`
 	Task        string  `gorm:"index"`
 	GitlabLogin *string `gorm:"index"`
 	CreatedAt   time.Time
+
+	// GitLab login of the student the flag was issued for, nil for legacy unbound flags
+	Owner *string `gorm:"index"`
 }
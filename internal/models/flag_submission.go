@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+const (
+	FlagSubmissionAccepted    = "accepted"
+	FlagSubmissionDuplicate   = "duplicate"
+	FlagSubmissionInvalid     = "invalid"
+	FlagSubmissionUnknown     = "unknown"
+	FlagSubmissionForeign     = "foreign"
+	FlagSubmissionRateLimited = "rate_limited"
+)
+
+type FlagSubmissionOutcome = string
+
+// Outcomes counted towards the wrong attempts limit, duplicates and rate limited attempts are not guesses
+var WrongFlagSubmissionOutcomes = []FlagSubmissionOutcome{
+	FlagSubmissionInvalid,
+	FlagSubmissionUnknown,
+	FlagSubmissionForeign,
+}
+
+// FlagSubmission records every attempt to submit a flag
+type FlagSubmission struct {
+	ID          uint   `gorm:"primaryKey"`
+	UserID      uint   `gorm:"index"`
+	Course      string `gorm:"index;not null;default:''"`
+	GitlabLogin string `gorm:"index"`
+
+	Flag    string
+	Task    string
+	Outcome FlagSubmissionOutcome `gorm:"index"`
+	// Student the flag was issued for, first submitter for legacy flags
+	FlagOwner *string
+
+	IP        string
+	CreatedAt time.Time `gorm:"index"`
+}
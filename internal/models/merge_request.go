@@ -14,6 +14,7 @@ type MergeRequestStatus = string
 
 type MergeRequest struct {
 	ID      int    `gorm:"primaryKey"`
+	Course  string `gorm:"index;not null;default:''"`
 	Project string `gorm:"index"`
 
 	Task      string `gorm:"index"`
@@ -15,6 +15,7 @@ type PipelineStatus = string
 
 type Pipeline struct {
 	ID      int    `gorm:"primaryKey"`
+	Course  string `gorm:"index;not null;default:''"`
 	Project string `gorm:"index"`
 
 	Task      string `gorm:"index"`
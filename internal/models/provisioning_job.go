@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+const (
+	ProvisioningJobPending = "pending"
+	ProvisioningJobRunning = "running"
+	ProvisioningJobDone    = "done"
+	ProvisioningJobFailed  = "failed"
+)
+
+type ProvisioningJobState = string
+
+// ProvisioningJob creates the GitLab project of the user, failed attempts are retried with backoff
+type ProvisioningJob struct {
+	ID     uint   `gorm:"primaryKey"`
+	Course string `gorm:"index;not null;default:''"`
+	UserID uint   `gorm:"uniqueIndex"`
+
+	State         ProvisioningJobState `gorm:"index"`
+	Attempts      int
+	NextAttemptAt time.Time `gorm:"index"`
+	LastError     string
+
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+const (
+	ScoreOverrideSet   = "set"
+	ScoreOverrideDelta = "delta"
+	ScoreOverrideReset = "reset"
+)
+
+type ScoreOverrideKind = string
+
+// ScoreOverride rows are append-only and serve as an audit log,
+// the latest override of the (user, task) pair is effective
+type ScoreOverride struct {
+	ID     uint   `gorm:"primaryKey"`
+	UserID uint   `gorm:"index"`
+	Course string `gorm:"index;not null;default:''"`
+	Task   string `gorm:"index"`
+
+	Kind    ScoreOverrideKind
+	Score   int
+	Comment string
+
+	Author    string
+	CreatedAt time.Time
+}
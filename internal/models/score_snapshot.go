@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// ScoreSnapshot is the total score of the user at the moment
+type ScoreSnapshot struct {
+	ID          uint   `gorm:"primaryKey"`
+	Course      string `gorm:"index;not null;default:''"`
+	GitlabLogin string `gorm:"index"`
+	GroupName   string `gorm:"index"`
+	Subgroup    string
+
+	Score         int
+	MaxScore      int
+	TasksOnReview int
+
+	CreatedAt time.Time `gorm:"index"`
+}
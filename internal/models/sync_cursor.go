@@ -0,0 +1,14 @@
+package models
+
+import (
+	"time"
+)
+
+// SyncCursor points to the latest pipeline update fetched from the project
+type SyncCursor struct {
+	Course  string `gorm:"primaryKey"`
+	Project string `gorm:"primaryKey"`
+
+	PipelinesUpdatedAt time.Time
+	LastPipelineID     int
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+const (
+	TemplateUpdateOpened   = "opened"
+	TemplateUpdateMerged   = "merged"
+	TemplateUpdateConflict = "conflict"
+	TemplateUpdateClosed   = "closed"
+	TemplateUpdateFailed   = "failed"
+)
+
+type TemplateUpdateStatus = string
+
+// TemplateUpdate is the state of the latest template update of the project
+type TemplateUpdate struct {
+	Course  string `gorm:"primaryKey"`
+	Project string `gorm:"primaryKey"`
+
+	// Template commit being merged into the project default branch
+	TemplateCommit  string
+	Status          TemplateUpdateStatus `gorm:"index"`
+	MergeRequestIID int
+	MergeRequestURL string
+	Error           string
+	UpdatedAt       time.Time
+}
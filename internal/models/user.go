@@ -1,28 +1,57 @@
 package models
 
 import (
+	"time"
+
 	"gorm.io/gorm"
 )
 
 type GitlabUser struct {
-	GitlabID    *int    `gorm:"uniqueIndex"`
-	GitlabLogin *string `gorm:"uniqueIndex"`
+	GitlabID    *int    `gorm:"uniqueIndex:idx_users_course_gitlab_id"`
+	GitlabLogin *string `gorm:"uniqueIndex:idx_users_course_gitlab_login"`
 	Repository  *string
 }
 
 type User struct {
 	gorm.Model
 
+	// Users are enrolled per course, the same GitLab account may appear in several courses
+	Course string `gorm:"uniqueIndex:idx_users_course_gitlab_id;uniqueIndex:idx_users_course_gitlab_login;uniqueIndex:idx_users_course_name;not null;default:''"`
+
 	GitlabUser
 
-	FirstName    string `gorm:"uniqueIndex:idx_name"`
-	LastName     string `gorm:"uniqueIndex:idx_name"`
-	GroupName    string `gorm:"uniqueIndex:idx_name"`
-	SubgroupName string `gorm:"uniqueIndex:idx_name"`
+	FirstName    string `gorm:"uniqueIndex:idx_users_course_name"`
+	LastName     string `gorm:"uniqueIndex:idx_users_course_name"`
+	GroupName    string `gorm:"uniqueIndex:idx_users_course_name"`
+	SubgroupName string `gorm:"uniqueIndex:idx_users_course_name"`
+
+	IsAdmin bool
+
+	// IANA time zone dates are shown in, the course time zone if empty
+	TimeZone string `gorm:"not null;default:''"`
+
+	// Personal token for API clients
+	ApiToken *string `gorm:"uniqueIndex"`
+	// Secret of the calendar feed URL, calendar apps can not send headers
+	FeedToken *string `gorm:"uniqueIndex"`
 }
 
 type Session struct {
 	ID     uint   `gorm:"primaryKey"`
 	Token  string `gorm:"uniqueIndex"`
-	UserID uint
+	UserID uint   `gorm:"index"`
+	Course string `gorm:"index;not null;default:''"`
+
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	// Moved forward on each request, see TouchSession
+	ExpiresAt time.Time `gorm:"index"`
+
+	UserAgent string
+	IP        string
+}
+
+// Expired is false for sessions not backfilled yet, they get expiration on the next touch
+func (s *Session) Expired(now time.Time) bool {
+	return !s.ExpiresAt.IsZero() && !now.Before(s.ExpiresAt)
 }
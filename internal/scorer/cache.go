@@ -0,0 +1,204 @@
+package scorer
+
+import (
+	"sync"
+	"time"
+
+	"github.com/bigredeye/notmanytask/internal/database"
+	"github.com/bigredeye/notmanytask/internal/deadlines"
+	"github.com/bigredeye/notmanytask/internal/models"
+)
+
+// Tables the scores depend on
+var scoredTables = map[string]bool{
+	"users":           true,
+	"pipelines":       true,
+	"merge_requests":  true,
+	"flags":           true,
+	"extensions":      true,
+	"score_overrides": true,
+}
+
+// standingsCache keeps scores of every group user between requests.
+// Database changes mark affected users dirty, they are recomputed on the next read.
+type standingsCache struct {
+	mu     sync.Mutex
+	course string
+	groups map[string]*groupScores
+}
+
+type groupScores struct {
+	deadlines *deadlines.Deadlines
+	users     []*models.User
+	scores    map[uint]*UserScores
+	projects  map[string]uint
+	logins    map[string]uint
+
+	dirty map[uint]bool
+	// The whole group has to be reloaded
+	stale bool
+	// Scores change when the next task group is released
+	expiresAt  time.Time
+	modifiedAt time.Time
+}
+
+func newStandingsCache(course string) *standingsCache {
+	return &standingsCache{
+		course: course,
+		groups: make(map[string]*groupScores),
+	}
+}
+
+func (c *standingsCache) invalidate(change *database.Change) {
+	if change.Course != "" && change.Course != c.course {
+		return
+	}
+	if !scoredTables[change.Table] {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, group := range c.groups {
+		group.invalidate(change)
+	}
+}
+
+func (g *groupScores) invalidate(change *database.Change) {
+	if change.Table == "users" || len(change.Rows) == 0 {
+		g.stale = true
+		return
+	}
+
+	for _, row := range change.Rows {
+		id, known := g.findUser(row)
+		if !known {
+			g.stale = true
+			return
+		}
+		// Zero id belongs to a user of another group
+		if id != 0 {
+			g.dirty[id] = true
+		}
+	}
+}
+
+func (g *groupScores) findUser(row interface{}) (id uint, known bool) {
+	switch row := row.(type) {
+	case *models.Pipeline:
+		return g.projects[row.Project], row.Project != ""
+	case *models.MergeRequest:
+		return g.projects[row.Project], row.Project != ""
+	case *models.Flag:
+		if row.GitlabLogin == nil {
+			return 0, false
+		}
+		return g.logins[*row.GitlabLogin], true
+	case *models.Extension:
+		return g.memberID(row.UserID), row.UserID != 0
+	case *models.ScoreOverride:
+		return g.memberID(row.UserID), row.UserID != 0
+	}
+	return 0, false
+}
+
+func (g *groupScores) expired(now time.Time) bool {
+	return !g.expiresAt.IsZero() && !now.Before(g.expiresAt)
+}
+
+func (g *groupScores) memberID(id uint) uint {
+	if _, found := g.scores[id]; found {
+		return id
+	}
+	return 0
+}
+
+// groupScores returns up to date scores of the group, the cache must be locked
+func (s Scorer) groupScores(groupName string, currentDeadlines *deadlines.Deadlines) (*groupScores, error) {
+	now := time.Now()
+	group := s.cache.groups[groupName]
+	if group == nil || group.stale || group.deadlines != currentDeadlines || group.expired(now) {
+		group, err := s.loadGroupScores(groupName, currentDeadlines, now)
+		if err != nil {
+			return nil, err
+		}
+		s.cache.groups[groupName] = group
+		return group, nil
+	}
+
+	if len(group.dirty) == 0 {
+		return group, nil
+	}
+
+	for _, user := range group.users {
+		if !group.dirty[user.ID] {
+			continue
+		}
+		scores, err := s.calcUserScoresFromDB(currentDeadlines, user, now)
+		if err != nil {
+			return nil, err
+		}
+		scores.ModifiedAt = now
+		group.scores[user.ID] = scores
+		delete(group.dirty, user.ID)
+	}
+	group.modifiedAt = now
+	return group, nil
+}
+
+func (s Scorer) loadGroupScores(groupName string, currentDeadlines *deadlines.Deadlines, now time.Time) (*groupScores, error) {
+	users, err := s.db.ListGroupUsers(groupName, "")
+	if err != nil {
+		return nil, err
+	}
+
+	pipelines, err := s.makeCachedPipelinesProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	mergeRequests, err := s.makeCachedMergeRequestsProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	flags, err := s.makeCachedFlagsProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	extensions, err := s.makeCachedExtensionsProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	overrides, err := s.makeCachedOverridesProvider()
+	if err != nil {
+		return nil, err
+	}
+
+	group := &groupScores{
+		deadlines:  currentDeadlines,
+		users:      users,
+		scores:     make(map[uint]*UserScores, len(users)),
+		projects:   make(map[string]uint, len(users)),
+		logins:     make(map[string]uint, len(users)),
+		dirty:      make(map[uint]bool),
+		modifiedAt: now,
+	}
+	group.expiresAt, _ = deadlines.NextRelease(currentDeadlines, now)
+	for _, user := range users {
+		scores, err := s.calcUserScoresImpl(currentDeadlines, user, now, pipelines, mergeRequests, flags, extensions, overrides)
+		if err != nil {
+			return nil, err
+		}
+		scores.ModifiedAt = now
+
+		group.scores[user.ID] = scores
+		group.projects[s.projects.MakeProjectName(user)] = user.ID
+		if user.GitlabLogin != nil {
+			group.logins[*user.GitlabLogin] = user.ID
+		}
+	}
+	return group, nil
+}
@@ -0,0 +1,154 @@
+package scorer
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/pkg/errors"
+
+	"github.com/bigredeye/notmanytask/internal/config"
+)
+
+const defaultMaxGrade = 10
+
+type FinalGrade struct {
+	Grade int
+	// Weighted score ratio, may exceed 1 if some caps are greater than 1
+	Ratio float64
+	// Unmet requirements, grade is FailGrade if there are any
+	Failures []string
+}
+
+// ValidateGrading checks grading formula declared in the group config
+func ValidateGrading(grading *config.GradingConfig) error {
+	if grading.MaxGrade < 0 {
+		return errors.Errorf("Invalid max grade %d", grading.MaxGrade)
+	}
+
+	seen := make(map[string]bool, len(grading.Components))
+	totalWeight := 0.0
+	for _, component := range grading.Components {
+		if seen[component.TaskGroup] {
+			return errors.Errorf("Duplicate grading component %q", component.TaskGroup)
+		}
+		seen[component.TaskGroup] = true
+
+		if component.Weight < 0 {
+			return errors.Errorf("Invalid weight %v of task group %q", component.Weight, component.TaskGroup)
+		}
+		if component.MinTasks < 0 {
+			return errors.Errorf("Invalid min tasks %d of task group %q", component.MinTasks, component.TaskGroup)
+		}
+		if component.Cap != nil && *component.Cap < 0 {
+			return errors.Errorf("Invalid cap %v of task group %q", *component.Cap, component.TaskGroup)
+		}
+		totalWeight += component.Weight
+	}
+	if len(grading.Components) > 0 && totalWeight == 0 {
+		return errors.New("Total weight of grading components is zero")
+	}
+
+	for _, threshold := range grading.Scale {
+		if threshold.MinScore < 0 {
+			return errors.Errorf("Invalid min score %v of grade %d", threshold.MinScore, threshold.Grade)
+		}
+	}
+
+	return nil
+}
+
+func ratio(score, maxScore int) float64 {
+	if maxScore <= 0 {
+		return 0
+	}
+	return float64(score) / float64(maxScore)
+}
+
+func solvedTasks(group *ScoredTaskGroup) int {
+	solved := 0
+	for _, task := range group.Tasks {
+		// Exempt students are not required to solve the task
+		if task.Score > 0 || task.Status == TaskStatusExempt {
+			solved++
+		}
+	}
+	return solved
+}
+
+func calcFinalGrade(grading *config.GradingConfig, scores *UserScores) (*FinalGrade, error) {
+	if err := ValidateGrading(grading); err != nil {
+		return nil, err
+	}
+
+	grade := &FinalGrade{}
+
+	if len(grading.Components) == 0 {
+		grade.Ratio = ratio(scores.Score, scores.MaxScore)
+	} else {
+		groups := make(map[string]*ScoredTaskGroup, len(scores.Groups))
+		for i := range scores.Groups {
+			groups[scores.Groups[i].Title] = &scores.Groups[i]
+		}
+
+		weightedSum := 0.0
+		totalWeight := 0.0
+		for _, component := range grading.Components {
+			group := groups[component.TaskGroup]
+			// Groups which have not started yet do not affect the grade
+			if group != nil && !group.Released {
+				continue
+			}
+
+			totalWeight += component.Weight
+			if group == nil {
+				if component.MinTasks > 0 {
+					grade.Failures = append(grade.Failures, fmt.Sprintf("%s: no such task group", component.TaskGroup))
+				}
+				continue
+			}
+
+			if solved := solvedTasks(group); solved < component.MinTasks {
+				grade.Failures = append(grade.Failures, fmt.Sprintf("%s: %d of %d required tasks solved", component.TaskGroup, solved, component.MinTasks))
+			}
+
+			groupRatio := ratio(group.Score, group.MaxScore)
+			maxRatio := 1.0
+			if component.Cap != nil {
+				maxRatio = *component.Cap
+			}
+			weightedSum += component.Weight * math.Min(groupRatio, maxRatio)
+		}
+		if totalWeight > 0 {
+			grade.Ratio = weightedSum / totalWeight
+		}
+	}
+
+	if len(grade.Failures) > 0 {
+		grade.Grade = grading.FailGrade
+		return grade, nil
+	}
+
+	if len(grading.Scale) > 0 {
+		scale := make([]config.GradeThresholdConfig, len(grading.Scale))
+		copy(scale, grading.Scale)
+		sort.SliceStable(scale, func(i, j int) bool {
+			return scale[i].MinScore > scale[j].MinScore
+		})
+
+		for _, threshold := range scale {
+			if grade.Ratio >= threshold.MinScore {
+				grade.Grade = threshold.Grade
+				break
+			}
+		}
+		return grade, nil
+	}
+
+	maxGrade := grading.MaxGrade
+	if maxGrade == 0 {
+		maxGrade = defaultMaxGrade
+	}
+	grade.Grade = int(math.Min(math.Round(grade.Ratio*float64(maxGrade)), float64(maxGrade)))
+	return grade, nil
+}
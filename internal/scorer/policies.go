@@ -0,0 +1,306 @@
+package scorer
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/bigredeye/notmanytask/internal/config"
+	"github.com/bigredeye/notmanytask/internal/deadlines"
+	"github.com/bigredeye/notmanytask/internal/models"
+)
+
+const (
+	PolicyHard        = "hard"
+	PolicyLinear      = "linear"
+	PolicyExponential = "exponential"
+	PolicyStep        = "step"
+
+	defaultPolicy = PolicyExponential
+)
+
+const (
+	week = time.Hour * 24 * 7
+
+	defaultLinearGrace = week
+	defaultLinearFloor = 0.5
+
+	defaultExponentialFloor = 0.3
+)
+
+// Score is divided by e every five days
+var defaultHalfLife = time.Duration(math.Round(float64(time.Hour*24*5) * math.Ln2))
+
+type scoringFunc = func(task *deadlines.Task, group *deadlines.TaskGroup, pipeline *models.Pipeline) int
+
+// ScoringPolicy builds scoring function from the parameters declared in deadlines or config
+type ScoringPolicy = func(params *config.ScoringConfig) (scoringFunc, error)
+
+var policies = map[string]ScoringPolicy{
+	PolicyHard:        makeHardPolicy,
+	PolicyLinear:      makeLinearPolicy,
+	PolicyExponential: makeExponentialPolicy,
+	PolicyStep:        makeStepPolicy,
+}
+var policiesMutex sync.RWMutex
+
+func RegisterPolicy(name string, policy ScoringPolicy) {
+	policiesMutex.Lock()
+	defer policiesMutex.Unlock()
+	policies[name] = policy
+}
+
+func makeScoringFunc(params *config.ScoringConfig) (scoringFunc, error) {
+	name := params.Policy
+	if name == "" {
+		name = defaultPolicy
+	}
+
+	policiesMutex.RLock()
+	policy, found := policies[name]
+	policiesMutex.RUnlock()
+	if !found {
+		return nil, errors.Errorf("Unknown scoring policy %q", name)
+	}
+
+	return policy(params)
+}
+
+// scoringFuncs are built once per loaded deadlines and keyed by the declared parameters,
+// the course default is stored under nil
+type scoringFuncs map[*config.ScoringConfig]scoringFunc
+
+func makeScoringFuncs(currentDeadlines *deadlines.Deadlines, defaults *config.ScoringConfig) (scoringFuncs, error) {
+	funcs := make(scoringFuncs)
+	add := func(key *config.ScoringConfig, params *config.ScoringConfig, owner string) error {
+		if _, found := funcs[key]; found {
+			return nil
+		}
+		score, err := makeScoringFunc(params)
+		if err != nil {
+			return errors.Wrapf(err, "Invalid scoring of %s", owner)
+		}
+		funcs[key] = score
+		return nil
+	}
+
+	if err := add(nil, defaults, "course"); err != nil {
+		return nil, err
+	}
+	for i := range *currentDeadlines {
+		group := &(*currentDeadlines)[i]
+		if group.Scoring != nil {
+			if err := add(group.Scoring, group.Scoring, "task group "+group.Group); err != nil {
+				return nil, err
+			}
+		}
+		for j := range group.Tasks {
+			task := &group.Tasks[j]
+			if task.Scoring != nil {
+				if err := add(task.Scoring, task.Scoring, "task "+task.Task); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	return funcs, nil
+}
+
+func (f scoringFuncs) score(task *deadlines.Task, group *deadlines.TaskGroup, pipeline *models.Pipeline) int {
+	params := task.Scoring
+	if params == nil {
+		params = group.Scoring
+	}
+	return f[params](task, group, pipeline)
+}
+
+// scoringCache keeps scoring functions of the deadlines served to every group
+type scoringCache struct {
+	mu     sync.Mutex
+	groups map[string]*groupScoring
+}
+
+type groupScoring struct {
+	deadlines *deadlines.Deadlines
+	funcs     scoringFuncs
+}
+
+func newScoringCache() *scoringCache {
+	return &scoringCache{groups: make(map[string]*groupScoring)}
+}
+
+func (s Scorer) scoringFuncs(groupName string, currentDeadlines *deadlines.Deadlines) (scoringFuncs, error) {
+	if s.scoring == nil {
+		return makeScoringFuncs(currentDeadlines, &s.config.Scoring)
+	}
+
+	s.scoring.mu.Lock()
+	defer s.scoring.mu.Unlock()
+	if cached := s.scoring.groups[groupName]; cached != nil && cached.deadlines == currentDeadlines {
+		return cached.funcs, nil
+	}
+	funcs, err := makeScoringFuncs(currentDeadlines, &s.config.Scoring)
+	if err != nil {
+		return nil, err
+	}
+	s.scoring.groups[groupName] = &groupScoring{deadlines: currentDeadlines, funcs: funcs}
+	return funcs, nil
+}
+
+// ValidateScoring checks that policy is known and its parameters are sane
+func ValidateScoring(params *config.ScoringConfig) error {
+	_, err := makeScoringFunc(params)
+	return err
+}
+
+func floorOrDefault(params *config.ScoringConfig, def float64) (float64, error) {
+	if params.Floor == nil {
+		return def, nil
+	}
+	floor := *params.Floor
+	if floor < 0 || floor > 1 {
+		return 0, errors.Errorf("Invalid scoring floor %v, expected value in [0, 1]", floor)
+	}
+	return floor, nil
+}
+
+func makeHardPolicy(params *config.ScoringConfig) (scoringFunc, error) {
+	return hardScore, nil
+}
+
+func makeLinearPolicy(params *config.ScoringConfig) (scoringFunc, error) {
+	grace := params.Grace
+	if grace == 0 {
+		grace = defaultLinearGrace
+	}
+	if grace < 0 {
+		return nil, errors.Errorf("Invalid linear scoring grace %s", grace)
+	}
+
+	floor, err := floorOrDefault(params, defaultLinearFloor)
+	if err != nil {
+		return nil, err
+	}
+
+	return makeLinearScore(grace, floor), nil
+}
+
+func makeExponentialPolicy(params *config.ScoringConfig) (scoringFunc, error) {
+	halfLife := params.HalfLife
+	if halfLife == 0 {
+		halfLife = defaultHalfLife
+	}
+	if halfLife < 0 {
+		return nil, errors.Errorf("Invalid exponential scoring half-life %s", halfLife)
+	}
+
+	floor, err := floorOrDefault(params, defaultExponentialFloor)
+	if err != nil {
+		return nil, err
+	}
+
+	return makeExponentialScore(halfLife, floor), nil
+}
+
+func makeStepPolicy(params *config.ScoringConfig) (scoringFunc, error) {
+	if len(params.Steps) == 0 {
+		return nil, errors.New("Step scoring requires at least one step")
+	}
+
+	steps := make([]config.ScoringStepConfig, len(params.Steps))
+	copy(steps, params.Steps)
+	sort.SliceStable(steps, func(i, j int) bool {
+		return steps[i].Delay < steps[j].Delay
+	})
+
+	for _, step := range steps {
+		if step.Delay < 0 {
+			return nil, errors.Errorf("Invalid step scoring delay %s", step.Delay)
+		}
+		if step.Percent < 0 || step.Percent > 100 {
+			return nil, errors.Errorf("Invalid step scoring percent %d, expected value in [0, 100]", step.Percent)
+		}
+	}
+
+	return makeStepScore(steps), nil
+}
+
+func hardScore(task *deadlines.Task, group *deadlines.TaskGroup, pipeline *models.Pipeline) int {
+	if pipeline.Status != models.PipelineStatusSuccess {
+		return 0
+	}
+
+	if pipeline.StartedAt.Before(group.Deadline.Time) {
+		return task.Score
+	}
+	return 0
+}
+
+func makeLinearScore(grace time.Duration, floor float64) scoringFunc {
+	return func(task *deadlines.Task, group *deadlines.TaskGroup, pipeline *models.Pipeline) int {
+		if pipeline.Status != models.PipelineStatusSuccess {
+			return 0
+		}
+
+		deadline := group.Deadline.Time
+
+		if pipeline.StartedAt.Before(deadline) {
+			return task.Score
+		}
+
+		graceEnd := deadline.Add(grace)
+		if pipeline.StartedAt.After(graceEnd) {
+			return int(float64(task.Score) * floor)
+		}
+
+		mult := 1.0 - (1.0-floor)*pipeline.StartedAt.Sub(deadline).Seconds()/grace.Seconds()
+
+		return int(float64(task.Score) * mult)
+	}
+}
+
+func makeExponentialScore(halfLife time.Duration, floor float64) scoringFunc {
+	return func(task *deadlines.Task, group *deadlines.TaskGroup, pipeline *models.Pipeline) int {
+		if pipeline.Status != models.PipelineStatusSuccess {
+			return 0
+		}
+
+		deadline := group.Deadline.Time
+		if pipeline.StartedAt.Before(deadline) {
+			return task.Score
+		}
+
+		delta := pipeline.StartedAt.Sub(deadline).Seconds() / halfLife.Seconds()
+
+		return int(math.Max(floor, math.Exp2(-delta)) * float64(task.Score))
+	}
+}
+
+func makeStepScore(steps []config.ScoringStepConfig) scoringFunc {
+	return func(task *deadlines.Task, group *deadlines.TaskGroup, pipeline *models.Pipeline) int {
+		if pipeline.Status != models.PipelineStatusSuccess {
+			return 0
+		}
+
+		deadline := group.Deadline.Time
+		if pipeline.StartedAt.Before(deadline) {
+			return task.Score
+		}
+
+		delay := pipeline.StartedAt.Sub(deadline)
+		for _, step := range steps {
+			if delay <= step.Delay {
+				return task.Score * step.Percent / 100
+			}
+		}
+		return 0
+	}
+}
+
+var (
+	linearScore      = makeLinearScore(defaultLinearGrace, defaultLinearFloor)
+	exponentialScore = makeExponentialScore(defaultHalfLife, defaultExponentialFloor)
+)
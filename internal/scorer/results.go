@@ -1,6 +1,8 @@
 package scorer
 
 import (
+	"time"
+
 	"github.com/bigredeye/notmanytask/internal/deadlines"
 	"github.com/bigredeye/notmanytask/internal/models"
 )
@@ -12,6 +14,7 @@ const (
 	TaskStatusSuccess  = "success"
 	TaskStatusOnReview = "on_review"
 	TaskStatusPending  = "pending"
+	TaskStatusExempt   = "exempt"
 )
 
 type TaskStatus = string
@@ -31,6 +34,19 @@ func ClassifyPipelineStatus(status models.PipelineStatus) TaskStatus {
 	}
 }
 
+type Extension struct {
+	Deadline deadlines.Date
+	Exempt   bool
+	Reason   string
+}
+
+type Override struct {
+	Kind    models.ScoreOverrideKind
+	Score   int
+	Comment string
+	Author  string
+}
+
 type ScoredTask struct {
 	Task      string
 	ShortName string
@@ -41,6 +57,9 @@ type ScoredTask struct {
 
 	TaskUrl     string
 	PipelineUrl string
+
+	Extension *Extension
+	Override  *Override
 }
 
 type ScoredTaskGroup struct {
@@ -48,11 +67,23 @@ type ScoredTaskGroup struct {
 	PrettyTitle string
 	Deadline    deadlines.Date
 	Tasks       []ScoredTask
+	Extension   *Extension
+
+	Start deadlines.Date
+	// Unreleased groups are hidden from students and do not count towards total scores
+	Released bool
 
 	Score    int
 	MaxScore int
 }
 
+// UpcomingGroup is the next task group to be released
+type UpcomingGroup struct {
+	Title       string
+	PrettyTitle string
+	Start       deadlines.Date
+}
+
 type User struct {
 	FirstName     string
 	LastName      string
@@ -71,11 +102,83 @@ type UserScores struct {
 	Score         int
 	MaxScore      int
 	TasksOnReview int
+	FinalGrade    *FinalGrade
+	Upcoming      *UpcomingGroup
 
 	User User
+
+	// Time the scores were last recomputed, zero if they are not cached
+	ModifiedAt time.Time `json:"-"`
 }
 
 type Standings struct {
 	Deadlines *deadlines.Deadlines
 	Users     []*UserScores
+	// Final grades are computed for the group
+	Graded bool
+
+	ModifiedAt time.Time `json:"-"`
+}
+
+// Released returns a copy of the scores without groups which have not started yet
+func (u *UserScores) Released() *UserScores {
+	scores := *u
+	scores.Groups = make([]ScoredTaskGroup, 0, len(u.Groups))
+	for _, group := range u.Groups {
+		if group.Released {
+			scores.Groups = append(scores.Groups, group)
+		}
+	}
+	return &scores
+}
+
+// WithoutNotes returns a copy of the scores without comments of overrides and reasons of extensions,
+// they are meant for the student and admins only
+func (u *UserScores) WithoutNotes() *UserScores {
+	scores := *u
+	scores.Groups = make([]ScoredTaskGroup, len(u.Groups))
+	for i, group := range u.Groups {
+		group.Extension = group.Extension.withoutNotes()
+		group.Tasks = make([]ScoredTask, len(u.Groups[i].Tasks))
+		for j, task := range u.Groups[i].Tasks {
+			task.Extension = task.Extension.withoutNotes()
+			if task.Override != nil {
+				override := *task.Override
+				override.Comment = ""
+				override.Author = ""
+				task.Override = &override
+			}
+			group.Tasks[j] = task
+		}
+		scores.Groups[i] = group
+	}
+	return &scores
+}
+
+func (e *Extension) withoutNotes() *Extension {
+	if e == nil {
+		return nil
+	}
+	extension := *e
+	extension.Reason = ""
+	return &extension
+}
+
+// Released returns a copy of the standings without groups which have not started yet
+func (s *Standings) Released(now time.Time) *Standings {
+	standings := *s
+	if s.Deadlines != nil {
+		released := make(deadlines.Deadlines, 0, len(*s.Deadlines))
+		for _, group := range *s.Deadlines {
+			if group.Released(now) {
+				released = append(released, group)
+			}
+		}
+		standings.Deadlines = &released
+	}
+	standings.Users = make([]*UserScores, len(s.Users))
+	for i, user := range s.Users {
+		standings.Users[i] = user.Released()
+	}
+	return &standings
 }
@@ -2,13 +2,13 @@ package scorer
 
 import (
 	"fmt"
-	"math"
 	"path"
 	"regexp"
 	"sort"
 	"strings"
 	"time"
 
+	"github.com/bigredeye/notmanytask/internal/config"
 	"github.com/bigredeye/notmanytask/internal/database"
 	"github.com/bigredeye/notmanytask/internal/deadlines"
 	"github.com/bigredeye/notmanytask/internal/models"
@@ -24,13 +24,18 @@ type ProjectNameFactory interface {
 }
 
 type Scorer struct {
+	config    *config.Config
 	deadlines *deadlines.Fetcher
 	db        *database.DataBase
 	projects  ProjectNameFactory
+	cache     *standingsCache
+	scoring   *scoringCache
 }
 
-func NewScorer(db *database.DataBase, deadlines *deadlines.Fetcher, projects ProjectNameFactory) *Scorer {
-	return &Scorer{deadlines, db, projects}
+func NewScorer(config *config.Config, db *database.DataBase, deadlines *deadlines.Fetcher, projects ProjectNameFactory) *Scorer {
+	cache := newStandingsCache(config.Course)
+	db.Subscribe(cache.invalidate)
+	return &Scorer{config, deadlines, db, projects, cache, newScoringCache()}
 }
 
 const (
@@ -62,16 +67,29 @@ type pipelinesMap map[string]*models.Pipeline
 type mergeRequestsMap map[string]*models.MergeRequest
 type flagsMap map[string]*models.Flag
 
+type overridesMap map[string]*models.ScoreOverride
+
+type extensionsMap struct {
+	tasks  map[string]*models.Extension
+	groups map[string]*models.Extension
+}
+
 type pipelinesProvider = func(project string) (pipelines []models.Pipeline, err error)
 type mergeRequestsProvider = func(project string) (mergeRequests []models.MergeRequest, err error)
 type flagsProvider = func(gitlabLogin string) (flags []models.Flag, err error)
+type extensionsProvider = func(userID uint) (extensions []models.Extension, err error)
+type overridesProvider = func(userID uint) (overrides []models.ScoreOverride, err error)
 
 func (s Scorer) loadUserPipelines(user *models.User, provider pipelinesProvider) (pipelinesMap, error) {
 	pipelines, err := provider(s.projects.MakeProjectName(user))
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to list use rpipelines")
 	}
+	return makePipelinesMap(pipelines), nil
+}
 
+// makePipelinesMap keeps the latest pipeline of every task
+func makePipelinesMap(pipelines []models.Pipeline) pipelinesMap {
 	pipelinesMap := make(pipelinesMap)
 	for i := range pipelines {
 		pipeline := &pipelines[i]
@@ -81,7 +99,7 @@ func (s Scorer) loadUserPipelines(user *models.User, provider pipelinesProvider)
 		}
 		pipelinesMap[pipeline.Task] = prev
 	}
-	return pipelinesMap, nil
+	return pipelinesMap
 }
 
 func (s Scorer) loadUserMergeRequests(user *models.User, provider mergeRequestsProvider) (mergeRequestsMap, error) {
@@ -107,7 +125,11 @@ func (s Scorer) loadUserFlags(user *models.User, provider flagsProvider) (flagsM
 	if err != nil {
 		return nil, errors.Wrap(err, "Failed to list user flags")
 	}
+	return makeFlagsMap(flags), nil
+}
 
+// makeFlagsMap keeps the first flag of every task
+func makeFlagsMap(flags []models.Flag) flagsMap {
 	flagsMap := make(flagsMap)
 	for i := range flags {
 		flag := &flags[i]
@@ -117,43 +139,97 @@ func (s Scorer) loadUserFlags(user *models.User, provider flagsProvider) (flagsM
 		}
 		flagsMap[flag.Task] = prev
 	}
-	return flagsMap, nil
+	return flagsMap
 }
 
-func (s Scorer) CalcScoreboard(groupName string, subgroupName string) (*Standings, error) {
-	currentDeadlines := s.deadlines.GroupDeadlines(groupName)
-	if currentDeadlines == nil {
-		return nil, fmt.Errorf("No deadlines found")
+func (s Scorer) loadUserExtensions(user *models.User, provider extensionsProvider) (*extensionsMap, error) {
+	extensions, err := provider(user.ID)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to list user extensions")
 	}
 
-	users, err := s.db.ListGroupUsers(groupName, subgroupName)
-	if err != nil {
-		return nil, err
+	// The latest granted extension wins, so teachers are able to revoke extensions
+	res := &extensionsMap{
+		tasks:  make(map[string]*models.Extension),
+		groups: make(map[string]*models.Extension),
+	}
+	for i := range extensions {
+		extension := &extensions[i]
+		target, key := res.groups, extension.TaskGroup
+		if extension.Task != "" {
+			target, key = res.tasks, extension.Task
+		}
+		prev, found := target[key]
+		if !found || !extension.CreatedAt.Before(prev.CreatedAt) {
+			prev = extension
+		}
+		target[key] = prev
 	}
+	return res, nil
+}
 
-	pipelines, err := s.makeCachedPipelinesProvider()
-	if err != nil {
-		return nil, err
+// extendedGroup returns the group with the deadline of the task moved by its extensions,
+// task extensions take precedence over the group ones and exemptions keep the deadline
+func (e *extensionsMap) extendedGroup(group deadlines.TaskGroup, task string) deadlines.TaskGroup {
+	if extension := e.groups[group.Group]; extension != nil && !extension.Exempt {
+		group.Deadline = deadlines.Date{Time: extension.Deadline}
+	}
+	if extension := e.tasks[task]; extension != nil && !extension.Exempt {
+		group.Deadline = deadlines.Date{Time: extension.Deadline}
 	}
+	return group
+}
 
-	mergeRequests, err := s.makeCachedMergeRequestsProvider()
+// effectiveSubmit chooses the submit determining the task score: the latest pipeline or the first flag
+// if there are no pipelines, submissions made before the release are ignored
+func effectiveSubmit(group *deadlines.TaskGroup, task string, pipelines pipelinesMap, flags flagsMap) (*models.Pipeline, *models.Flag) {
+	if pipeline, found := pipelines[task]; found && group.Released(pipeline.StartedAt) {
+		return pipeline, nil
+	}
+	if flag, found := flags[task]; found && group.Released(flag.CreatedAt) {
+		return nil, flag
+	}
+	return nil, nil
+}
+
+func (s Scorer) loadUserOverrides(user *models.User, provider overridesProvider) (overridesMap, error) {
+	overrides, err := provider(user.ID)
 	if err != nil {
-		return nil, err
+		return nil, errors.Wrap(err, "Failed to list user score overrides")
+	}
+
+	overridesMap := make(overridesMap)
+	for i := range overrides {
+		override := &overrides[i]
+		prev, found := overridesMap[override.Task]
+		if !found || !override.CreatedAt.Before(prev.CreatedAt) {
+			prev = override
+		}
+		overridesMap[override.Task] = prev
+	}
+	return overridesMap, nil
+}
+
+func (s Scorer) CalcScoreboard(groupName string, subgroupName string) (*Standings, error) {
+	currentDeadlines := s.deadlines.GroupDeadlines(groupName)
+	if currentDeadlines == nil {
+		return nil, fmt.Errorf("No deadlines found")
 	}
 
-	flags, err := s.makeCachedFlagsProvider()
+	s.cache.mu.Lock()
+	defer s.cache.mu.Unlock()
+
+	cached, err := s.groupScores(groupName, currentDeadlines)
 	if err != nil {
 		return nil, err
 	}
 
-	scores := make([]*UserScores, len(users))
-	for i, user := range users {
-		userScores, err := s.calcUserScoresImpl(currentDeadlines, user, pipelines, mergeRequests, flags)
-		if err != nil {
-			return nil, err
+	scores := make([]*UserScores, 0, len(cached.users))
+	for _, user := range cached.users {
+		if subgroupName != "" && user.SubgroupName != subgroupName {
+			continue
 		}
-
-		scores[i] = userScores
+		scores = append(scores, cached.scores[user.ID])
 	}
 
 	sort.Slice(scores, func(i, j int) bool {
@@ -166,7 +242,12 @@ func (s Scorer) CalcScoreboard(groupName string, subgroupName string) (*Standing
 		return scores[i].User.FullName() < scores[j].User.FullName()
 	})
 
-	return &Standings{currentDeadlines, scores}, nil
+	graded := false
+	if group := s.config.FindGroup(groupName); group != nil {
+		graded = group.Grading != nil
+	}
+
+	return &Standings{Deadlines: currentDeadlines, Users: scores, Graded: graded, ModifiedAt: cached.modifiedAt}, nil
 }
 
 func (s Scorer) makeCachedPipelinesProvider() (pipelinesProvider, error) {
@@ -232,16 +313,78 @@ func (s Scorer) makeCachedFlagsProvider() (flagsProvider, error) {
 	}, nil
 }
 
+func (s Scorer) makeCachedExtensionsProvider() (extensionsProvider, error) {
+	extensions, err := s.db.ListAllExtensions()
+	if err != nil {
+		return nil, err
+	}
+
+	extensionsMap := make(map[uint][]models.Extension)
+	for _, extension := range extensions {
+		extensionsMap[extension.UserID] = append(extensionsMap[extension.UserID], extension)
+	}
+
+	return func(userID uint) (extensions []models.Extension, err error) {
+		return extensionsMap[userID], nil
+	}, nil
+}
+
+func (s Scorer) makeCachedOverridesProvider() (overridesProvider, error) {
+	overrides, err := s.db.ListAllScoreOverrides()
+	if err != nil {
+		return nil, err
+	}
+
+	overridesMap := make(map[uint][]models.ScoreOverride)
+	for _, override := range overrides {
+		overridesMap[override.UserID] = append(overridesMap[override.UserID], override)
+	}
+
+	return func(userID uint) (overrides []models.ScoreOverride, err error) {
+		return overridesMap[userID], nil
+	}, nil
+}
+
 func (s Scorer) CalcUserScores(user *models.User) (*UserScores, error) {
 	currentDeadlines := s.deadlines.GroupDeadlines(user.GroupName)
 	if currentDeadlines == nil {
 		return nil, fmt.Errorf("No deadlines found")
 	}
 
-	return s.calcUserScoresImpl(currentDeadlines, user, s.db.ListProjectPipelines, s.db.ListProjectMergeRequests, s.db.ListUserFlags)
+	s.cache.mu.Lock()
+	defer s.cache.mu.Unlock()
+
+	group, err := s.groupScores(user.GroupName, currentDeadlines)
+	if err != nil {
+		return nil, err
+	}
+
+	cached, found := group.scores[user.ID]
+	if !found {
+		// Users without repository are not listed in standings
+		return s.calcUserScoresFromDB(currentDeadlines, user, time.Now())
+	}
+
+	// Callers are free to reorder groups of the returned scores
+	scores := *cached
+	scores.Groups = append([]ScoredTaskGroup(nil), cached.Groups...)
+	return &scores, nil
+}
+
+func (s Scorer) calcUserScoresFromDB(currentDeadlines *deadlines.Deadlines, user *models.User, now time.Time) (*UserScores, error) {
+	return s.calcUserScoresImpl(currentDeadlines, user, now, s.db.ListProjectPipelines, s.db.ListProjectMergeRequests, s.db.ListUserFlags, s.db.ListUserExtensions, s.db.ListUserScoreOverrides)
 }
 
-func (s Scorer) calcUserScoresImpl(currentDeadlines *deadlines.Deadlines, user *models.User, pipelinesP pipelinesProvider, mergeRequestsP mergeRequestsProvider, flagsP flagsProvider) (*UserScores, error) {
+func (s Scorer) calcUserScoresImpl(
+	currentDeadlines *deadlines.Deadlines,
+	user *models.User,
+	now time.Time,
+	pipelinesP pipelinesProvider,
+	mergeRequestsP mergeRequestsProvider,
+	flagsP flagsProvider,
+	extensionsP extensionsProvider,
+	overridesP overridesProvider,
+) (*UserScores, error) {
 	pipelinesMap, err := s.loadUserPipelines(user, pipelinesP)
 	if err != nil {
 		return nil, err
@@ -257,6 +400,21 @@ func (s Scorer) calcUserScoresImpl(currentDeadlines *deadlines.Deadlines, user *
 		return nil, err
 	}
 
+	extensions, err := s.loadUserExtensions(user, extensionsP)
+	if err != nil {
+		return nil, err
+	}
+
+	overrides, err := s.loadUserOverrides(user, overridesP)
+	if err != nil {
+		return nil, err
+	}
+
+	scoring, err := s.scoringFuncs(user.GroupName, currentDeadlines)
+	if err != nil {
+		return nil, err
+	}
+
 	scores := &UserScores{
 		Groups:   make([]ScoredTaskGroup, 0),
 		Score:    0,
@@ -272,11 +430,22 @@ func (s Scorer) calcUserScoresImpl(currentDeadlines *deadlines.Deadlines, user *
 	}
 
 	for _, group := range *currentDeadlines {
+		released := group.Released(now)
+		if !released && (scores.Upcoming == nil || group.Start.Before(scores.Upcoming.Start.Time)) {
+			scores.Upcoming = &UpcomingGroup{
+				Title:       group.Group,
+				PrettyTitle: prettifyTitle(group.Group),
+				Start:       group.Start,
+			}
+		}
+
 		tasks := make([]ScoredTask, len(group.Tasks))
 		totalScore := 0
 		maxTotalScore := 0
 		tasksOnReview := 0
 
+		groupExtension := extensions.groups[group.Group]
+
 		for i, task := range group.Tasks {
 			tasks[i] = ScoredTask{
 				Task:      task.Task,
@@ -286,12 +455,27 @@ func (s Scorer) calcUserScoresImpl(currentDeadlines *deadlines.Deadlines, user *
 				MaxScore:  task.Score,
 				TaskUrl:   s.projects.MakeTaskUrl(task.Task),
 			}
+
+			// Task extensions take precedence over the group ones
+			taskExtension := extensions.tasks[task.Task]
+			if taskExtension != nil {
+				tasks[i].Extension = makeExtension(taskExtension)
+			}
+			// Overrides are explicit grades, so they take precedence over exemptions
+			override := overrides[task.Task]
+			if isExempt(groupExtension, taskExtension) && (override == nil || override.Kind == models.ScoreOverrideReset) {
+				tasks[i].Status = TaskStatusExempt
+				continue
+			}
 			maxTotalScore += tasks[i].MaxScore
 
-			pipeline, found := pipelinesMap[task.Task]
-			if found {
+			// Extensions move deadlines used for scoring, the original deadline is still shown
+			taskGroup := extensions.extendedGroup(group, task.Task)
+
+			pipeline, flag := effectiveSubmit(&group, task.Task, pipelinesMap, flagsMap)
+			if pipeline != nil {
 				tasks[i].Status = ClassifyPipelineStatus(pipeline.Status)
-				tasks[i].Score = s.scorePipeline(&task, &group, pipeline)
+				tasks[i].Score = scoring.score(&task, &taskGroup, pipeline)
 				tasks[i].PipelineUrl = s.projects.MakePipelineUrl(user, pipeline)
 
 				mergeRequest, mergeRequestFound := mergeRequestsMap[task.Task]
@@ -308,38 +492,98 @@ func (s Scorer) calcUserScoresImpl(currentDeadlines *deadlines.Deadlines, user *
 						}
 					}
 				}
-			} else {
-				flag, found := flagsMap[task.Task]
-				if found {
-					tasks[i].Status = TaskStatusSuccess
-
-					// FIXME(BigRedEye): I just want to sleep
-					// Do not try to mimic pipelines
-					tasks[i].Score = s.scorePipeline(&task, &group, &models.Pipeline{
-						StartedAt: flag.CreatedAt,
-						Status:    models.PipelineStatusSuccess,
-					})
-				}
+			} else if flag != nil {
+				tasks[i].Status = TaskStatusSuccess
+
+				// FIXME(BigRedEye): I just want to sleep
+				// Do not try to mimic pipelines
+				tasks[i].Score = scoring.score(&task, &taskGroup, &models.Pipeline{
+					StartedAt: flag.CreatedAt,
+					Status:    models.PipelineStatusSuccess,
+				})
+			}
+
+			if override != nil {
+				applyOverride(&tasks[i], override)
 			}
 			totalScore += tasks[i].Score
 		}
 
-		scores.Groups = append(scores.Groups, ScoredTaskGroup{
+		scoredGroup := ScoredTaskGroup{
 			Title:       group.Group,
 			PrettyTitle: prettifyTitle(group.Group),
 			Deadline:    group.Deadline,
+			Start:       group.Start,
+			Released:    released,
 			Score:       totalScore,
 			MaxScore:    maxTotalScore,
 			Tasks:       tasks,
-		})
+		}
+		if groupExtension != nil {
+			scoredGroup.Extension = makeExtension(groupExtension)
+		}
+		scores.Groups = append(scores.Groups, scoredGroup)
+		if !released {
+			continue
+		}
 		scores.Score += totalScore
 		scores.MaxScore += maxTotalScore
 		scores.TasksOnReview += tasksOnReview
 	}
 
+	if group := s.config.FindGroup(user.GroupName); group != nil && group.Grading != nil {
+		scores.FinalGrade, err = calcFinalGrade(group.Grading, scores)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid grading config of group %s", user.GroupName)
+		}
+	}
+
 	return scores, nil
 }
 
+func applyOverride(task *ScoredTask, override *models.ScoreOverride) {
+	switch override.Kind {
+	case models.ScoreOverrideSet:
+		task.Score = override.Score
+		if task.Score > 0 {
+			task.Status = TaskStatusSuccess
+		}
+	case models.ScoreOverrideDelta:
+		// Deltas adjust the earned score and must not leave the range of the task
+		task.Score += override.Score
+		if task.Score < 0 {
+			task.Score = 0
+		}
+		if task.Score > task.MaxScore {
+			task.Score = task.MaxScore
+		}
+	default:
+		return
+	}
+
+	task.Override = &Override{
+		Kind:    override.Kind,
+		Score:   override.Score,
+		Comment: override.Comment,
+		Author:  override.Author,
+	}
+}
+
+func makeExtension(extension *models.Extension) *Extension {
+	return &Extension{
+		Deadline: deadlines.Date{Time: extension.Deadline},
+		Exempt:   extension.Exempt,
+		Reason:   extension.Reason,
+	}
+}
+
+func isExempt(groupExtension *models.Extension, taskExtension *models.Extension) bool {
+	if taskExtension != nil {
+		return taskExtension.Exempt
+	}
+	return groupExtension != nil && groupExtension.Exempt
+}
+
 var re = regexp.MustCompile(`^\d+-(.*)$`)
 
 func prettifyTitle(title string) string {
@@ -357,52 +601,3 @@ func capitalize(title string) string {
 func makeShortTaskName(name string) string {
 	return path.Base(name)
 }
-
-const (
-	week = time.Hour * 24 * 7
-)
-
-// TODO(BigRedEye): Do not hardcode scoring logic
-// Maybe read scoring model from deadlines?
-type scoringFunc = func(task *deadlines.Task, group *deadlines.TaskGroup, pipeline *models.Pipeline) int
-
-func linearScore(task *deadlines.Task, group *deadlines.TaskGroup, pipeline *models.Pipeline) int {
-	if pipeline.Status != models.PipelineStatusSuccess {
-		return 0
-	}
-
-	deadline := group.Deadline.Time
-
-	if pipeline.StartedAt.Before(deadline) {
-		return task.Score
-	}
-
-	weekAfter := group.Deadline.Time.Add(week)
-	if pipeline.StartedAt.After(weekAfter) {
-		return task.Score / 2
-	}
-
-	mult := 1.0 - 0.5*pipeline.StartedAt.Sub(deadline).Seconds()/(weekAfter.Sub(deadline)).Seconds()
-
-	return int(float64(task.Score) * mult)
-}
-
-func exponentialScore(task *deadlines.Task, group *deadlines.TaskGroup, pipeline *models.Pipeline) int {
-	if pipeline.Status != models.PipelineStatusSuccess {
-		return 0
-	}
-
-	deadline := group.Deadline.Time
-	if pipeline.StartedAt.Before(deadline) {
-		return task.Score
-	}
-
-	deltaDays := pipeline.StartedAt.Sub(deadline).Hours() / 24.0
-
-	return int(math.Max(0.3, 1.0/math.Exp(deltaDays/5.0)) * float64(task.Score))
-}
-
-func (s Scorer) scorePipeline(task *deadlines.Task, group *deadlines.TaskGroup, pipeline *models.Pipeline) int {
-	// return s.linearScore(task, group, pipeline)
-	return exponentialScore(task, group, pipeline)
-}
@@ -1,9 +1,12 @@
 package scorer
 
 import (
+	"reflect"
 	"testing"
 	"time"
 
+	"github.com/bigredeye/notmanytask/internal/config"
+	"github.com/bigredeye/notmanytask/internal/database"
 	"github.com/bigredeye/notmanytask/internal/deadlines"
 	"github.com/bigredeye/notmanytask/internal/models"
 	"gopkg.in/yaml.v2"
@@ -90,3 +93,658 @@ func TestExponentialScoring(t *testing.T) {
 	checkFailedScore(t, groups, "19-07-1969 23:00", exponentialScore, models.PipelineStatusRunning)
 	checkFailedScore(t, groups, "19-07-1969 23:00", exponentialScore, models.PipelineStatusFailed)
 }
+
+const policiesDeadlines = `
+- group:    42-cpp-sucks
+  start:    17-07-1968 18:00
+  deadline: 20-07-1969 23:17
+  scoring:
+    policy: step
+    steps:
+      - delay: 24h
+        percent: 80
+      - delay: 72h
+        percent: 50
+  tasks:
+    - task: fly-me-to-the-moon
+      score: 100
+    - task: rewrite-in-rust
+      score: 9000
+      scoring:
+        policy: hard
+`
+
+func TestHardScoring(t *testing.T) {
+	groups := deadlines.Deadlines{}
+	err := yaml.Unmarshal([]byte(someStrangeDeadlines), &groups)
+	if err != nil {
+		panic(err)
+	}
+
+	checkScore(t, groups, "19-07-1969 23:00", 9000, hardScore)
+	checkScore(t, groups, "20-07-1969 20:18", 0, hardScore)
+	checkFailedScore(t, groups, "19-07-1969 23:00", hardScore, models.PipelineStatusFailed)
+}
+
+func TestPoliciesFromDeadlines(t *testing.T) {
+	groups := deadlines.Deadlines{}
+	err := yaml.Unmarshal([]byte(policiesDeadlines), &groups)
+	if err != nil {
+		t.Fatal("Failed to parse deadlines:", err)
+	}
+
+	s := Scorer{config: &config.Config{}}
+	scoring, err := s.scoringFuncs(groups[0].Group, &groups)
+	if err != nil {
+		t.Fatal("Failed to build scoring functions:", err)
+	}
+	check := func(task int, submitDate string, expectedScore int) {
+		score := scoring.score(&groups[0].Tasks[task], &groups[0], makePipeline(submitDate, models.PipelineStatusSuccess))
+		if score != expectedScore {
+			t.Fatalf("Invalid score: %d, expected: %d", score, expectedScore)
+		}
+	}
+
+	check(0, "19-07-1969 23:00", 100) // before deadline
+	check(0, "21-07-1969 13:17", 80)  // next day after deadline
+	check(0, "22-07-1969 23:17", 50)  // two days after deadline
+	check(0, "27-07-1969 20:14", 0)   // one week after deadline
+	check(1, "19-07-1969 23:00", 9000)
+	check(1, "21-07-1969 13:17", 0)
+}
+
+func TestScoringValidation(t *testing.T) {
+	floor := 1.5
+	for _, params := range []config.ScoringConfig{
+		{Policy: "quadratic"},
+		{Policy: PolicyStep},
+		{Policy: PolicyStep, Steps: []config.ScoringStepConfig{{Delay: time.Hour, Percent: 120}}},
+		{Policy: PolicyLinear, Floor: &floor},
+		{Policy: PolicyExponential, HalfLife: -time.Hour},
+	} {
+		params := params
+		if err := ValidateScoring(&params); err == nil {
+			t.Fatalf("Expected invalid scoring params: %+v", params)
+		}
+	}
+
+	for _, params := range []config.ScoringConfig{
+		{},
+		{Policy: PolicyHard},
+		{Policy: PolicyLinear, Grace: week * 2},
+		{Policy: PolicyStep, Steps: []config.ScoringStepConfig{{Delay: time.Hour, Percent: 50}}},
+	} {
+		params := params
+		if err := ValidateScoring(&params); err != nil {
+			t.Fatalf("Unexpected error for scoring params %+v: %v", params, err)
+		}
+	}
+}
+
+func TestScoringFuncsCache(t *testing.T) {
+	groups := deadlines.Deadlines{}
+	if err := yaml.Unmarshal([]byte(policiesDeadlines), &groups); err != nil {
+		t.Fatal("Failed to parse deadlines:", err)
+	}
+	reloaded := append(deadlines.Deadlines(nil), groups...)
+
+	s := Scorer{config: &config.Config{}, scoring: newScoringCache()}
+	first, err := s.scoringFuncs("students", &groups)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _ := s.scoringFuncs("students", &groups)
+	if reflect.ValueOf(first).Pointer() != reflect.ValueOf(second).Pointer() {
+		t.Errorf("Scoring functions are rebuilt for the same deadlines")
+	}
+	third, _ := s.scoringFuncs("students", &reloaded)
+	if reflect.ValueOf(first).Pointer() == reflect.ValueOf(third).Pointer() {
+		t.Errorf("Scoring functions are not rebuilt for reloaded deadlines")
+	}
+
+	invalid := deadlines.Deadlines{{Group: "g", Scoring: &config.ScoringConfig{Policy: "quadratic"}}}
+	if _, err = s.scoringFuncs("other", &invalid); err == nil {
+		t.Errorf("Unknown policy is accepted")
+	}
+}
+
+type fakeProjects struct{}
+
+func (fakeProjects) MakeProjectUrl(user *models.User) string  { return "" }
+func (fakeProjects) MakeProjectName(user *models.User) string { return *user.GitlabLogin }
+func (fakeProjects) MakePipelineUrl(user *models.User, pipeline *models.Pipeline) string {
+	return ""
+}
+func (fakeProjects) MakeMergeRequestUrl(user *models.User, mergeRequest *models.MergeRequest) string {
+	return ""
+}
+func (fakeProjects) MakeTaskUrl(task string) string { return "" }
+
+func TestExtensions(t *testing.T) {
+	groups := deadlines.Deadlines{}
+	err := yaml.Unmarshal([]byte(policiesDeadlines), &groups)
+	if err != nil {
+		t.Fatal("Failed to parse deadlines:", err)
+	}
+
+	login := "neil"
+	user := &models.User{GitlabUser: models.GitlabUser{GitlabLogin: &login}}
+	user.ID = 1
+
+	pipelines := func(project string) ([]models.Pipeline, error) {
+		moon := makePipeline("21-07-1969 13:17", models.PipelineStatusSuccess)
+		moon.Task = "fly-me-to-the-moon"
+		rust := makePipeline("21-07-1969 13:17", models.PipelineStatusSuccess)
+		rust.Task = "rewrite-in-rust"
+		return []models.Pipeline{*moon, *rust}, nil
+	}
+	mergeRequests := func(project string) ([]models.MergeRequest, error) {
+		return nil, nil
+	}
+	flags := func(gitlabLogin string) ([]models.Flag, error) {
+		return nil, nil
+	}
+	extensions := func(userID uint) ([]models.Extension, error) {
+		return []models.Extension{{
+			UserID:    1,
+			TaskGroup: "42-cpp-sucks",
+			Deadline:  mustParse(time.Parse("02-01-2006 15:04", "22-07-1969 00:00")),
+		}, {
+			UserID:    1,
+			Task:      "rewrite-in-rust",
+			Deadline:  mustParse(time.Parse("02-01-2006 15:04", "21-07-1969 00:00")),
+			CreatedAt: time.Unix(1, 0),
+		}}, nil
+	}
+
+	overrides := func(userID uint) ([]models.ScoreOverride, error) {
+		return nil, nil
+	}
+
+	s := Scorer{config: &config.Config{}, projects: fakeProjects{}}
+	scores, err := s.calcUserScoresImpl(&groups, user, time.Now(), pipelines, mergeRequests, flags, extensions, overrides)
+	if err != nil {
+		t.Fatal("Failed to calc scores:", err)
+	}
+
+	group := scores.Groups[0]
+	if group.Extension == nil || group.Tasks[0].Extension != nil || group.Tasks[1].Extension == nil {
+		t.Fatalf("Extensions are not reported: %+v", group)
+	}
+	if group.Tasks[0].Score != 100 {
+		t.Fatalf("Invalid score for extended group: %d, expected: %d", group.Tasks[0].Score, 100)
+	}
+	if group.Tasks[1].Score != 0 {
+		t.Fatalf("Invalid score for extended task: %d, expected: %d", group.Tasks[1].Score, 0)
+	}
+}
+
+func TestExemptions(t *testing.T) {
+	groups := deadlines.Deadlines{}
+	err := yaml.Unmarshal([]byte(policiesDeadlines), &groups)
+	if err != nil {
+		t.Fatal("Failed to parse deadlines:", err)
+	}
+
+	login := "neil"
+	user := &models.User{GitlabUser: models.GitlabUser{GitlabLogin: &login}}
+	user.ID = 1
+
+	pipelines := func(project string) ([]models.Pipeline, error) {
+		moon := makePipeline("20-07-1969 13:17", models.PipelineStatusSuccess)
+		moon.Task = "fly-me-to-the-moon"
+		return []models.Pipeline{*moon}, nil
+	}
+	mergeRequests := func(project string) ([]models.MergeRequest, error) {
+		return nil, nil
+	}
+	flags := func(gitlabLogin string) ([]models.Flag, error) {
+		return nil, nil
+	}
+	extensions := func(userID uint) ([]models.Extension, error) {
+		return []models.Extension{{
+			UserID:    1,
+			TaskGroup: "42-cpp-sucks",
+			Exempt:    true,
+		}, {
+			// Task extension overrides the group exemption
+			UserID:    1,
+			Task:      "fly-me-to-the-moon",
+			Deadline:  mustParse(time.Parse("02-01-2006 15:04", "21-07-1969 00:00")),
+			CreatedAt: time.Unix(1, 0),
+		}}, nil
+	}
+	overrides := func(userID uint) ([]models.ScoreOverride, error) {
+		return nil, nil
+	}
+
+	s := Scorer{config: &config.Config{}, projects: fakeProjects{}}
+	scores, err := s.calcUserScoresImpl(&groups, user, time.Now(), pipelines, mergeRequests, flags, extensions, overrides)
+	if err != nil {
+		t.Fatal("Failed to calc scores:", err)
+	}
+
+	group := scores.Groups[0]
+	if group.Tasks[0].Status != TaskStatusSuccess || group.Tasks[0].Score != 100 {
+		t.Errorf("Task extension is not applied: %+v", group.Tasks[0])
+	}
+	if group.Tasks[1].Status != TaskStatusExempt || group.Tasks[1].Score != 0 {
+		t.Errorf("Task is not exempt: %+v", group.Tasks[1])
+	}
+	if group.MaxScore != 100 || scores.MaxScore != 100 {
+		t.Errorf("Exempt task counts towards the max score: %d, %d", group.MaxScore, scores.MaxScore)
+	}
+
+	overrides = func(userID uint) ([]models.ScoreOverride, error) {
+		return []models.ScoreOverride{{
+			Task:  "rewrite-in-rust",
+			Kind:  models.ScoreOverrideSet,
+			Score: 4500,
+		}}, nil
+	}
+	scores, err = s.calcUserScoresImpl(&groups, user, time.Now(), pipelines, mergeRequests, flags, extensions, overrides)
+	if err != nil {
+		t.Fatal("Failed to calc scores:", err)
+	}
+	if task := scores.Groups[0].Tasks[1]; task.Status != TaskStatusSuccess || task.Score != 4500 || task.Override == nil {
+		t.Errorf("Override of the exempt task is not applied: %+v", task)
+	}
+	if scores.Score != 4600 || scores.MaxScore != 9100 {
+		t.Errorf("Overridden exempt task is not counted: %d / %d", scores.Score, scores.MaxScore)
+	}
+}
+
+func TestScoreOverrides(t *testing.T) {
+	groups := deadlines.Deadlines{}
+	err := yaml.Unmarshal([]byte(someStrangeDeadlines), &groups)
+	if err != nil {
+		t.Fatal("Failed to parse deadlines:", err)
+	}
+
+	login := "buzz"
+	user := &models.User{GitlabUser: models.GitlabUser{GitlabLogin: &login}}
+
+	pipelines := func(project string) ([]models.Pipeline, error) {
+		rust := makePipeline("19-07-1969 23:00", models.PipelineStatusSuccess)
+		rust.Task = "rewrite-in-rust"
+		return []models.Pipeline{*rust}, nil
+	}
+	mergeRequests := func(project string) ([]models.MergeRequest, error) {
+		return nil, nil
+	}
+	flags := func(gitlabLogin string) ([]models.Flag, error) {
+		return nil, nil
+	}
+	extensions := func(userID uint) ([]models.Extension, error) {
+		return nil, nil
+	}
+	overrides := func(userID uint) ([]models.ScoreOverride, error) {
+		return []models.ScoreOverride{{
+			Task:      "fly-me-to-the-moon",
+			Kind:      models.ScoreOverrideSet,
+			Score:     150,
+			CreatedAt: time.Unix(1, 0),
+		}, {
+			Task:      "rewrite-in-rust",
+			Kind:      models.ScoreOverrideDelta,
+			Score:     -1000,
+			CreatedAt: time.Unix(2, 0),
+		}, {
+			Task:      "rewrite-in-go",
+			Kind:      models.ScoreOverrideSet,
+			Score:     50,
+			CreatedAt: time.Unix(3, 0),
+		}, {
+			Task:      "rewrite-in-go",
+			Kind:      models.ScoreOverrideReset,
+			CreatedAt: time.Unix(4, 0),
+		}}, nil
+	}
+
+	s := Scorer{config: &config.Config{}, projects: fakeProjects{}}
+	scores, err := s.calcUserScoresImpl(&groups, user, time.Now(), pipelines, mergeRequests, flags, extensions, overrides)
+	if err != nil {
+		t.Fatal("Failed to calc scores:", err)
+	}
+
+	tasks := scores.Groups[0].Tasks
+	for i, expected := range []int{150, 8000, 0, 0} {
+		if tasks[i].Score != expected {
+			t.Fatalf("Invalid score for task %s: %d, expected: %d", tasks[i].Task, tasks[i].Score, expected)
+		}
+	}
+	if tasks[0].Override == nil || tasks[0].Status != TaskStatusSuccess || tasks[1].Override == nil || tasks[2].Override != nil {
+		t.Fatalf("Overrides are not reported: %+v", tasks)
+	}
+	if scores.Score != 8150 {
+		t.Fatalf("Invalid total score: %d, expected: %d", scores.Score, 8150)
+	}
+}
+
+func TestScoreOverrideDeltaClamping(t *testing.T) {
+	for _, tc := range []struct {
+		score    int
+		delta    int
+		expected int
+	}{
+		{score: 50, delta: 20, expected: 70},
+		{score: 50, delta: -70, expected: 0},
+		{score: 50, delta: 70, expected: 100},
+		{score: 0, delta: -1, expected: 0},
+	} {
+		task := ScoredTask{Score: tc.score, MaxScore: 100}
+		applyOverride(&task, &models.ScoreOverride{Kind: models.ScoreOverrideDelta, Score: tc.delta})
+		if task.Score != tc.expected {
+			t.Errorf("Invalid score of %d with delta %d: %d, expected: %d", tc.score, tc.delta, task.Score, tc.expected)
+		}
+	}
+}
+
+func makeGradedScores() *UserScores {
+	return &UserScores{
+		Score:    250,
+		MaxScore: 400,
+		Groups: []ScoredTaskGroup{
+			{Title: "intro", Released: true, Score: 100, MaxScore: 100, Tasks: []ScoredTask{{Task: "a", Score: 50}, {Task: "b", Score: 50}}},
+			{Title: "stl", Released: true, Score: 150, MaxScore: 300, Tasks: []ScoredTask{{Task: "c", Score: 150}, {Task: "d"}, {Task: "e"}}},
+			{Title: "future", MaxScore: 1000, Tasks: []ScoredTask{{Task: "f"}}},
+		},
+	}
+}
+
+func checkGrade(t *testing.T, grading *config.GradingConfig, expectedGrade int, expectedFailures int) {
+	grade, err := calcFinalGrade(grading, makeGradedScores())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if grade.Grade != expectedGrade {
+		t.Fatalf("Invalid grade: %d (ratio %v), expected: %d", grade.Grade, grade.Ratio, expectedGrade)
+	}
+	if len(grade.Failures) != expectedFailures {
+		t.Fatalf("Invalid failures: %v, expected %d failures", grade.Failures, expectedFailures)
+	}
+}
+
+func TestFinalGrade(t *testing.T) {
+	// Total ratio 0.625
+	checkGrade(t, &config.GradingConfig{}, 6, 0)
+	checkGrade(t, &config.GradingConfig{MaxGrade: 100}, 63, 0)
+
+	// (1 * 1 + 2 * 0.5) / 3
+	// Unreleased groups are ignored
+	weighted := &config.GradingConfig{
+		Components: []config.GradingComponentConfig{
+			{TaskGroup: "intro", Weight: 1},
+			{TaskGroup: "stl", Weight: 2},
+			{TaskGroup: "future", Weight: 10, MinTasks: 1},
+		},
+	}
+	checkGrade(t, weighted, 7, 0)
+
+	half := 0.5
+	weighted.Components[0].Cap = &half
+	checkGrade(t, weighted, 5, 0)
+
+	weighted.Scale = []config.GradeThresholdConfig{
+		{MinScore: 0, Grade: 1},
+		{MinScore: 0.8, Grade: 10},
+		{MinScore: 0.5, Grade: 7},
+	}
+	checkGrade(t, weighted, 7, 0)
+
+	weighted.FailGrade = 2
+	weighted.Components[1].MinTasks = 2
+	checkGrade(t, weighted, 2, 1)
+
+	weighted.Components = append(weighted.Components, config.GradingComponentConfig{TaskGroup: "missing", MinTasks: 1})
+	checkGrade(t, weighted, 2, 2)
+}
+
+func TestGradingValidation(t *testing.T) {
+	negative := -1.0
+	for _, grading := range []*config.GradingConfig{
+		{MaxGrade: -1},
+		{Components: []config.GradingComponentConfig{{TaskGroup: "a", Weight: 0}}},
+		{Components: []config.GradingComponentConfig{{TaskGroup: "a", Weight: -1}, {TaskGroup: "b", Weight: 2}}},
+		{Components: []config.GradingComponentConfig{{TaskGroup: "a", Weight: 1}, {TaskGroup: "a", Weight: 1}}},
+		{Components: []config.GradingComponentConfig{{TaskGroup: "a", Weight: 1, MinTasks: -1}}},
+		{Components: []config.GradingComponentConfig{{TaskGroup: "a", Weight: 1, Cap: &negative}}},
+		{Scale: []config.GradeThresholdConfig{{MinScore: -0.5, Grade: 1}}},
+	} {
+		if err := ValidateGrading(grading); err == nil {
+			t.Fatalf("Expected validation error for %+v", grading)
+		}
+	}
+}
+
+func TestTaskTimeline(t *testing.T) {
+	groups := deadlines.Deadlines{}
+	err := yaml.Unmarshal([]byte(someStrangeDeadlines), &groups)
+	if err != nil {
+		t.Fatal("Failed to parse deadlines:", err)
+	}
+
+	login := "neil"
+	user := &models.User{GitlabUser: models.GitlabUser{GitlabLogin: &login}}
+
+	pipelines := func(project string) ([]models.Pipeline, error) {
+		early := makePipeline("19-07-1969 23:00", models.PipelineStatusSuccess)
+		early.Task = "rewrite-in-rust"
+		late := makePipeline("21-07-1969 13:17", models.PipelineStatusFailed)
+		late.Task = "rewrite-in-rust"
+		other := makePipeline("19-07-1969 23:00", models.PipelineStatusSuccess)
+		other.Task = "rewrite-in-go"
+		return []models.Pipeline{*early, *late, *other}, nil
+	}
+	mergeRequests := func(project string) ([]models.MergeRequest, error) {
+		return nil, nil
+	}
+	flags := func(gitlabLogin string) ([]models.Flag, error) {
+		return []models.Flag{{
+			Task:      "rewrite-in-rust",
+			CreatedAt: mustParse(time.Parse("02-01-2006 15:04", "18-07-1969 00:00")),
+		}}, nil
+	}
+	extensions := func(userID uint) ([]models.Extension, error) {
+		return nil, nil
+	}
+	overrides := func(userID uint) ([]models.ScoreOverride, error) {
+		return nil, nil
+	}
+
+	s := Scorer{config: &config.Config{}, projects: fakeProjects{}}
+	timeline, err := s.calcTaskTimelineImpl(&groups, user, "rewrite-in-rust", time.Now(), pipelines, mergeRequests, flags, extensions, overrides)
+	if err != nil {
+		t.Fatal("Failed to calc timeline:", err)
+	}
+
+	if len(timeline.Entries) != 3 {
+		t.Fatalf("Expected 3 attempts, got %d", len(timeline.Entries))
+	}
+	failed, early, flag := timeline.Entries[0], timeline.Entries[1], timeline.Entries[2]
+	if failed.Kind != TimelineEntryPipeline || failed.Score != 0 || !failed.Effective {
+		t.Errorf("The latest pipeline must be effective: %+v", failed)
+	}
+	if early.Score != 9000 || early.Effective {
+		t.Errorf("Unexpected early attempt: %+v", early)
+	}
+	if flag.Kind != TimelineEntryFlag || flag.Effective {
+		t.Errorf("Flag must not be effective if there are pipelines: %+v", flag)
+	}
+	if timeline.Task.Score != 0 || timeline.Task.Status != TaskStatusFailed {
+		t.Errorf("Unexpected task result: %+v", timeline.Task)
+	}
+
+	if _, err = s.calcTaskTimelineImpl(&groups, user, "unknown", time.Now(), pipelines, mergeRequests, flags, extensions, overrides); err == nil {
+		t.Errorf("Unknown task is accepted")
+	}
+}
+
+func TestStandingsCacheInvalidation(t *testing.T) {
+	login := "gopher"
+	makeGroup := func() *groupScores {
+		return &groupScores{
+			scores:   map[uint]*UserScores{1: {}},
+			projects: map[string]uint{"gopher": 1},
+			logins:   map[string]uint{"gopher": 1},
+			dirty:    make(map[uint]bool),
+		}
+	}
+	cache := newStandingsCache("cpp")
+
+	group := makeGroup()
+	cache.groups["hse"] = group
+	cache.invalidate(&database.Change{Course: "cpp", Table: "pipelines", Rows: []interface{}{&models.Pipeline{Project: "gopher"}}})
+	cache.invalidate(&database.Change{Course: "cpp", Table: "pipelines", Rows: []interface{}{&models.Pipeline{Project: "stranger"}}})
+	if group.stale || len(group.dirty) != 1 || !group.dirty[1] {
+		t.Errorf("Only the pipeline owner must be recomputed: stale=%v, dirty=%v", group.stale, group.dirty)
+	}
+
+	group = makeGroup()
+	cache.groups["hse"] = group
+	cache.invalidate(&database.Change{Course: "rust", Table: "flags", Rows: []interface{}{&models.Flag{GitlabLogin: &login}}})
+	cache.invalidate(&database.Change{Course: "cpp", Table: "sessions", Rows: []interface{}{&models.Session{UserID: 1}}})
+	if group.stale || len(group.dirty) != 0 {
+		t.Errorf("Unrelated changes invalidated the cache: stale=%v, dirty=%v", group.stale, group.dirty)
+	}
+
+	cache.invalidate(&database.Change{Course: "cpp", Table: "flags", Rows: []interface{}{&models.Flag{}}})
+	if !group.stale {
+		t.Errorf("Change of unknown user must reload the group")
+	}
+
+	group = makeGroup()
+	cache.groups["hse"] = group
+	cache.invalidate(&database.Change{Table: "users", Rows: []interface{}{&models.User{}}})
+	if !group.stale {
+		t.Errorf("User changes must reload the group")
+	}
+}
+
+func TestScheduledRelease(t *testing.T) {
+	groups := deadlines.Deadlines{}
+	err := yaml.Unmarshal([]byte(policiesDeadlines), &groups)
+	if err != nil {
+		t.Fatal("Failed to parse deadlines:", err)
+	}
+
+	login := "neil"
+	user := &models.User{GitlabUser: models.GitlabUser{GitlabLogin: &login}}
+
+	pipelines := func(project string) ([]models.Pipeline, error) {
+		early := makePipeline("01-07-1968 12:00", models.PipelineStatusSuccess)
+		early.Task = "fly-me-to-the-moon"
+		return []models.Pipeline{*early}, nil
+	}
+	mergeRequests := func(project string) ([]models.MergeRequest, error) {
+		return nil, nil
+	}
+	flags := func(gitlabLogin string) ([]models.Flag, error) {
+		return nil, nil
+	}
+	extensions := func(userID uint) ([]models.Extension, error) {
+		return nil, nil
+	}
+	overrides := func(userID uint) ([]models.ScoreOverride, error) {
+		return nil, nil
+	}
+
+	s := Scorer{config: &config.Config{}, projects: fakeProjects{}}
+	before := mustParse(time.Parse("02-01-2006 15:04", "10-07-1968 00:00"))
+	scores, err := s.calcUserScoresImpl(&groups, user, before, pipelines, mergeRequests, flags, extensions, overrides)
+	if err != nil {
+		t.Fatal("Failed to calc scores:", err)
+	}
+	if scores.Groups[0].Released || scores.MaxScore != 0 {
+		t.Errorf("Unreleased group is counted: released=%v, max score %d", scores.Groups[0].Released, scores.MaxScore)
+	}
+	if scores.Upcoming == nil || scores.Upcoming.Title != "42-cpp-sucks" {
+		t.Errorf("Unexpected upcoming group: %+v", scores.Upcoming)
+	}
+	if len(scores.Released().Groups) != 0 {
+		t.Errorf("Unreleased group is not hidden")
+	}
+
+	after := mustParse(time.Parse("02-01-2006 15:04", "01-01-1970 00:00"))
+	scores, err = s.calcUserScoresImpl(&groups, user, after, pipelines, mergeRequests, flags, extensions, overrides)
+	if err != nil {
+		t.Fatal("Failed to calc scores:", err)
+	}
+	if !scores.Groups[0].Released || scores.MaxScore != 9100 || scores.Upcoming != nil {
+		t.Errorf("Released group is not counted: %+v", scores)
+	}
+	if task := scores.Groups[0].Tasks[0]; task.Status != TaskStatusAssigned || task.Score != 0 {
+		t.Errorf("Pipeline started before the release is not ignored: %+v", task)
+	}
+}
+
+func TestWithoutNotes(t *testing.T) {
+	scores := &UserScores{Groups: []ScoredTaskGroup{{
+		Extension: &Extension{Reason: "sick leave"},
+		Tasks: []ScoredTask{{
+			Score:     42,
+			Extension: &Extension{Reason: "olympiad"},
+			Override:  &Override{Kind: models.ScoreOverrideSet, Score: 42, Comment: "oral exam", Author: "teacher"},
+		}},
+	}}}
+
+	stripped := scores.WithoutNotes()
+	group := stripped.Groups[0]
+	task := group.Tasks[0]
+	if group.Extension.Reason != "" || task.Extension.Reason != "" || task.Override.Comment != "" || task.Override.Author != "" {
+		t.Errorf("Notes are not stripped: %+v", group)
+	}
+	if task.Score != 42 || task.Override.Score != 42 {
+		t.Errorf("Scores are changed: %+v", task)
+	}
+
+	original := scores.Groups[0]
+	if original.Extension.Reason == "" || original.Tasks[0].Extension.Reason == "" || original.Tasks[0].Override.Comment == "" {
+		t.Errorf("Original scores are modified: %+v", original)
+	}
+}
+
+func TestMakeSnapshots(t *testing.T) {
+	now := mustParse(time.Parse("02-01-2006 15:04", "20-07-1969 20:17"))
+	groups := func(scores ...int) []ScoredTaskGroup {
+		return []ScoredTaskGroup{
+			{Title: "saturn", Released: true, Score: scores[0], MaxScore: 450},
+			{Title: "moon", Released: true, Score: scores[1], MaxScore: 9000},
+			// Scores of hidden groups are not saved
+			{Title: "mars", Score: scores[2], MaxScore: 100500},
+		}
+	}
+	standings := &Standings{Users: []*UserScores{
+		{User: User{GitlabLogin: "neil", Group: "apollo", Subgroup: "11"}, Groups: groups(100, 0, 42), Score: 100, MaxScore: 9450, TasksOnReview: 1},
+		{User: User{GitlabLogin: "buzz", Group: "apollo"}, Groups: groups(0, 50, 0), Score: 50, MaxScore: 9450},
+	}}
+
+	expected := []models.ScoreSnapshot{
+		{GitlabLogin: "neil", GroupName: "apollo", Subgroup: "11", Score: 100, MaxScore: 9450, TasksOnReview: 1, CreatedAt: now},
+		{GitlabLogin: "buzz", GroupName: "apollo", Score: 50, MaxScore: 9450, CreatedAt: now},
+	}
+	if snapshots := makeSnapshots(standings, now); !reflect.DeepEqual(snapshots, expected) {
+		t.Errorf("Unexpected snapshots %+v, expected: %+v", snapshots, expected)
+	}
+
+	if snapshots := makeSnapshots(&Standings{}, now); len(snapshots) != 0 {
+		t.Errorf("Unexpected snapshots of empty standings: %+v", snapshots)
+	}
+}
+
+func TestSnapshotDue(t *testing.T) {
+	now := mustParse(time.Parse("02-01-2006 15:04", "20-07-1969 20:17"))
+	for _, tc := range []struct {
+		latest   *models.ScoreSnapshot
+		expected bool
+	}{
+		{nil, true},
+		{&models.ScoreSnapshot{CreatedAt: now.Add(-time.Hour)}, false},
+		{&models.ScoreSnapshot{CreatedAt: now.Add(-6 * time.Hour)}, true},
+		{&models.ScoreSnapshot{CreatedAt: now.Add(-24 * time.Hour)}, true},
+	} {
+		if due := snapshotDue(tc.latest, now, 6*time.Hour); due != tc.expected {
+			t.Errorf("Unexpected snapshotDue(%+v): %v", tc.latest, due)
+		}
+	}
+}
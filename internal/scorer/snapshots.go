@@ -0,0 +1,98 @@
+package scorer
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/bigredeye/notmanytask/internal/config"
+	"github.com/bigredeye/notmanytask/internal/database"
+	"github.com/bigredeye/notmanytask/internal/models"
+)
+
+const defaultSnapshotInterval = 6 * time.Hour
+
+// Snapshotter periodically saves total scores of all users to draw progress charts
+type Snapshotter struct {
+	config *config.Config
+	logger *zap.Logger
+	scorer *Scorer
+	db     *database.DataBase
+}
+
+func NewSnapshotter(config *config.Config, logger *zap.Logger, scorer *Scorer, db *database.DataBase) *Snapshotter {
+	return &Snapshotter{config, logger, scorer, db}
+}
+
+func (s Snapshotter) Run(ctx context.Context) {
+	interval := s.config.PullIntervals.ScoreSnapshots
+	if interval <= 0 {
+		interval = defaultSnapshotInterval
+	}
+
+	// Restarts must not leave gaps in charts longer than the interval
+	latest, err := s.db.FindLatestScoreSnapshot()
+	if err != nil {
+		s.logger.Error("Failed to find latest score snapshot", zap.Error(err))
+	} else if snapshotDue(latest, time.Now(), interval) {
+		s.snapshot()
+	}
+
+	tick := time.Tick(interval)
+	for {
+		select {
+		case <-tick:
+			s.snapshot()
+		case <-ctx.Done():
+			s.logger.Info("Stopping score snapshotter")
+			return
+		}
+	}
+}
+
+func (s Snapshotter) snapshot() {
+	for _, group := range s.config.Groups {
+		log := s.logger.With(zap.String("group", group.Name))
+
+		standings, err := s.scorer.CalcScoreboard(group.Name, "")
+		if err != nil {
+			log.Error("Failed to calc scoreboard", zap.Error(err))
+			continue
+		}
+
+		snapshots := makeSnapshots(standings, time.Now())
+		if err = s.db.AddScoreSnapshots(snapshots); err != nil {
+			log.Error("Failed to save score snapshots", zap.Error(err))
+			continue
+		}
+		log.Info("Saved score snapshots", zap.Int("count", len(snapshots)))
+	}
+}
+
+func snapshotDue(latest *models.ScoreSnapshot, now time.Time, interval time.Duration) bool {
+	return latest == nil || !now.Before(latest.CreatedAt.Add(interval))
+}
+
+// makeSnapshots counts released groups only, history is shown to students
+func makeSnapshots(standings *Standings, now time.Time) []models.ScoreSnapshot {
+	released := standings.Released(now)
+	snapshots := make([]models.ScoreSnapshot, len(released.Users))
+	for i, user := range released.Users {
+		score, maxScore := 0, 0
+		for _, group := range user.Groups {
+			score += group.Score
+			maxScore += group.MaxScore
+		}
+		snapshots[i] = models.ScoreSnapshot{
+			GitlabLogin:   user.User.GitlabLogin,
+			GroupName:     user.User.Group,
+			Subgroup:      user.User.Subgroup,
+			Score:         score,
+			MaxScore:      maxScore,
+			TasksOnReview: user.TasksOnReview,
+			CreatedAt:     now,
+		}
+	}
+	return snapshots
+}
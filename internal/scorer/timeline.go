@@ -0,0 +1,163 @@
+package scorer
+
+import (
+	"sort"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/bigredeye/notmanytask/internal/deadlines"
+	"github.com/bigredeye/notmanytask/internal/models"
+)
+
+const (
+	TimelineEntryPipeline     = "pipeline"
+	TimelineEntryMergeRequest = "merge_request"
+	TimelineEntryFlag         = "flag"
+)
+
+type TimelineEntryKind = string
+
+type TimelineEntry struct {
+	Kind   TimelineEntryKind
+	Time   time.Time
+	Status string
+	Url    string
+
+	// Score the attempt earns under the current policy, merge requests do not score
+	Score int
+	// The attempt determines the task score
+	Effective bool
+}
+
+type TaskTimeline struct {
+	// Effective task result, including overrides
+	Task     ScoredTask
+	Group    string
+	Deadline deadlines.Date
+	Released bool
+
+	// Newest first
+	Entries []TimelineEntry
+}
+
+// CalcTaskTimeline lists every submit of the task made by the user
+func (s Scorer) CalcTaskTimeline(user *models.User, taskName string) (*TaskTimeline, error) {
+	currentDeadlines := s.deadlines.GroupDeadlines(user.GroupName)
+	if currentDeadlines == nil {
+		return nil, errors.New("No deadlines found")
+	}
+
+	return s.calcTaskTimelineImpl(currentDeadlines, user, taskName, time.Now(), s.db.ListProjectPipelines, s.db.ListProjectMergeRequests, s.db.ListUserFlags, s.db.ListUserExtensions, s.db.ListUserScoreOverrides)
+}
+
+func (s Scorer) calcTaskTimelineImpl(
+	currentDeadlines *deadlines.Deadlines,
+	user *models.User,
+	taskName string,
+	now time.Time,
+	pipelinesP pipelinesProvider,
+	mergeRequestsP mergeRequestsProvider,
+	flagsP flagsProvider,
+	extensionsP extensionsProvider,
+	overridesP overridesProvider,
+) (*TaskTimeline, error) {
+	scores, err := s.calcUserScoresImpl(currentDeadlines, user, now, pipelinesP, mergeRequestsP, flagsP, extensionsP, overridesP)
+	if err != nil {
+		return nil, err
+	}
+
+	group, task := deadlines.FindTask(currentDeadlines, taskName)
+	if task == nil {
+		return nil, errors.Errorf("Unknown task %s", taskName)
+	}
+
+	timeline := &TaskTimeline{Group: group.Group, Deadline: group.Deadline, Released: group.Released(now)}
+	for _, g := range scores.Groups {
+		for _, t := range g.Tasks {
+			if t.Task == taskName {
+				timeline.Task = t
+			}
+		}
+	}
+
+	extensions, err := s.loadUserExtensions(user, extensionsP)
+	if err != nil {
+		return nil, err
+	}
+	taskGroup := extensions.extendedGroup(*group, taskName)
+	timeline.Deadline = taskGroup.Deadline
+
+	scoring, err := s.scoringFuncs(user.GroupName, currentDeadlines)
+	if err != nil {
+		return nil, err
+	}
+
+	pipelines, err := pipelinesP(s.projects.MakeProjectName(user))
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to list user pipelines")
+	}
+	flags, err := flagsP(*user.GitlabLogin)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to list user flags")
+	}
+	effectivePipeline, effectiveFlag := effectiveSubmit(group, taskName, makePipelinesMap(pipelines), makeFlagsMap(flags))
+
+	for i := range pipelines {
+		pipeline := &pipelines[i]
+		if pipeline.Task != taskName || !group.Released(pipeline.StartedAt) {
+			continue
+		}
+		entry := TimelineEntry{
+			Kind:      TimelineEntryPipeline,
+			Time:      pipeline.StartedAt,
+			Status:    pipeline.Status,
+			Url:       s.projects.MakePipelineUrl(user, pipeline),
+			Effective: pipeline == effectivePipeline,
+		}
+		if pipeline.Status == models.PipelineStatusSuccess {
+			entry.Score = scoring.score(task, &taskGroup, pipeline)
+		}
+		timeline.Entries = append(timeline.Entries, entry)
+	}
+
+	for i := range flags {
+		flag := &flags[i]
+		if flag.Task != taskName || !group.Released(flag.CreatedAt) {
+			continue
+		}
+		entry := TimelineEntry{
+			Kind:      TimelineEntryFlag,
+			Time:      flag.CreatedAt,
+			Status:    models.PipelineStatusSuccess,
+			Effective: flag == effectiveFlag,
+		}
+		entry.Score = scoring.score(task, &taskGroup, &models.Pipeline{
+			StartedAt: flag.CreatedAt,
+			Status:    models.PipelineStatusSuccess,
+		})
+		timeline.Entries = append(timeline.Entries, entry)
+	}
+
+	mergeRequests, err := mergeRequestsP(s.projects.MakeProjectName(user))
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to list user merge requests")
+	}
+	for i := range mergeRequests {
+		mergeRequest := &mergeRequests[i]
+		if mergeRequest.Task != taskName {
+			continue
+		}
+		timeline.Entries = append(timeline.Entries, TimelineEntry{
+			Kind:   TimelineEntryMergeRequest,
+			Time:   mergeRequest.StartedAt,
+			Status: mergeRequest.Status,
+			Url:    s.projects.MakeMergeRequestUrl(user, mergeRequest),
+		})
+	}
+
+	sort.SliceStable(timeline.Entries, func(i, j int) bool {
+		return timeline.Entries[i].Time.After(timeline.Entries[j].Time)
+	})
+	return timeline, nil
+}
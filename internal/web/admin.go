@@ -0,0 +1,562 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/bigredeye/notmanytask/internal/deadlines"
+	"github.com/bigredeye/notmanytask/internal/export"
+	lf "github.com/bigredeye/notmanytask/internal/logfield"
+	"github.com/bigredeye/notmanytask/internal/models"
+)
+
+const (
+	extensionTargetGroup = "group:"
+	extensionTargetTask  = "task:"
+
+	// Format of <input type="datetime-local">
+	dateTimeLocalFormat = "2006-01-02T15:04"
+)
+
+type AdminUser struct {
+	*models.User
+
+	HomeLink      string
+	StandingsLink string
+}
+
+func (s *server) makeAdminUserLink(endpoint string, login *string) string {
+	if login == nil {
+		return ""
+	}
+	return endpoint + "?login=" + url.QueryEscape(*login)
+}
+
+func (s *server) makeAdminGroupLinks() GroupLinks {
+	links := make(GroupLinks, 0, len(s.config.Groups))
+	for _, g := range s.config.Groups {
+		links = append(links, GroupLink{
+			Name: g.Name,
+			Link: s.config.Endpoints.Admin.Users + "?group=" + url.QueryEscape(g.Name),
+		})
+		for _, subgroup := range g.Subgroups {
+			links = append(links, GroupLink{
+				Name: fmt.Sprintf("%s/%s", g.Name, subgroup.Name),
+				Link: s.config.Endpoints.Admin.Users + "?group=" + url.QueryEscape(g.Name) + "&subgroup=" + url.QueryEscape(subgroup.Name),
+			})
+		}
+	}
+	return links
+}
+
+func (s *server) RenderAdminUsersPage(c *gin.Context) {
+	s.RenderAdminUsersPageDetails(c, "")
+}
+
+func (s *server) RenderAdminUsersPageDetails(c *gin.Context, errorMessage string) {
+	admin := s.getUser(c)
+
+	users, err := s.db.ListUsers(c.Query("group"), c.Query("subgroup"))
+	if err != nil {
+		s.logger.Error("Failed to list users", zap.Error(err))
+		errorMessage = "Failed to list users"
+	}
+
+	adminUsers := make([]AdminUser, len(users))
+	for i, user := range users {
+		adminUsers[i] = AdminUser{
+			User:          user,
+			HomeLink:      s.makeAdminUserLink(s.config.Endpoints.Admin.User, user.GitlabLogin),
+			StandingsLink: s.makeAdminUserLink(s.config.Endpoints.Admin.Standings, user.GitlabLogin),
+		}
+	}
+
+	c.HTML(http.StatusOK, "/admin.tmpl", gin.H{
+		"CourseName":   s.config.Title,
+		"Title":        s.config.Title,
+		"Config":       s.config,
+		"Links":        s.makeLinks(admin),
+		"Groups":       s.makeAdminGroupLinks(),
+		"Users":        adminUsers,
+		"Exports":      s.makeExportLinks(c.Query("group"), c.Query("subgroup")),
+		"ErrorMessage": errorMessage,
+	})
+}
+
+func (s *server) handleGrantExtension(c *gin.Context) {
+	admin := s.getUser(c)
+	login := c.PostForm("login")
+	target := c.PostForm("target")
+	reason := c.PostForm("reason")
+
+	log := s.logger.With(
+		lf.GitlabLogin(login),
+		zap.String("target", target),
+		zap.Stringp("granted_by", admin.GitlabLogin),
+	)
+
+	user, err := s.db.FindUserByGitlabLogin(login)
+	if err != nil {
+		log.Warn("Failed to find user", zap.Error(err))
+		s.RenderAdminUsersPageDetails(c, "Unknown user")
+		return
+	}
+
+	extension := &models.Extension{
+		UserID:    user.ID,
+		Exempt:    c.PostForm("exempt") != "",
+		Reason:    reason,
+		GrantedBy: *admin.GitlabLogin,
+	}
+	if !extension.Exempt {
+		extension.Deadline, err = time.ParseInLocation(dateTimeLocalFormat, c.PostForm("deadline"), s.viewerLocation(admin))
+		if err != nil {
+			log.Warn("Invalid extension deadline", zap.Error(err))
+			s.RenderAdminUsersPageDetails(c, "Invalid extension deadline")
+			return
+		}
+	}
+
+	// Extensions of unknown tasks would silently do nothing
+	groupDeadlines := s.deadlines.GroupDeadlines(user.GroupName)
+	known := false
+	switch {
+	case strings.HasPrefix(target, extensionTargetGroup):
+		extension.TaskGroup = strings.TrimPrefix(target, extensionTargetGroup)
+		known = groupDeadlines != nil && deadlines.FindGroup(groupDeadlines, extension.TaskGroup) != nil
+	case strings.HasPrefix(target, extensionTargetTask):
+		extension.Task = strings.TrimPrefix(target, extensionTargetTask)
+		if groupDeadlines != nil {
+			_, task := deadlines.FindTask(groupDeadlines, extension.Task)
+			known = task != nil
+		}
+	}
+	if !known {
+		log.Warn("Invalid extension target")
+		s.RenderAdminUsersPageDetails(c, "Invalid extension target")
+		return
+	}
+
+	if err = s.db.AddExtension(extension); err != nil {
+		log.Error("Failed to add extension", zap.Error(err))
+		s.RenderAdminUsersPageDetails(c, "Failed to add extension")
+		return
+	}
+	log.Info("Granted extension", zap.Time("deadline", extension.Deadline), zap.Bool("exempt", extension.Exempt))
+
+	c.Redirect(http.StatusFound, s.makeAdminUserLink(s.config.Endpoints.Admin.User, user.GitlabLogin))
+}
+
+func (s *server) handleScoreOverride(c *gin.Context) {
+	admin := s.getUser(c)
+	login := c.PostForm("login")
+	task := c.PostForm("task")
+	kind := c.PostForm("kind")
+
+	log := s.logger.With(
+		lf.GitlabLogin(login),
+		zap.String("task", task),
+		zap.String("kind", kind),
+		zap.Stringp("author", admin.GitlabLogin),
+	)
+
+	user, err := s.db.FindUserByGitlabLogin(login)
+	if err != nil {
+		log.Warn("Failed to find user", zap.Error(err))
+		s.RenderAdminUsersPageDetails(c, "Unknown user")
+		return
+	}
+
+	// Overrides are never deleted, so invalid ones would stay in the audit log doing nothing
+	switch kind {
+	case models.ScoreOverrideSet, models.ScoreOverrideDelta, models.ScoreOverrideReset:
+	default:
+		log.Warn("Unknown override kind")
+		s.RenderAdminUsersPageDetails(c, "Unknown override kind")
+		return
+	}
+	known := false
+	if groupDeadlines := s.deadlines.GroupDeadlines(user.GroupName); groupDeadlines != nil {
+		_, found := deadlines.FindTask(groupDeadlines, task)
+		known = found != nil
+	}
+	if !known {
+		log.Warn("Unknown override task")
+		s.RenderAdminUsersPageDetails(c, "Unknown task")
+		return
+	}
+
+	score := 0
+	if kind != models.ScoreOverrideReset {
+		score, err = strconv.Atoi(c.PostForm("score"))
+		if err != nil {
+			log.Warn("Invalid override score", zap.Error(err))
+			s.RenderAdminUsersPageDetails(c, "Invalid score")
+			return
+		}
+	}
+
+	err = s.db.AddScoreOverride(&models.ScoreOverride{
+		UserID:  user.ID,
+		Task:    task,
+		Kind:    kind,
+		Score:   score,
+		Comment: c.PostForm("comment"),
+		Author:  *admin.GitlabLogin,
+	})
+	if err != nil {
+		log.Error("Failed to add score override", zap.Error(err))
+		s.RenderAdminUsersPageDetails(c, "Failed to override score")
+		return
+	}
+	log.Info("Overrode task score", zap.Int("score", score))
+
+	c.Redirect(http.StatusFound, s.makeAdminUserLink(s.config.Endpoints.Admin.User, user.GitlabLogin))
+}
+
+func (s *server) handleSyncTemplate(c *gin.Context) {
+	admin := s.getUser(c)
+	login := c.PostForm("login")
+	log := s.logger.With(lf.GitlabLogin(login), zap.Stringp("author", admin.GitlabLogin))
+
+	user, err := s.db.FindUserByGitlabLogin(login)
+	if err != nil {
+		log.Warn("Failed to find user", zap.Error(err))
+		s.RenderAdminUsersPageDetails(c, "Unknown user")
+		return
+	}
+
+	update, err := s.templates.SyncProject(user)
+	if err != nil {
+		log.Error("Failed to sync template", zap.Error(err))
+		s.RenderAdminUsersPageDetails(c, "Failed to sync template")
+		return
+	}
+	if update.Status == models.TemplateUpdateFailed {
+		s.RenderAdminUsersPageDetails(c, "Failed to sync template: "+update.Error)
+		return
+	}
+	if update.Status != models.TemplateUpdateMerged && update.MergeRequestURL != "" {
+		c.Redirect(http.StatusFound, update.MergeRequestURL)
+		return
+	}
+
+	c.Redirect(http.StatusFound, s.makeAdminUserLink(s.config.Endpoints.Admin.User, user.GitlabLogin))
+}
+
+type TemplateUpdateEntry struct {
+	Student  *models.User
+	Update   *models.TemplateUpdate
+	HomeLink string
+}
+
+func (s *server) RenderTemplateUpdatesPage(c *gin.Context) {
+	s.RenderTemplateUpdatesPageDetails(c, "")
+}
+
+// RenderTemplateUpdatesPageDetails lists students who have not merged the latest template update
+func (s *server) RenderTemplateUpdatesPageDetails(c *gin.Context, errorMessage string) {
+	admin := s.getUser(c)
+	showAll := c.Query("all") != ""
+
+	updates, err := s.db.ListTemplateUpdates()
+	if err != nil {
+		s.logger.Error("Failed to list template updates", zap.Error(err))
+		errorMessage = "Failed to list template updates"
+	}
+	projectUpdates := make(map[string]*models.TemplateUpdate, len(updates))
+	for i := range updates {
+		projectUpdates[updates[i].Project] = &updates[i]
+	}
+
+	users, err := s.db.ListUsers("", "")
+	if err != nil {
+		s.logger.Error("Failed to list users", zap.Error(err))
+		errorMessage = "Failed to list users"
+	}
+	entries := make([]TemplateUpdateEntry, 0, len(users))
+	for _, user := range users {
+		if user.Repository == nil || s.config.ProjectFor(user.GroupName).ForkFrom == 0 {
+			continue
+		}
+		update := projectUpdates[s.gitlab.MakeProjectName(user)]
+		if !showAll && update != nil && update.Status == models.TemplateUpdateMerged {
+			continue
+		}
+		entries = append(entries, TemplateUpdateEntry{
+			Student:  user,
+			Update:   update,
+			HomeLink: s.makeAdminUserLink(s.config.Endpoints.Admin.User, user.GitlabLogin),
+		})
+	}
+
+	c.HTML(http.StatusOK, "/template_updates.tmpl", gin.H{
+		"CourseName":   s.config.Title,
+		"Title":        s.config.Title,
+		"Config":       s.config,
+		"Links":        s.makeLinks(admin),
+		"Location":     s.viewerLocation(admin),
+		"Entries":      entries,
+		"ShowAll":      showAll,
+		"ErrorMessage": errorMessage,
+	})
+}
+
+func (s *server) handleTemplateUpdatesTrigger(c *gin.Context) {
+	admin := s.getUser(c)
+	s.logger.Info("Template update requested", zap.Stringp("author", admin.GitlabLogin))
+	s.templates.Trigger()
+	c.Redirect(http.StatusFound, s.config.Endpoints.Admin.TemplateUpdates)
+}
+
+type ProvisioningJobEntry struct {
+	models.ProvisioningJob
+
+	Student  *models.User
+	HomeLink string
+}
+
+func (s *server) RenderProvisioningPage(c *gin.Context) {
+	s.RenderProvisioningPageDetails(c, "")
+}
+
+func (s *server) RenderProvisioningPageDetails(c *gin.Context, errorMessage string) {
+	admin := s.getUser(c)
+
+	jobs, err := s.db.ListProvisioningJobs()
+	if err != nil {
+		s.logger.Error("Failed to list provisioning jobs", zap.Error(err))
+		errorMessage = "Failed to list provisioning jobs"
+	}
+
+	users, err := s.db.ListUsers("", "")
+	if err != nil {
+		s.logger.Error("Failed to list users", zap.Error(err))
+		errorMessage = "Failed to list users"
+	}
+	usersByID := make(map[uint]*models.User, len(users))
+	for _, user := range users {
+		usersByID[user.ID] = user
+	}
+
+	entries := make([]ProvisioningJobEntry, 0, len(jobs))
+	for _, job := range jobs {
+		entry := ProvisioningJobEntry{ProvisioningJob: job, Student: usersByID[job.UserID]}
+		if entry.Student != nil {
+			entry.HomeLink = s.makeAdminUserLink(s.config.Endpoints.Admin.User, entry.Student.GitlabLogin)
+		}
+		entries = append(entries, entry)
+	}
+
+	c.HTML(http.StatusOK, "/provisioning.tmpl", gin.H{
+		"CourseName":   s.config.Title,
+		"Title":        s.config.Title,
+		"Config":       s.config,
+		"Links":        s.makeLinks(admin),
+		"Location":     s.viewerLocation(admin),
+		"Jobs":         entries,
+		"ErrorMessage": errorMessage,
+	})
+}
+
+func (s *server) handleProvisioningRetry(c *gin.Context) {
+	admin := s.getUser(c)
+	id, err := strconv.ParseUint(c.PostForm("id"), 10, 64)
+	if err != nil {
+		s.RenderProvisioningPageDetails(c, "Invalid job id")
+		return
+	}
+
+	log := s.logger.With(zap.Uint64("job_id", id), zap.Stringp("author", admin.GitlabLogin))
+	if err = s.db.RetryProvisioningJob(uint(id), time.Now()); err != nil {
+		log.Warn("Failed to retry provisioning job", zap.Error(err))
+		s.RenderProvisioningPageDetails(c, "Failed to retry job")
+		return
+	}
+	log.Info("Retrying provisioning job")
+	s.projects.Wakeup()
+
+	c.Redirect(http.StatusFound, s.config.Endpoints.Admin.Provisioning)
+}
+
+type DeadlinesRevisionEntry struct {
+	models.DeadlinesRevision
+
+	ChangeList []string
+}
+
+func (s *server) RenderDeadlinesStatusPage(c *gin.Context) {
+	admin := s.getUser(c)
+	errorMessage := ""
+
+	revisions, err := s.db.ListDeadlinesRevisions(50)
+	if err != nil {
+		s.logger.Error("Failed to list deadlines revisions", zap.Error(err))
+		errorMessage = "Failed to list deadlines revisions"
+	}
+
+	entries := make([]DeadlinesRevisionEntry, 0, len(revisions))
+	for _, revision := range revisions {
+		entry := DeadlinesRevisionEntry{DeadlinesRevision: revision}
+		if revision.Changes != "" {
+			entry.ChangeList = strings.Split(revision.Changes, "\n")
+		}
+		entries = append(entries, entry)
+	}
+
+	c.HTML(http.StatusOK, "/deadlines.tmpl", gin.H{
+		"CourseName":   s.config.Title,
+		"Title":        s.config.Title,
+		"Config":       s.config,
+		"Links":        s.makeLinks(admin),
+		"Location":     s.viewerLocation(admin),
+		"Statuses":     s.deadlines.Status(),
+		"Revisions":    entries,
+		"ErrorMessage": errorMessage,
+	})
+}
+
+type ScoreOverrideLogEntry struct {
+	models.ScoreOverride
+
+	Student  *models.User
+	HomeLink string
+}
+
+func (s *server) RenderScoreOverridesPage(c *gin.Context) {
+	admin := s.getUser(c)
+	errorMessage := ""
+
+	overrides, err := s.db.ListAllScoreOverrides()
+	if err != nil {
+		s.logger.Error("Failed to list score overrides", zap.Error(err))
+		errorMessage = "Failed to list score overrides"
+	}
+
+	users, err := s.db.ListUsers("", "")
+	if err != nil {
+		s.logger.Error("Failed to list users", zap.Error(err))
+		errorMessage = "Failed to list users"
+	}
+	usersByID := make(map[uint]*models.User, len(users))
+	for _, user := range users {
+		usersByID[user.ID] = user
+	}
+
+	// Newest first
+	entries := make([]ScoreOverrideLogEntry, len(overrides))
+	for i := range overrides {
+		override := overrides[len(overrides)-i-1]
+		entries[i] = ScoreOverrideLogEntry{
+			ScoreOverride: override,
+			Student:       usersByID[override.UserID],
+		}
+		if student := entries[i].Student; student != nil {
+			entries[i].HomeLink = s.makeAdminUserLink(s.config.Endpoints.Admin.User, student.GitlabLogin)
+		}
+	}
+
+	c.HTML(http.StatusOK, "/overrides.tmpl", gin.H{
+		"CourseName":   s.config.Title,
+		"Title":        s.config.Title,
+		"Config":       s.config,
+		"Links":        s.makeLinks(admin),
+		"Location":     s.viewerLocation(admin),
+		"Overrides":    entries,
+		"ErrorMessage": errorMessage,
+	})
+}
+
+type ExportLink struct {
+	Format string
+	Link   string
+}
+
+func (s *server) makeExportLinks(group, subgroup string) []ExportLink {
+	if group == "" {
+		return nil
+	}
+
+	query := url.Values{}
+	query.Set("group", group)
+	if subgroup != "" {
+		query.Set("subgroup", subgroup)
+	}
+
+	links := make([]ExportLink, 0, 2)
+	for _, format := range []string{export.FormatCSV, export.FormatXLSX} {
+		query.Set("format", format)
+		links = append(links, ExportLink{
+			Format: strings.ToUpper(format),
+			Link:   s.config.Endpoints.Admin.Export + "?" + query.Encode(),
+		})
+	}
+	return links
+}
+
+func (s *server) handleStandingsExport(c *gin.Context) {
+	group := c.Query("group")
+	subgroup := c.Query("subgroup")
+	format := c.DefaultQuery("format", export.FormatCSV)
+
+	log := s.logger.With(
+		zap.String("group", group),
+		zap.String("subgroup", subgroup),
+		zap.String("format", format),
+	)
+
+	if format != export.FormatCSV && format != export.FormatXLSX {
+		log.Warn("Unknown export format")
+		c.String(http.StatusBadRequest, "Unknown export format %q", format)
+		return
+	}
+
+	standings, err := s.scorer.CalcScoreboard(group, subgroup)
+	if err != nil {
+		log.Error("Failed to calc standings", zap.Error(err))
+		c.String(http.StatusInternalServerError, "Failed to calc standings")
+		return
+	}
+
+	name := "standings-" + group
+	if subgroup != "" {
+		name += "-" + subgroup
+	}
+	name += "." + format
+
+	c.Header("Content-Type", export.ContentType(format))
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+	c.Status(http.StatusOK)
+
+	if err = export.NewTable(standings).Write(c.Writer, format); err != nil {
+		log.Error("Failed to export standings", zap.Error(err))
+	}
+}
+
+func (s *server) RenderForeignFlagsPage(c *gin.Context) {
+	admin := s.getUser(c)
+	errorMessage := ""
+
+	submissions, err := s.db.ListForeignFlagSubmissions()
+	if err != nil {
+		s.logger.Error("Failed to list foreign flag submissions", zap.Error(err))
+		errorMessage = "Failed to list foreign flag submissions"
+	}
+
+	c.HTML(http.StatusOK, "/foreign_flags.tmpl", gin.H{
+		"CourseName":   s.config.Title,
+		"Title":        s.config.Title,
+		"Config":       s.config,
+		"Links":        s.makeLinks(admin),
+		"Location":     s.viewerLocation(admin),
+		"Submissions":  submissions,
+		"ErrorMessage": errorMessage,
+	})
+}
@@ -1,25 +1,42 @@
 package web
 
 import (
+	"crypto/subtle"
 	"fmt"
+	"io"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/bigredeye/notmanytask/api"
+	"github.com/bigredeye/notmanytask/internal/gitlab"
 	lf "github.com/bigredeye/notmanytask/internal/logfield"
+	"github.com/bigredeye/notmanytask/internal/models"
 	"github.com/gin-gonic/gin"
+	gogitlab "github.com/xanzy/go-gitlab"
 	"go.uber.org/zap"
 )
 
+type snapshotsStorage interface {
+	ListUserScoreSnapshots(gitlabLogin string, since time.Time) ([]models.ScoreSnapshot, error)
+	ListGroupScoreSnapshots(groupName string, subgroupName string, since time.Time) ([]models.ScoreSnapshot, error)
+}
+
 type apiService struct {
 	webService
+	snapshots snapshotsStorage
 }
 
 func setupApiService(server *server, r *gin.Engine) error {
-	s := apiService{webService{server, server.config, server.logger}}
+	s := apiService{webService{server, server.config, server.logger}, server.db}
 
 	r.POST(server.config.Endpoints.Api.Report, s.report)
 	r.POST(server.config.Endpoints.Api.Flag, s.createFlag)
+	r.GET(server.config.Endpoints.Api.Scores, server.validateApiSession, s.userScores)
+	r.GET(server.config.Endpoints.Api.Standings, server.validateApiSession, s.standings)
+	r.POST(server.config.Endpoints.Api.Webhook, s.webhook)
+	r.GET(server.config.Endpoints.Api.ScoresHistory, server.validateApiSession, s.scoresHistory)
+	r.GET(server.config.Endpoints.Api.StandingsHistory, server.validateApiSession, s.standingsHistory)
 
 	return nil
 }
@@ -110,6 +127,7 @@ func (s apiService) createFlag(c *gin.Context) {
 	s.log.Info("Parsed flag request json",
 		lf.Token(req.Token),
 		zap.String("task", req.Task),
+		lf.GitlabLogin(req.Login),
 	)
 
 	// Check token
@@ -126,13 +144,30 @@ func (s apiService) createFlag(c *gin.Context) {
 		return
 	}
 
-	flag, err := s.server.db.CreateFlag(req.Task)
+	var flag *models.Flag
+	var err error
+	if req.Login != "" && s.server.flags != nil {
+		login := req.Login
+		now := time.Now()
+		flag = &models.Flag{
+			ID:        s.server.flags.Sign(req.Task, login, now),
+			Task:      req.Task,
+			CreatedAt: now,
+			Owner:     &login,
+		}
+		err = s.server.db.AddFlag(flag)
+	} else if s.config.Flags.AllowUnbound || s.server.flags == nil {
+		flag, err = s.server.db.CreateFlag(req.Task)
+	} else {
+		onError(http.StatusBadRequest, fmt.Errorf("Login is required"))
+		return
+	}
 	if err != nil {
 		s.log.Error("Failed to create flag", zap.String("task", req.Task), zap.Error(err))
 		onError(http.StatusInternalServerError, err)
 		return
 	}
-	s.log.Info("Created new flag", zap.String("flag", flag.ID), zap.String("task", flag.Task))
+	s.log.Info("Created new flag", zap.String("flag", flag.ID), zap.String("task", flag.Task), zap.Stringp("owner", flag.Owner))
 
 	c.JSON(http.StatusOK, &api.FlagResponse{
 		Status: api.Status{
@@ -160,19 +195,32 @@ func (s apiService) userScores(c *gin.Context) {
 		return
 	}
 
-	user, err := s.server.db.FindUserByGitlabLogin(req.Login)
-	if err != nil {
-		s.log.Error("Failed to get user by login", lf.GitlabLogin(req.Login))
-		onError(http.StatusNotFound, fmt.Errorf("Not found user"))
-		return
+	viewer := s.server.getUser(c)
+	user := viewer
+	if req.Login != "" && req.Login != *user.GitlabLogin {
+		if !viewer.IsAdmin {
+			onError(http.StatusForbidden, fmt.Errorf("Scores of other users are available to admins only"))
+			return
+		}
+		var err error
+		user, err = s.server.db.FindUserByGitlabLogin(req.Login)
+		if err != nil {
+			s.log.Error("Failed to get user by login", lf.GitlabLogin(req.Login))
+			onError(http.StatusNotFound, fmt.Errorf("Not found user"))
+			return
+		}
 	}
 
 	scores, err := s.server.scorer.CalcUserScores(user)
 	if err != nil {
-		s.log.Error("Failed to calc scores", lf.GitlabLogin(req.Login), zap.Error(err))
+		s.log.Error("Failed to calc scores", lf.GitlabLogin(*user.GitlabLogin), zap.Error(err))
 		onError(http.StatusInternalServerError, fmt.Errorf("Failed to calc scores"))
 		return
 	}
+	if notModified(c, makeETag(scores.ModifiedAt, viewer.ID), scores.ModifiedAt) {
+		return
+	}
+	scores = visibleScores(viewer, scores)
 
 	c.JSON(http.StatusOK, &api.UserScoresResponse{
 		Status: api.Status{
@@ -181,3 +229,216 @@ func (s apiService) userScores(c *gin.Context) {
 		Scores: scores,
 	})
 }
+
+func (s apiService) standings(c *gin.Context) {
+	s.log.Info("Handling standings request")
+	onError := func(code int, err error) {
+		s.log.Warn("Failed to create standings report", zap.Error(err))
+		c.JSON(code, &api.StandingsResponse{
+			Status: api.Status{
+				Ok:    false,
+				Error: err.Error(),
+			}},
+		)
+	}
+
+	req := api.StandingsRequest{}
+	if err := c.Bind(&req); err != nil {
+		onError(http.StatusBadRequest, fmt.Errorf("Failed to parse request: %w", err))
+		return
+	}
+	if req.Group == "" {
+		req.Group = s.server.getUser(c).GroupName
+	}
+
+	standings, err := s.server.scorer.CalcScoreboard(req.Group, req.Subgroup)
+	if err != nil {
+		s.log.Error("Failed to calc standings", zap.String("group", req.Group), zap.String("subgroup", req.Subgroup), zap.Error(err))
+		onError(http.StatusInternalServerError, fmt.Errorf("Failed to calc standings"))
+		return
+	}
+	viewer := s.server.getUser(c)
+	if notModified(c, makeETag(standings.ModifiedAt, viewer.ID), standings.ModifiedAt) {
+		return
+	}
+	standings = visibleStandings(viewer, standings)
+
+	c.JSON(http.StatusOK, &api.StandingsResponse{
+		Status: api.Status{
+			Ok: true,
+		},
+		Standings: standings,
+	})
+}
+
+func makeScoresHistory(snapshots []models.ScoreSnapshot) []*api.ScoresHistory {
+	histories := make([]*api.ScoresHistory, 0)
+	byLogin := make(map[string]*api.ScoresHistory)
+	for _, snapshot := range snapshots {
+		history, found := byLogin[snapshot.GitlabLogin]
+		if !found {
+			history = &api.ScoresHistory{
+				Login:    snapshot.GitlabLogin,
+				Subgroup: snapshot.Subgroup,
+				Points:   make([]api.ScorePoint, 0),
+			}
+			byLogin[snapshot.GitlabLogin] = history
+			histories = append(histories, history)
+		}
+		history.Points = append(history.Points, api.ScorePoint{
+			Time:     snapshot.CreatedAt,
+			Score:    snapshot.Score,
+			MaxScore: snapshot.MaxScore,
+		})
+	}
+	return histories
+}
+
+func (s apiService) scoresHistory(c *gin.Context) {
+	s.log.Info("Handling scores history request")
+	onError := func(code int, err error) {
+		s.log.Warn("Failed to create scores history", zap.Error(err))
+		c.JSON(code, &api.ScoresHistoryResponse{
+			Status: api.Status{
+				Ok:    false,
+				Error: err.Error(),
+			}},
+		)
+	}
+
+	req := api.ScoresHistoryRequest{}
+	if err := c.Bind(&req); err != nil {
+		onError(http.StatusBadRequest, fmt.Errorf("Failed to parse request: %w", err))
+		return
+	}
+	viewer := s.server.getUser(c)
+	if req.Login == "" {
+		req.Login = *viewer.GitlabLogin
+	}
+	if req.Login != *viewer.GitlabLogin && !viewer.IsAdmin {
+		onError(http.StatusForbidden, fmt.Errorf("Scores history of other users is available to admins only"))
+		return
+	}
+
+	snapshots, err := s.snapshots.ListUserScoreSnapshots(req.Login, req.Since)
+	if err != nil {
+		s.log.Error("Failed to list score snapshots", lf.GitlabLogin(req.Login), zap.Error(err))
+		onError(http.StatusInternalServerError, fmt.Errorf("Failed to list score snapshots"))
+		return
+	}
+
+	history := &api.ScoresHistory{Login: req.Login, Points: make([]api.ScorePoint, 0)}
+	if histories := makeScoresHistory(snapshots); len(histories) > 0 {
+		history = histories[0]
+	}
+	c.JSON(http.StatusOK, &api.ScoresHistoryResponse{
+		Status: api.Status{
+			Ok: true,
+		},
+		History: history,
+	})
+}
+
+func (s apiService) standingsHistory(c *gin.Context) {
+	s.log.Info("Handling standings history request")
+	onError := func(code int, err error) {
+		s.log.Warn("Failed to create standings history", zap.Error(err))
+		c.JSON(code, &api.StandingsHistoryResponse{
+			Status: api.Status{
+				Ok:    false,
+				Error: err.Error(),
+			}},
+		)
+	}
+
+	req := api.StandingsHistoryRequest{}
+	if err := c.Bind(&req); err != nil {
+		onError(http.StatusBadRequest, fmt.Errorf("Failed to parse request: %w", err))
+		return
+	}
+	if req.Group == "" {
+		req.Group = s.server.getUser(c).GroupName
+	}
+
+	snapshots, err := s.snapshots.ListGroupScoreSnapshots(req.Group, req.Subgroup, req.Since)
+	if err != nil {
+		s.log.Error("Failed to list score snapshots", zap.String("group", req.Group), zap.String("subgroup", req.Subgroup), zap.Error(err))
+		onError(http.StatusInternalServerError, fmt.Errorf("Failed to list score snapshots"))
+		return
+	}
+
+	c.JSON(http.StatusOK, &api.StandingsHistoryResponse{
+		Status: api.Status{
+			Ok: true,
+		},
+		Users: makeScoresHistory(snapshots),
+	})
+}
+
+func (s apiService) webhook(c *gin.Context) {
+	eventType := gogitlab.HookEventType(c.Request)
+	log := s.log.With(zap.String("event_type", string(eventType)))
+	log.Info("Handling gitlab webhook")
+	onError := func(code int, err error) {
+		log.Warn("Failed to process gitlab webhook", zap.Error(err))
+		c.JSON(code, &api.Status{
+			Ok:    false,
+			Error: err.Error(),
+		})
+	}
+
+	secret := s.config.GitLab.Webhook.Secret
+	if secret == "" {
+		onError(http.StatusForbidden, fmt.Errorf("Webhooks are disabled"))
+		return
+	}
+	token := c.GetHeader("X-Gitlab-Token")
+	if subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+		onError(http.StatusUnauthorized, fmt.Errorf("Invalid webhook token"))
+		return
+	}
+
+	payload, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		onError(http.StatusBadRequest, fmt.Errorf("Failed to read request body: %w", err))
+		return
+	}
+
+	event, err := gogitlab.ParseWebhook(eventType, payload)
+	if err != nil {
+		onError(http.StatusBadRequest, fmt.Errorf("Failed to parse webhook: %w", err))
+		return
+	}
+
+	// GitLab retries failed webhooks, so only storage failures are reported as errors
+	switch event := event.(type) {
+	case *gogitlab.PipelineEvent:
+		err = s.server.pipelines.HandlePipelineEvent(event)
+	case *gogitlab.MergeEvent:
+		err = s.server.mrs.HandleMergeEvent(event)
+	case *gogitlab.MergeCommentEvent:
+		err = s.server.mrs.HandleMergeCommentEvent(event)
+	case *gogitlab.PushEvent:
+		err = s.server.mrs.HandlePushEvent(event)
+	default:
+		err = &gitlab.IgnoredEvent{Reason: "unsupported event type"}
+	}
+	if gitlab.IsIgnoredEvent(err) {
+		log.Info("Ignoring gitlab webhook", zap.Error(err))
+		c.JSON(http.StatusOK, &api.WebhookResponse{
+			Status:  api.Status{Ok: true},
+			Ignored: err.Error(),
+		})
+		return
+	}
+	if err != nil {
+		onError(http.StatusInternalServerError, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, &api.WebhookResponse{
+		Status: api.Status{
+			Ok: true,
+		},
+	})
+}
@@ -0,0 +1,216 @@
+package web
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/bigredeye/notmanytask/api"
+	"github.com/bigredeye/notmanytask/internal/models"
+)
+
+// fakeSnapshots filters the snapshots like the database does and records queries
+type fakeSnapshots struct {
+	snapshots []models.ScoreSnapshot
+	err       error
+
+	queries []string
+	since   []time.Time
+}
+
+func (f *fakeSnapshots) ListUserScoreSnapshots(gitlabLogin string, since time.Time) ([]models.ScoreSnapshot, error) {
+	f.queries = append(f.queries, "login="+gitlabLogin)
+	f.since = append(f.since, since)
+	if f.err != nil {
+		return nil, f.err
+	}
+	res := make([]models.ScoreSnapshot, 0)
+	for _, snapshot := range f.snapshots {
+		if snapshot.GitlabLogin == gitlabLogin && !snapshot.CreatedAt.Before(since) {
+			res = append(res, snapshot)
+		}
+	}
+	return res, nil
+}
+
+func (f *fakeSnapshots) ListGroupScoreSnapshots(groupName string, subgroupName string, since time.Time) ([]models.ScoreSnapshot, error) {
+	f.queries = append(f.queries, "group="+groupName+"/"+subgroupName)
+	f.since = append(f.since, since)
+	if f.err != nil {
+		return nil, f.err
+	}
+	res := make([]models.ScoreSnapshot, 0)
+	for _, snapshot := range f.snapshots {
+		if snapshot.GroupName == groupName && (subgroupName == "" || snapshot.Subgroup == subgroupName) && !snapshot.CreatedAt.Before(since) {
+			res = append(res, snapshot)
+		}
+	}
+	return res, nil
+}
+
+func mustParseDay(value string) time.Time {
+	t, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+var testSnapshots = []models.ScoreSnapshot{
+	{GitlabLogin: "neil", GroupName: "apollo", Subgroup: "11", Score: 0, MaxScore: 100, CreatedAt: mustParseDay("1969-07-16")},
+	{GitlabLogin: "buzz", GroupName: "apollo", Subgroup: "12", Score: 10, MaxScore: 100, CreatedAt: mustParseDay("1969-07-16")},
+	{GitlabLogin: "neil", GroupName: "apollo", Subgroup: "11", Score: 100, MaxScore: 100, CreatedAt: mustParseDay("1969-07-20")},
+}
+
+func TestMakeScoresHistory(t *testing.T) {
+	expected := []*api.ScoresHistory{
+		{Login: "neil", Subgroup: "11", Points: []api.ScorePoint{
+			{Time: mustParseDay("1969-07-16"), Score: 0, MaxScore: 100},
+			{Time: mustParseDay("1969-07-20"), Score: 100, MaxScore: 100},
+		}},
+		{Login: "buzz", Subgroup: "12", Points: []api.ScorePoint{
+			{Time: mustParseDay("1969-07-16"), Score: 10, MaxScore: 100},
+		}},
+	}
+	if histories := makeScoresHistory(testSnapshots); !reflect.DeepEqual(histories, expected) {
+		t.Errorf("Unexpected history %+v, expected: %+v", histories, expected)
+	}
+
+	if histories := makeScoresHistory(nil); histories == nil || len(histories) != 0 {
+		t.Errorf("Unexpected history of no snapshots: %+v", histories)
+	}
+}
+
+func serveHistory(t *testing.T, handler func(s apiService) gin.HandlerFunc, storage *fakeSnapshots, query string, response interface{}) int {
+	return serveHistoryAs(t, false, handler, storage, query, response)
+}
+
+func serveHistoryAs(t *testing.T, admin bool, handler func(s apiService) gin.HandlerFunc, storage *fakeSnapshots, query string, response interface{}) int {
+	gin.SetMode(gin.TestMode)
+	login := "neil"
+	viewer := &models.User{GroupName: "apollo", IsAdmin: admin}
+	viewer.GitlabLogin = &login
+	s := apiService{webService{server: &server{}, log: zap.NewNop()}, storage}
+
+	r := gin.New()
+	r.GET("/history", func(c *gin.Context) {
+		c.Set("user", viewer)
+	}, handler(s))
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/history"+query, nil))
+	if err := json.Unmarshal(w.Body.Bytes(), response); err != nil {
+		t.Fatalf("Failed to parse response %q: %v", w.Body.String(), err)
+	}
+	return w.Code
+}
+
+func TestScoresHistory(t *testing.T) {
+	handler := func(s apiService) gin.HandlerFunc { return s.scoresHistory }
+
+	for _, tc := range []struct {
+		query    string
+		admin    bool
+		expected api.ScoresHistory
+		since    time.Time
+	}{
+		{
+			query:    "",
+			expected: api.ScoresHistory{Login: "neil", Subgroup: "11", Points: []api.ScorePoint{{Time: mustParseDay("1969-07-16"), MaxScore: 100}, {Time: mustParseDay("1969-07-20"), Score: 100, MaxScore: 100}}},
+		},
+		{
+			query:    "?login=neil",
+			expected: api.ScoresHistory{Login: "neil", Subgroup: "11", Points: []api.ScorePoint{{Time: mustParseDay("1969-07-16"), MaxScore: 100}, {Time: mustParseDay("1969-07-20"), Score: 100, MaxScore: 100}}},
+		},
+		{
+			query:    "?login=buzz",
+			admin:    true,
+			expected: api.ScoresHistory{Login: "buzz", Subgroup: "12", Points: []api.ScorePoint{{Time: mustParseDay("1969-07-16"), Score: 10, MaxScore: 100}}},
+		},
+		{
+			query:    "?since=1969-07-17",
+			expected: api.ScoresHistory{Login: "neil", Subgroup: "11", Points: []api.ScorePoint{{Time: mustParseDay("1969-07-20"), Score: 100, MaxScore: 100}}},
+			since:    mustParseDay("1969-07-17"),
+		},
+		{
+			query:    "?login=mike",
+			admin:    true,
+			expected: api.ScoresHistory{Login: "mike", Points: []api.ScorePoint{}},
+		},
+	} {
+		storage := &fakeSnapshots{snapshots: testSnapshots}
+		var response api.ScoresHistoryResponse
+		if code := serveHistoryAs(t, tc.admin, handler, storage, tc.query, &response); code != http.StatusOK || !response.Ok {
+			t.Errorf("%q: unexpected response %d %+v", tc.query, code, response)
+			continue
+		}
+		if !reflect.DeepEqual(response.History, &tc.expected) {
+			t.Errorf("%q: unexpected history %+v, expected: %+v", tc.query, response.History, tc.expected)
+		}
+		if !storage.since[0].Equal(tc.since) {
+			t.Errorf("%q: unexpected since %v, expected: %v", tc.query, storage.since[0], tc.since)
+		}
+	}
+
+	var response api.ScoresHistoryResponse
+	code := serveHistory(t, handler, &fakeSnapshots{err: errors.New("connection refused")}, "", &response)
+	if code != http.StatusInternalServerError || response.Ok || response.History != nil {
+		t.Errorf("Storage error is not reported: %d %+v", code, response)
+	}
+
+	storage := &fakeSnapshots{snapshots: testSnapshots}
+	response = api.ScoresHistoryResponse{}
+	code = serveHistory(t, handler, storage, "?login=buzz", &response)
+	if code != http.StatusForbidden || response.Ok || response.History != nil || len(storage.queries) != 0 {
+		t.Errorf("History of another student is available to non-admin: %d %+v", code, response)
+	}
+
+	code = serveHistory(t, handler, &fakeSnapshots{}, "?since=yesterday", &response)
+	if code != http.StatusBadRequest || response.Ok {
+		t.Errorf("Invalid request is accepted: %d %+v", code, response)
+	}
+}
+
+func TestStandingsHistory(t *testing.T) {
+	handler := func(s apiService) gin.HandlerFunc { return s.standingsHistory }
+
+	for _, tc := range []struct {
+		query    string
+		logins   []string
+		expected string
+	}{
+		{query: "", logins: []string{"neil", "buzz"}, expected: "group=apollo/"},
+		{query: "?subgroup=12", logins: []string{"buzz"}, expected: "group=apollo/12"},
+		{query: "?group=gemini", logins: []string{}, expected: "group=gemini/"},
+	} {
+		storage := &fakeSnapshots{snapshots: testSnapshots}
+		var response api.StandingsHistoryResponse
+		if code := serveHistory(t, handler, storage, tc.query, &response); code != http.StatusOK || !response.Ok {
+			t.Errorf("%q: unexpected response %d %+v", tc.query, code, response)
+			continue
+		}
+		if len(storage.queries) != 1 || storage.queries[0] != tc.expected {
+			t.Errorf("%q: unexpected queries %v, expected: %s", tc.query, storage.queries, tc.expected)
+		}
+		logins := make([]string, 0)
+		for _, user := range response.Users {
+			logins = append(logins, user.Login)
+		}
+		if !reflect.DeepEqual(logins, tc.logins) {
+			t.Errorf("%q: unexpected users %v, expected: %v", tc.query, logins, tc.logins)
+		}
+	}
+
+	var response api.StandingsHistoryResponse
+	code := serveHistory(t, handler, &fakeSnapshots{err: errors.New("connection refused")}, "", &response)
+	if code != http.StatusInternalServerError || response.Ok || response.Users != nil {
+		t.Errorf("Storage error is not reported: %d %+v", code, response)
+	}
+}
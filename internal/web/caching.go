@@ -0,0 +1,53 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// makeETag tags a response computed at modifiedAt for the viewer,
+// pages of different users must not match each other
+func makeETag(modifiedAt time.Time, viewer uint) string {
+	return fmt.Sprintf(`W/"%x-%d"`, modifiedAt.UnixNano(), viewer)
+}
+
+// notModified sets cache validators and responds with 304 if the client copy is fresh.
+// Responses without modification time are not validated.
+func notModified(c *gin.Context, etag string, modifiedAt time.Time) bool {
+	if modifiedAt.IsZero() {
+		return false
+	}
+
+	c.Header("Cache-Control", "private, no-cache")
+	c.Header("ETag", etag)
+	c.Header("Last-Modified", modifiedAt.UTC().Format(http.TimeFormat))
+
+	// If-None-Match takes precedence over If-Modified-Since, see RFC 7232
+	if match := c.GetHeader("If-None-Match"); match != "" {
+		if !etagMatches(match, etag) {
+			return false
+		}
+	} else {
+		since, err := http.ParseTime(c.GetHeader("If-Modified-Since"))
+		if err != nil || modifiedAt.Truncate(time.Second).After(since) {
+			return false
+		}
+	}
+
+	c.Status(http.StatusNotModified)
+	return true
+}
+
+func etagMatches(header string, etag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,172 @@
+package web
+
+import (
+	"bytes"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/bigredeye/notmanytask/internal/deadlines"
+	lf "github.com/bigredeye/notmanytask/internal/logfield"
+	"github.com/bigredeye/notmanytask/internal/models"
+)
+
+const calendarContentType = "text/calendar; charset=utf-8"
+
+func (s *server) makeFeedUrl(endpoint string, token string) string {
+	return s.config.Endpoints.HostName + endpoint + "?" + url.Values{"token": {token}}.Encode()
+}
+
+// makeWebcalUrl makes the link opening subscription dialog of calendar apps
+func makeWebcalUrl(feedUrl string) string {
+	for _, scheme := range []string{"https://", "http://"} {
+		if strings.HasPrefix(feedUrl, scheme) {
+			return "webcal://" + strings.TrimPrefix(feedUrl, scheme)
+		}
+	}
+	return feedUrl
+}
+
+func (s *server) RenderCalendarPage(c *gin.Context) {
+	user := s.getUser(c)
+	params := gin.H{
+		"CourseName": s.config.Title,
+		"Title":      s.config.Title,
+		"Config":     s.config,
+		"Links":      s.makeLinks(user),
+		"Token":      user.FeedToken,
+	}
+	if user.FeedToken != nil {
+		feedUrl := s.makeFeedUrl(s.config.Endpoints.CalendarFeed, *user.FeedToken)
+		groupFeedUrl := s.makeFeedUrl(s.config.Endpoints.GroupCalendarFeed, *user.FeedToken)
+		params["FeedUrl"] = feedUrl
+		params["WebcalUrl"] = makeWebcalUrl(feedUrl)
+		params["GroupFeedUrl"] = groupFeedUrl
+		params["GroupWebcalUrl"] = makeWebcalUrl(groupFeedUrl)
+	}
+	c.HTML(http.StatusOK, "/calendar.tmpl", params)
+}
+
+func (s *server) handleFeedTokenReset(c *gin.Context) {
+	user := s.getUser(c)
+	token, err := s.db.ResetUserFeedToken(user.ID)
+	if err != nil {
+		s.logger.Error("Failed to reset feed token", zap.Error(err), lf.UserID(user.ID))
+		c.Redirect(http.StatusFound, s.config.Endpoints.Calendar)
+		return
+	}
+	s.logger.Info("Reset feed token", lf.UserID(user.ID))
+
+	user.FeedToken = &token
+	s.RenderCalendarPage(c)
+}
+
+// findFeedUser authenticates calendar apps by the token in the feed url
+func (s *server) findFeedUser(c *gin.Context) *models.User {
+	token := c.Query("token")
+	if token == "" {
+		c.String(http.StatusUnauthorized, "Feed token is required")
+		return nil
+	}
+	user, err := s.db.FindUserByFeedToken(token)
+	if err != nil {
+		s.logger.Warn("Unknown feed token", zap.Error(err))
+		c.String(http.StatusUnauthorized, "Invalid feed token")
+		return nil
+	}
+	return user
+}
+
+func (s *server) handleCalendarFeed(c *gin.Context) {
+	user := s.findFeedUser(c)
+	if user == nil {
+		return
+	}
+
+	extensions, err := s.db.ListUserExtensions(user.ID)
+	if err != nil {
+		s.logger.Error("Failed to list extensions", zap.Error(err), lf.UserID(user.ID))
+		c.String(http.StatusInternalServerError, "Failed to list extensions")
+		return
+	}
+
+	params := s.makeCalendarParams(user, user.GroupName)
+	params.Name = s.config.Title + " deadlines"
+	params.GroupExtensions = make(map[string]time.Time)
+	params.TaskExtensions = make(map[string]time.Time)
+	for _, extension := range extensions {
+		if extension.Exempt {
+			continue
+		}
+		if extension.TaskGroup != "" {
+			params.GroupExtensions[extension.TaskGroup] = extension.Deadline
+		} else {
+			params.TaskExtensions[extension.Task] = extension.Deadline
+		}
+	}
+	s.writeCalendar(c, user.GroupName, params)
+}
+
+func (s *server) handleGroupCalendarFeed(c *gin.Context) {
+	user := s.findFeedUser(c)
+	if user == nil {
+		return
+	}
+
+	group := c.DefaultQuery("group", user.GroupName)
+	if group != user.GroupName && !user.IsAdmin {
+		c.String(http.StatusForbidden, "Deadlines of other groups are available to admins only")
+		return
+	}
+
+	params := s.makeCalendarParams(user, group)
+	params.Name = s.config.Title + " " + group + " deadlines"
+	s.writeCalendar(c, group, params)
+}
+
+func (s *server) makeCalendarParams(viewer *models.User, group string) deadlines.CalendarParams {
+	domain := "notmanytask"
+	if u, err := url.Parse(s.config.Endpoints.HostName); err == nil && u.Hostname() != "" {
+		domain = u.Hostname()
+	}
+	namespace := group
+	if s.config.Course != "" {
+		namespace = s.config.Course + "-" + group
+	}
+
+	now := time.Now()
+	params := deadlines.CalendarParams{
+		Domain:    domain,
+		Namespace: namespace,
+		URL:       s.config.Endpoints.HostName + s.config.Endpoints.Home,
+		Now:       now,
+	}
+	// Events of unreleased task groups would reveal them to students
+	if !viewer.IsAdmin {
+		params.Filter = func(group *deadlines.TaskGroup) bool {
+			return group.Released(now)
+		}
+	}
+	return params
+}
+
+func (s *server) writeCalendar(c *gin.Context, group string, params deadlines.CalendarParams) {
+	groupDeadlines := s.deadlines.GroupDeadlines(group)
+	if groupDeadlines == nil {
+		c.String(http.StatusNotFound, "No deadlines found")
+		return
+	}
+
+	var buf bytes.Buffer
+	if err := deadlines.WriteCalendar(&buf, *groupDeadlines, params); err != nil {
+		s.logger.Error("Failed to write calendar", zap.Error(err), zap.String("group", group))
+		c.String(http.StatusInternalServerError, "Failed to write calendar")
+		return
+	}
+	c.Header("Cache-Control", "private, no-cache")
+	c.Data(http.StatusOK, calendarContentType, buf.Bytes())
+}
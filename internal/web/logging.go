@@ -0,0 +1,51 @@
+package web
+
+import (
+	"net/url"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Query parameters carrying credentials, e.g. tokens of calendar feeds
+var secretQueryParams = []string{"token"}
+
+// logRequests logs requests like ginzap.Ginzap but hides secrets passed in queries
+func logRequests(logger *zap.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+		query := redactQuery(c.Request.URL.RawQuery)
+		c.Next()
+
+		end := time.Now()
+		if len(c.Errors) > 0 {
+			for _, e := range c.Errors.Errors() {
+				logger.Error(e)
+			}
+			return
+		}
+		logger.Info(path,
+			zap.Int("status", c.Writer.Status()),
+			zap.String("method", c.Request.Method),
+			zap.String("path", path),
+			zap.String("query", query),
+			zap.String("ip", c.ClientIP()),
+			zap.String("user-agent", c.Request.UserAgent()),
+			zap.String("time", end.UTC().Format(time.RFC3339)),
+			zap.Duration("latency", end.Sub(start)),
+		)
+	}
+}
+
+func redactQuery(rawQuery string) string {
+	// Malformed pairs are dropped by the parser, so they are not logged either
+	query, _ := url.ParseQuery(rawQuery)
+	for _, param := range secretQueryParams {
+		if _, found := query[param]; found {
+			query.Set(param, "REDACTED")
+		}
+	}
+	return query.Encode()
+}
@@ -0,0 +1,18 @@
+package web
+
+import "testing"
+
+func TestRedactQuery(t *testing.T) {
+	for query, expected := range map[string]string{
+		"":                         "",
+		"group=apollo":             "group=apollo",
+		"token=secret":             "token=REDACTED",
+		"group=apollo&token=s%3Bc": "group=apollo&token=REDACTED",
+		"token=a&token=b":          "token=REDACTED",
+		"token=secret;group=x":     "",
+	} {
+		if redacted := redactQuery(query); redacted != expected {
+			t.Errorf("Unexpected redacted query of %q: %q, expected: %q", query, redacted, expected)
+		}
+	}
+}
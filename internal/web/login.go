@@ -3,6 +3,7 @@ package web
 import (
 	"context"
 	"encoding/hex"
+	"errors"
 	"net/http"
 	"regexp"
 	"strings"
@@ -15,6 +16,8 @@ import (
 	perrors "github.com/pkg/errors"
 	"go.uber.org/zap"
 
+	"github.com/bigredeye/notmanytask/api"
+	"github.com/bigredeye/notmanytask/internal/config"
 	"github.com/bigredeye/notmanytask/internal/database"
 	"github.com/bigredeye/notmanytask/internal/gitlab"
 	lf "github.com/bigredeye/notmanytask/internal/logfield"
@@ -44,7 +47,7 @@ func setupLoginService(server *server, r *gin.Engine) error {
 
 func (s loginService) signup(c *gin.Context) {
 	c.HTML(http.StatusOK, "/signup.tmpl", gin.H{
-		"CourseName": "HSE Basic C++",
+		"CourseName": s.config.Title,
 		"Config":     s.config,
 	})
 }
@@ -139,7 +142,7 @@ func (s loginService) login(c *gin.Context) {
 	session := sessions.Default(c)
 
 	oauthState := uuid.New().String()
-	session.Set(sessionKeyOAuth, oauthState)
+	session.Set(s.server.sessionKey(sessionKeyOAuth), oauthState)
 	err := session.Save()
 	if err != nil {
 		s.log.Error("Failed to save session", zap.Error(err))
@@ -153,7 +156,7 @@ func (s loginService) oauth(c *gin.Context) {
 	// Compare oauth state in query and cookie
 	oauthState := c.Query("state")
 	storage := sessions.Default(c)
-	if v := storage.Get(sessionKeyOAuth); v == nil || v != oauthState {
+	if v := storage.Get(s.server.sessionKey(sessionKeyOAuth)); v == nil || v != oauthState {
 		if v == nil {
 			s.log.Info("No oauth state found")
 		} else {
@@ -199,6 +202,8 @@ func (s loginService) oauth(c *gin.Context) {
 		}
 	}
 
+	s.syncAdminRole(user, gitlabUser.Login)
+
 	if user.GitlabLogin != nil && user.GitlabID != nil {
 		if err = s.fillSessionForUser(c, user); err != nil {
 			s.log.Error("Failed to create session", zap.Error(err), zap.Int("gitlab_id", gitlabUser.ID))
@@ -237,16 +242,36 @@ func (s loginService) oauth(c *gin.Context) {
 	c.Redirect(http.StatusTemporaryRedirect, s.config.Endpoints.Home)
 }
 
+// syncAdminRole keeps admin role of the user in sync with the config
+func (s loginService) syncAdminRole(user *models.User, gitlabLogin string) {
+	isAdmin := s.config.IsAdmin(gitlabLogin)
+	if user.IsAdmin == isAdmin {
+		return
+	}
+
+	if err := s.server.db.SetUserAdmin(user.ID, isAdmin); err != nil {
+		s.log.Error("Failed to change admin role", zap.Error(err), lf.UserID(user.ID), lf.GitlabLogin(gitlabLogin), zap.Bool("admin", isAdmin))
+		return
+	}
+	user.IsAdmin = isAdmin
+	s.log.Info("Changed admin role", lf.UserID(user.ID), lf.GitlabLogin(gitlabLogin), zap.Bool("admin", isAdmin))
+}
+
 func (s loginService) logout(c *gin.Context) {
+	if token := s.server.tryGetToken(c); token != nil {
+		if err := s.server.db.DeleteSessionByToken(*token); err != nil {
+			s.log.Error("Failed to delete session", zap.Error(err))
+		}
+	}
 	s.RedirectToSignup(c, "")
 }
 
-func setupAuth(s *server, r *gin.Engine) error {
-	authKey, err := hex.DecodeString(s.config.Server.Cookies.AuthenticationKey)
+func setupAuth(config *config.Config, r *gin.Engine) error {
+	authKey, err := hex.DecodeString(config.Server.Cookies.AuthenticationKey)
 	if err != nil {
 		return perrors.Wrap(err, "Failed to decode hex authenticationKey")
 	}
-	encryptKey, err := hex.DecodeString(s.config.Server.Cookies.EncryptionKey)
+	encryptKey, err := hex.DecodeString(config.Server.Cookies.EncryptionKey)
 	if err != nil {
 		return perrors.Wrap(err, "Failed to decode hex encryptionKey")
 	}
@@ -260,9 +285,17 @@ func setupAuth(s *server, r *gin.Engine) error {
 	return nil
 }
 
-func tryGetToken(c *gin.Context) *string {
+// sessionKey separates cookie values of the courses sharing the host
+func (s *server) sessionKey(key string) string {
+	if s.config.Course == "" {
+		return key
+	}
+	return key + ":" + s.config.Course
+}
+
+func (s *server) tryGetToken(c *gin.Context) *string {
 	storage := sessions.Default(c)
-	v := storage.Get(sessionKeyToken)
+	v := storage.Get(s.sessionKey(sessionKeyToken))
 	if v == nil {
 		return nil
 	}
@@ -274,18 +307,31 @@ func tryGetToken(c *gin.Context) *string {
 }
 
 func (s *server) tryFindUserByToken(c *gin.Context) (*models.User, *models.Session, error) {
-	token := tryGetToken(c)
+	token := s.tryGetToken(c)
 	if token == nil {
 		s.logger.Info("No token found")
 		return nil, nil, nil
 	}
 
-	user, session, err := s.db.FindUserBySession(*token)
+	return s.findSessionUser(*token, time.Now())
+}
+
+// findSessionUser treats expired sessions as absent and deletes them
+func (s *server) findSessionUser(token string, now time.Time) (*models.User, *models.Session, error) {
+	user, session, err := s.sessions.FindUserBySession(token)
 	if err != nil {
-		s.logger.Warn("Failed to find session", zap.Error(err), zap.Stringp("token", token))
+		s.logger.Warn("Failed to find session", zap.Error(err), zap.String("token", token))
 		return nil, nil, err
 	}
 
+	if session.Expired(now) {
+		s.logger.Info("Session expired", zap.Uint("session_id", session.ID), lf.UserID(session.UserID))
+		if err = s.sessions.DeleteSessionByToken(token); err != nil {
+			s.logger.Error("Failed to delete expired session", zap.Error(err))
+		}
+		return nil, nil, nil
+	}
+
 	return user, session, nil
 }
 
@@ -300,6 +346,7 @@ func (s *server) validateSession(c *gin.Context) {
 
 	c.Set("user", user)
 	c.Set("session", session)
+	s.touchSession(c, session)
 
 	s.logger.Info("Valid session",
 		zap.Uint("session_id", session.ID),
@@ -321,14 +368,70 @@ func (s *server) validateSession(c *gin.Context) {
 	c.Next()
 }
 
+const apiTokenPrefix = "Bearer "
+
+// validateApiSession authenticates API clients either by personal token or by session cookie
+func (s *server) validateApiSession(c *gin.Context) {
+	onError := func(err error) {
+		s.logger.Warn("Failed to authenticate api request", zap.Error(err))
+		c.AbortWithStatusJSON(http.StatusUnauthorized, &api.Status{
+			Ok:    false,
+			Error: "Invalid or expired token",
+		})
+	}
+
+	var user *models.User
+	var err error
+	if header := c.GetHeader("Authorization"); strings.HasPrefix(header, apiTokenPrefix) {
+		user, err = s.db.FindUserByApiToken(strings.TrimPrefix(header, apiTokenPrefix))
+	} else {
+		var session *models.Session
+		user, session, err = s.tryFindUserByToken(c)
+		if err == nil && session == nil {
+			err = errors.New("No api token or session found")
+		}
+		if err == nil {
+			s.touchSession(c, session)
+		}
+	}
+	if err != nil {
+		onError(err)
+		return
+	}
+
+	if user.GitlabID == nil || user.GitlabLogin == nil {
+		onError(errors.New("User without gitlab account"))
+		return
+	}
+
+	c.Set("user", user)
+	c.Next()
+}
+
+func (s *server) requireAdmin(c *gin.Context) {
+	user := s.getUser(c)
+	if !user.IsAdmin {
+		s.logger.Warn("Non-admin user tried to access admin page",
+			lf.UserID(user.ID),
+			zap.Stringp("gitlab_login", user.GitlabLogin),
+			zap.String("path", c.Request.URL.Path),
+		)
+		c.Redirect(http.StatusFound, s.config.Endpoints.Home)
+		c.Abort()
+		return
+	}
+
+	c.Next()
+}
+
 func (s loginService) fillSessionForUser(c *gin.Context, user *models.User) error {
-	session, err := s.server.db.CreateSession(user.ID)
+	session, err := s.server.db.CreateSession(user.ID, c.Request.UserAgent(), c.ClientIP(), sessionTtl(s.config))
 	if err != nil {
 		return err
 	}
 
 	storage := sessions.Default(c)
-	storage.Set(sessionKeyToken, session.Token)
+	storage.Set(s.server.sessionKey(sessionKeyToken), session.Token)
 	return storage.Save()
 }
 
@@ -347,7 +450,7 @@ func (s loginService) RedirectToSignup(c *gin.Context, error string) {
 
 func (s loginService) clearSession(c *gin.Context) {
 	session := sessions.Default(c)
-	session.Set(sessionKeyToken, "")
+	session.Set(s.server.sessionKey(sessionKeyToken), "")
 	err := session.Save()
 	if err != nil {
 		s.log.Error("Failed to save session", zap.Error(err))
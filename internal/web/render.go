@@ -2,12 +2,16 @@ package web
 
 import (
 	"net/http"
+	"net/url"
 	"regexp"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
 	"go.uber.org/zap"
 
+	"github.com/bigredeye/notmanytask/internal/flags"
 	lf "github.com/bigredeye/notmanytask/internal/logfield"
 	"github.com/bigredeye/notmanytask/internal/models"
 	"github.com/bigredeye/notmanytask/internal/scorer"
@@ -15,7 +19,7 @@ import (
 
 func (s *server) RenderSignupPage(c *gin.Context, err string) {
 	c.HTML(http.StatusOK, "/signup.tmpl", gin.H{
-		"CourseName":   "HSE Basic C++",
+		"CourseName":   s.config.Title,
 		"Config":       s.config,
 		"ErrorMessage": err,
 	})
@@ -25,17 +29,39 @@ func (s *server) RenderSubmitFlagPage(c *gin.Context) {
 	s.RenderSubmitFlagPageDetails(c, "", "")
 }
 
+const (
+	defaultMaxWrongFlagAttempts    = 10
+	defaultWrongFlagAttemptsWindow = time.Hour
+
+	flagSubmissionsHistorySize = 50
+)
+
 func (s *server) RenderSubmitFlagPageDetails(c *gin.Context, err string, success string) {
 	user := c.MustGet("user").(*models.User)
+
+	submissions, listErr := s.db.ListUserFlagSubmissions(user.ID, flagSubmissionsHistorySize)
+	if listErr != nil {
+		s.logger.Error("Failed to list flag submissions", zap.Error(listErr), lf.UserID(user.ID))
+	}
+
 	c.HTML(http.StatusOK, "/flag.tmpl", gin.H{
-		"CourseName":     "HSE Basic C++",
+		"CourseName":     s.config.Title,
 		"Config":         s.config,
 		"ErrorMessage":   err,
 		"SuccessMessage": success,
 		"Links":          s.makeLinks(user),
+		"Location":       s.viewerLocation(user),
+		"Submissions":    submissions,
 	})
 }
 
+type flagsStorage interface {
+	CountWrongFlagSubmissions(user uint, since time.Time) (int64, error)
+	FindFlag(id string) (*models.Flag, error)
+	SubmitFlag(id string, gitlabLogin string) error
+	ListUsers(groupName string, subgroupName string) ([]*models.User, error)
+}
+
 var flagRe = regexp.MustCompile(`^\{FLAG(-[a-z0-9_]+)+(-[0-9a-f]+)+\}$`)
 
 func (s *server) handleFlagSubmit(c *gin.Context) {
@@ -46,28 +72,169 @@ func (s *server) handleFlagSubmit(c *gin.Context) {
 		return
 	}
 
-	flag := c.PostForm("flag")
-	if !flagRe.MatchString(flag) {
-		s.logger.Warn("Invalid flag", zap.String("flag", flag), lf.UserID(user.ID), lf.GitlabLogin(*user.GitlabLogin))
-		s.RenderSubmitFlagPageDetails(c, "Invalid flag", "")
+	submission := &models.FlagSubmission{
+		UserID:      user.ID,
+		GitlabLogin: *user.GitlabLogin,
+		Flag:        c.PostForm("flag"),
+		IP:          c.ClientIP(),
+		CreatedAt:   time.Now(),
+	}
+	log := s.logger.With(zap.String("flag", submission.Flag), lf.UserID(user.ID), lf.GitlabLogin(*user.GitlabLogin))
+
+	errorMessage := s.submitFlag(log, submission)
+	if err := s.db.AddFlagSubmission(submission); err != nil {
+		log.Error("Failed to save flag submission", zap.Error(err))
+	}
+
+	if errorMessage != "" {
+		log.Warn("Rejected flag", zap.String("outcome", submission.Outcome))
+		s.RenderSubmitFlagPageDetails(c, errorMessage, "")
 		return
 	}
 
-	err := s.db.SubmitFlag(flag, *user.GitlabLogin)
+	s.RenderSubmitFlagPageDetails(c, "", "The matrix has you...")
+}
+
+// submitFlag fills submission outcome and returns error message for the user
+func (s *server) submitFlag(log *zap.Logger, submission *models.FlagSubmission) string {
+	login := submission.GitlabLogin
+
+	maxAttempts := s.config.Flags.MaxWrongAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultMaxWrongFlagAttempts
+	}
+	window := s.config.Flags.WrongAttemptsWindow
+	if window <= 0 {
+		window = defaultWrongFlagAttemptsWindow
+	}
+	attempts, err := s.flagsDB.CountWrongFlagSubmissions(submission.UserID, submission.CreatedAt.Add(-window))
 	if err != nil {
-		s.RenderSubmitFlagPageDetails(c, "Unknown flag", "")
+		log.Error("Failed to count wrong flag submissions", zap.Error(err))
+	} else if attempts >= int64(maxAttempts) {
+		submission.Outcome = models.FlagSubmissionRateLimited
+		return "Too many wrong flags, try again later"
+	}
+
+	flag := submission.Flag
+	if !flagRe.MatchString(flag) {
+		submission.Outcome = models.FlagSubmissionInvalid
+		return "Invalid flag"
+	}
+
+	if signed, err := flags.Parse(flag); err == nil {
+		submission.Task = signed.Task
+	}
+
+	stored, err := s.flagsDB.FindFlag(flag)
+	if err != nil {
+		log.Error("Failed to find flag", zap.Error(err))
+	}
+	if stored != nil {
+		submission.Task = stored.Task
+		submission.FlagOwner = stored.Owner
+		if submission.FlagOwner == nil {
+			submission.FlagOwner = stored.GitlabLogin
+		}
+
+		if stored.GitlabLogin != nil {
+			if *stored.GitlabLogin == login {
+				submission.Outcome = models.FlagSubmissionDuplicate
+				return "You have already submitted this flag"
+			}
+			submission.Outcome = models.FlagSubmissionForeign
+			return "This flag was already submitted by another student"
+		}
+	}
+
+	if flags.IsSigned(flag) && s.flags != nil {
+		if _, err := s.flags.Verify(flag, login); err != nil {
+			submission.Outcome = models.FlagSubmissionForeign
+			if submission.FlagOwner == nil {
+				submission.FlagOwner = s.findFlagOwner(log, flag)
+			}
+			return "This flag was issued for another student"
+		}
+	}
+
+	if err = s.flagsDB.SubmitFlag(flag, login); err != nil {
+		submission.Outcome = models.FlagSubmissionUnknown
+		return "Unknown flag"
+	}
+
+	submission.Outcome = models.FlagSubmissionAccepted
+	return ""
+}
+
+// findFlagOwner recovers the student of the signed flag missing in the database by trying every login
+func (s *server) findFlagOwner(log *zap.Logger, flag string) *string {
+	users, err := s.flagsDB.ListUsers("", "")
+	if err != nil {
+		log.Error("Failed to list users", zap.Error(err))
+		return nil
+	}
+	for _, user := range users {
+		if user.GitlabLogin == nil {
+			continue
+		}
+		if _, err := s.flags.Verify(flag, *user.GitlabLogin); err == nil {
+			return user.GitlabLogin
+		}
+	}
+	return nil
+}
+
+func (s *server) RenderApiTokenPage(c *gin.Context) {
+	user := s.getUser(c)
+	c.HTML(http.StatusOK, "/token.tmpl", gin.H{
+		"CourseName": s.config.Title,
+		"Title":      s.config.Title,
+		"Config":     s.config,
+		"Token":      user.ApiToken,
+		"Links":      s.makeLinks(user),
+	})
+}
+
+func (s *server) handleApiTokenReset(c *gin.Context) {
+	user := s.getUser(c)
+	token, err := s.db.ResetUserApiToken(user.ID)
+	if err != nil {
+		s.logger.Error("Failed to reset api token", zap.Error(err), lf.UserID(user.ID))
+		c.Redirect(http.StatusFound, s.config.Endpoints.ApiToken)
 		return
 	}
+	s.logger.Info("Reset api token", lf.UserID(user.ID), lf.GitlabLogin(*user.GitlabLogin))
 
-	s.RenderSubmitFlagPageDetails(c, "", "The matrix has you...")
-	return
+	user.ApiToken = &token
+	s.RenderApiTokenPage(c)
 }
 
-func (s *server) handleChuckNorris(c *gin.Context) {
+func handleChuckNorris(c *gin.Context) {
 	c.Redirect(http.StatusTemporaryRedirect, "https://youtu.be/dQw4w9WgXcQ")
 	return
 }
 
+// visibleScores hides task groups which have not started yet from students
+func visibleScores(viewer *models.User, scores *scorer.UserScores) *scorer.UserScores {
+	if viewer.IsAdmin {
+		return scores
+	}
+	return scores.Released()
+}
+
+// visibleStandings also hides override comments and extension reasons of other students
+func visibleStandings(viewer *models.User, standings *scorer.Standings) *scorer.Standings {
+	if viewer.IsAdmin {
+		return standings
+	}
+	standings = standings.Released(time.Now())
+	for i, user := range standings.Users {
+		if viewer.GitlabLogin == nil || user.User.GitlabLogin != *viewer.GitlabLogin {
+			standings.Users[i] = user.WithoutNotes()
+		}
+	}
+	return standings
+}
+
 func reverseScores(scores *scorer.UserScores) {
 	groups := scores.Groups
 	for i, j := 0, len(groups)-1; i < j; i, j = i+1, j-1 {
@@ -83,6 +250,10 @@ type Links struct {
 	Submits         string
 	Logout          string
 	SubmitFlag      string
+	ApiToken        string
+	Calendar        string
+	Sessions        string
+	Admin           string
 }
 
 type GroupLink struct {
@@ -101,7 +272,25 @@ func (s *server) makeLinks(user *models.User) Links {
 		Submits:         s.gitlab.MakeProjectSubmitsUrl(user),
 		Logout:          s.config.Endpoints.Logout,
 		SubmitFlag:      s.config.Endpoints.Flag,
+		ApiToken:        s.config.Endpoints.ApiToken,
+		Calendar:        s.config.Endpoints.Calendar,
+		Sessions:        s.config.Endpoints.Sessions,
+		Admin:           s.makeAdminLink(user),
+	}
+}
+
+func (s *server) makeAdminLink(user *models.User) string {
+	if !user.IsAdmin {
+		return ""
 	}
+	return s.config.Endpoints.Admin.Users
+}
+
+// makeImpersonatedLinks makes links as seen by user, keeping admin section reachable
+func (s *server) makeImpersonatedLinks(admin *models.User, user *models.User) Links {
+	links := s.makeLinks(user)
+	links.Admin = s.makeAdminLink(admin)
+	return links
 }
 
 func (s *server) makeGroupLinks() GroupLinks {
@@ -122,34 +311,90 @@ func (s *server) makeGroupLink(g string) string {
 func (s *server) RenderHomePage(c *gin.Context) {
 	user := c.MustGet("user").(*models.User)
 	scores, err := s.scorer.CalcUserScores(user)
-	reverseScores(scores)
+	if scores != nil {
+		scores = visibleScores(user, scores)
+		reverseScores(scores)
+	}
 
 	c.HTML(http.StatusOK, "/home.tmpl", gin.H{
 		// FIXME(BigRedEye): Do not hardcode title
-		"CourseName": "HSE Basic C++",
-		"Title":      "HSE Basic C++",
+		"CourseName": s.config.Title,
+		"Title":      s.config.Title,
 		"Config":     s.config,
 		"Scores":     scores,
 		"Error":      err,
 		"Links":      s.makeLinks(user),
+		"Location":   s.viewerLocation(user),
+		"HistoryUrl": s.config.Endpoints.Api.ScoresHistory,
 	})
 }
 
 func (s *server) RenderCheaterPage(c *gin.Context) {
+	admin := s.getUser(c)
 	user, err := s.db.FindUserByGitlabLogin(c.Query("login"))
-	var scores *scorer.UserScores
-	if err == nil {
-		scores, err = s.scorer.CalcUserScores(user)
+	if err != nil {
+		s.logger.Warn("Failed to find user", zap.Error(err), lf.GitlabLogin(c.Query("login")))
+		s.RenderAdminUsersPageDetails(c, "Unknown user")
+		return
+	}
+
+	scores, err := s.scorer.CalcUserScores(user)
+	if scores != nil {
+		reverseScores(scores)
+	}
+
+	extensions, extensionsErr := s.db.ListUserExtensions(user.ID)
+	if extensionsErr != nil {
+		s.logger.Error("Failed to list user extensions", zap.Error(extensionsErr), lf.UserID(user.ID))
 	}
-	reverseScores(scores)
 
 	c.HTML(http.StatusOK, "/home.tmpl", gin.H{
-		"CourseName": "HSE Basic C++",
-		"Title":      "HSE Basic C++",
+		"CourseName":      s.config.Title,
+		"Title":           s.config.Title,
+		"Config":          s.config,
+		"Scores":          scores,
+		"Error":           err,
+		"Links":           s.makeImpersonatedLinks(admin, user),
+		"Location":        s.viewerLocation(admin),
+		"Student":         user,
+		"HistoryUrl":      s.userHistoryUrl(user),
+		"Extensions":      extensions,
+		"CanSyncTemplate": s.config.ProjectFor(user.GroupName).ForkFrom != 0,
+	})
+}
+
+// RenderTaskPage shows all attempts of the task, admins may look at any student with login param
+func (s *server) RenderTaskPage(c *gin.Context) {
+	viewer := s.getUser(c)
+	user := viewer
+	if login := c.Query("login"); login != "" && viewer.IsAdmin {
+		student, err := s.db.FindUserByGitlabLogin(login)
+		if err != nil {
+			s.logger.Warn("Failed to find user", zap.Error(err), lf.GitlabLogin(login))
+			s.RenderAdminUsersPageDetails(c, "Unknown user")
+			return
+		}
+		user = student
+	}
+
+	task := c.Query("task")
+	timeline, err := s.scorer.CalcTaskTimeline(user, task)
+	if err == nil && !timeline.Released && !viewer.IsAdmin {
+		timeline, err = nil, errors.Errorf("Unknown task %s", task)
+	}
+	if err != nil {
+		s.logger.Warn("Failed to build task timeline", zap.Error(err), lf.UserID(user.ID), zap.String("task", task))
+	}
+
+	c.HTML(http.StatusOK, "/task.tmpl", gin.H{
+		"CourseName": s.config.Title,
+		"Title":      s.config.Title,
 		"Config":     s.config,
-		"Scores":     scores,
+		"Links":      s.makeImpersonatedLinks(viewer, user),
+		"Location":   s.viewerLocation(viewer),
+		"TaskName":   task,
+		"Timeline":   timeline,
 		"Error":      err,
-		"Links":      s.makeLinks(user),
 	})
 }
 
@@ -161,41 +406,75 @@ func (s *server) RedirectToStandingsPage(c *gin.Context) {
 func (s *server) RenderStandingsPage(c *gin.Context) {
 	user := c.MustGet("user").(*models.User)
 	scores, err := s.scorer.CalcScoreboard(c.Param("group"), "")
+	if err == nil && notModified(c, makeETag(scores.ModifiedAt, user.ID), scores.ModifiedAt) {
+		return
+	}
+	if scores != nil {
+		scores = visibleStandings(user, scores)
+	}
 	c.HTML(http.StatusOK, "/standings.tmpl", gin.H{
-		"CourseName": "HSE Basic C++",
-		"Title":      "HSE Basic C++",
+		"CourseName": s.config.Title,
+		"Title":      s.config.Title,
 		"Config":     s.config,
 		"Standings":  scores,
 		"Error":      err,
 		"Links":      s.makeLinks(user),
 		"Groups":     s.makeGroupLinks(),
+		"HistoryUrl": s.standingsHistoryUrl(c.Param("group"), ""),
 	})
 }
 
 func (s *server) RenderSubgroupStandingsPage(c *gin.Context) {
 	user := c.MustGet("user").(*models.User)
 	scores, err := s.scorer.CalcScoreboard(c.Param("group"), c.Param("subgroup"))
+	if err == nil && notModified(c, makeETag(scores.ModifiedAt, user.ID), scores.ModifiedAt) {
+		return
+	}
+	if scores != nil {
+		scores = visibleStandings(user, scores)
+	}
 	c.HTML(http.StatusOK, "/standings.tmpl", gin.H{
-		"CourseName": "HSE Basic C++",
-		"Title":      "HSE Basic C++",
+		"CourseName": s.config.Title,
+		"Title":      s.config.Title,
 		"Config":     s.config,
 		"Standings":  scores,
 		"Error":      err,
 		"Links":      s.makeLinks(user),
 		"Groups":     s.makeGroupLinks(),
+		"HistoryUrl": s.standingsHistoryUrl(c.Param("group"), c.Param("subgroup")),
 	})
 }
 
 func (s *server) RenderStandingsCheaterPage(c *gin.Context) {
+	admin := s.getUser(c)
 	user, err := s.db.FindUserByGitlabLogin(c.Query("login"))
-	scores, err := s.scorer.CalcScoreboard("students", "")
+	if err != nil {
+		s.logger.Warn("Failed to find user", zap.Error(err), lf.GitlabLogin(c.Query("login")))
+		s.RenderAdminUsersPageDetails(c, "Unknown user")
+		return
+	}
+
+	scores, err := s.scorer.CalcScoreboard(user.GroupName, "")
 	c.HTML(http.StatusOK, "/standings.tmpl", gin.H{
-		"CourseName": "HSE Basic C++",
-		"Title":      "HSE Basic C++",
+		"CourseName": s.config.Title,
+		"Title":      s.config.Title,
 		"Config":     s.config,
 		"Standings":  scores,
 		"Error":      err,
-		"Links":      s.makeLinks(user),
+		"Links":      s.makeImpersonatedLinks(admin, user),
 		"Groups":     s.makeGroupLinks(),
+		"HistoryUrl": s.standingsHistoryUrl(user.GroupName, ""),
 	})
 }
+
+func (s *server) userHistoryUrl(user *models.User) string {
+	return s.config.Endpoints.Api.ScoresHistory + "?" + url.Values{"login": {*user.GitlabLogin}}.Encode()
+}
+
+func (s *server) standingsHistoryUrl(group, subgroup string) string {
+	query := url.Values{"group": {group}}
+	if subgroup != "" {
+		query.Set("subgroup", subgroup)
+	}
+	return s.config.Endpoints.Api.StandingsHistory + "?" + query.Encode()
+}
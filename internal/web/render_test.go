@@ -0,0 +1,191 @@
+package web
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/bigredeye/notmanytask/internal/config"
+	"github.com/bigredeye/notmanytask/internal/flags"
+	"github.com/bigredeye/notmanytask/internal/models"
+)
+
+// fakeFlags mimics flags and flag submissions tables
+type fakeFlags struct {
+	flags       map[string]*models.Flag
+	users       []*models.User
+	submissions []models.FlagSubmission
+}
+
+func (f *fakeFlags) CountWrongFlagSubmissions(user uint, since time.Time) (int64, error) {
+	count := int64(0)
+	for _, submission := range f.submissions {
+		if submission.UserID != user || submission.CreatedAt.Before(since) {
+			continue
+		}
+		for _, outcome := range models.WrongFlagSubmissionOutcomes {
+			if submission.Outcome == outcome {
+				count++
+			}
+		}
+	}
+	return count, nil
+}
+
+func (f *fakeFlags) FindFlag(id string) (*models.Flag, error) {
+	return f.flags[id], nil
+}
+
+func (f *fakeFlags) SubmitFlag(id string, gitlabLogin string) error {
+	flag := f.flags[id]
+	if flag == nil || flag.GitlabLogin != nil || (flag.Owner != nil && *flag.Owner != gitlabLogin) {
+		return errors.New("Unknown flag")
+	}
+	flag.GitlabLogin = &gitlabLogin
+	return nil
+}
+
+func (f *fakeFlags) ListUsers(groupName string, subgroupName string) ([]*models.User, error) {
+	return f.users, nil
+}
+
+func makeFlagUser(id uint, login string) *models.User {
+	user := &models.User{}
+	user.ID = id
+	if login != "" {
+		user.GitlabLogin = &login
+	}
+	return user
+}
+
+type flagsTest struct {
+	server  *server
+	storage *fakeFlags
+	signer  *flags.Signer
+}
+
+func newFlagsTest(t *testing.T) *flagsTest {
+	signer, err := flags.NewSigner("000102030405060708090a0b0c0d0e0f")
+	if err != nil {
+		t.Fatal(err)
+	}
+	storage := &fakeFlags{
+		flags: make(map[string]*models.Flag),
+		users: []*models.User{makeFlagUser(1, "neil"), makeFlagUser(2, "buzz"), makeFlagUser(3, "")},
+	}
+	conf := &config.Config{}
+	conf.Flags.MaxWrongAttempts = 3
+	conf.Flags.WrongAttemptsWindow = time.Hour
+	return &flagsTest{
+		server:  &server{config: conf, logger: zap.NewNop(), flags: signer, flagsDB: storage},
+		storage: storage,
+		signer:  signer,
+	}
+}
+
+// issue stores the flag signed for the owner like createFlag does
+func (f *flagsTest) issue(task string, owner string, now time.Time) string {
+	flag := f.signer.Sign(task, owner, now)
+	f.storage.flags[flag] = &models.Flag{ID: flag, Task: task, Owner: &owner, CreatedAt: now}
+	return flag
+}
+
+// submit records the submission like handleFlagSubmit does
+func (f *flagsTest) submit(user *models.User, flag string, now time.Time) *models.FlagSubmission {
+	submission := &models.FlagSubmission{
+		UserID:      user.ID,
+		GitlabLogin: *user.GitlabLogin,
+		Flag:        flag,
+		CreatedAt:   now,
+	}
+	f.server.submitFlag(zap.NewNop(), submission)
+	f.storage.submissions = append(f.storage.submissions, *submission)
+	return submission
+}
+
+func TestSubmitFlagOutcomes(t *testing.T) {
+	now := time.Date(2021, 7, 20, 20, 17, 0, 0, time.UTC)
+	f := newFlagsTest(t)
+	f.server.config.Flags.MaxWrongAttempts = 100
+	neil, buzz := f.storage.users[0], f.storage.users[1]
+
+	own := f.issue("fly-me-to-the-moon", "neil", now)
+	foreign := f.issue("fly-me-to-the-moon", "buzz", now)
+	accepted := f.issue("rewrite-in-rust", "buzz", now)
+	// Signed flags missing in the database, e.g. issued before a restore from backup
+	lost := f.signer.Sign("rewrite-in-go", "buzz", now)
+
+	if submission := f.submit(buzz, accepted, now); submission.Outcome != models.FlagSubmissionAccepted {
+		t.Fatalf("Flag is not accepted: %+v", submission)
+	}
+
+	for _, tc := range []struct {
+		name  string
+		flag  string
+		task  string
+		owner string
+
+		outcome models.FlagSubmissionOutcome
+	}{
+		{name: "malformed", flag: "{FLAG-hello}", outcome: models.FlagSubmissionInvalid},
+		{name: "unknown", flag: "{FLAG-fly-me-to-the-moon-0123abcd}", outcome: models.FlagSubmissionUnknown},
+		{name: "own", flag: own, task: "fly-me-to-the-moon", owner: "neil", outcome: models.FlagSubmissionAccepted},
+		{name: "duplicate", flag: own, task: "fly-me-to-the-moon", owner: "neil", outcome: models.FlagSubmissionDuplicate},
+		{name: "issued for another student", flag: foreign, task: "fly-me-to-the-moon", owner: "buzz", outcome: models.FlagSubmissionForeign},
+		{name: "submitted by another student", flag: accepted, task: "rewrite-in-rust", owner: "buzz", outcome: models.FlagSubmissionForeign},
+		{name: "signed for another student and missing", flag: lost, task: "rewrite-in-go", owner: "buzz", outcome: models.FlagSubmissionForeign},
+	} {
+		submission := f.submit(neil, tc.flag, now)
+		if submission.Outcome != tc.outcome || submission.Task != tc.task {
+			t.Errorf("%s: unexpected submission %+v, expected outcome %s of task %q", tc.name, submission, tc.outcome, tc.task)
+		}
+		owner := ""
+		if submission.FlagOwner != nil {
+			owner = *submission.FlagOwner
+		}
+		if owner != tc.owner {
+			t.Errorf("%s: unexpected flag owner %q, expected: %q", tc.name, owner, tc.owner)
+		}
+	}
+}
+
+func TestSubmitFlagRateLimit(t *testing.T) {
+	start := time.Date(2021, 7, 20, 20, 17, 0, 0, time.UTC)
+	f := newFlagsTest(t)
+	neil, buzz := f.storage.users[0], f.storage.users[1]
+
+	own := f.issue("fly-me-to-the-moon", "neil", start)
+	if submission := f.submit(neil, own, start); submission.Outcome != models.FlagSubmissionAccepted {
+		t.Fatalf("Flag is not accepted: %+v", submission)
+	}
+
+	// Duplicates are not guesses
+	for i := 0; i < 5; i++ {
+		if submission := f.submit(neil, own, start); submission.Outcome != models.FlagSubmissionDuplicate {
+			t.Fatalf("Duplicate is rate limited: %+v", submission)
+		}
+	}
+
+	for i := 0; i < 3; i++ {
+		if submission := f.submit(neil, "{FLAG-guess-0123abcd}", start.Add(time.Minute)); submission.Outcome != models.FlagSubmissionUnknown {
+			t.Fatalf("Attempt %d is rate limited: %+v", i, submission)
+		}
+	}
+
+	// Rate limited attempts do not prolong the limit
+	for _, offset := range []time.Duration{time.Minute, 30 * time.Minute, 59 * time.Minute} {
+		if submission := f.submit(neil, own, start.Add(offset)); submission.Outcome != models.FlagSubmissionRateLimited {
+			t.Fatalf("Attempt after %v is not rate limited: %+v", offset, submission)
+		}
+	}
+	if submission := f.submit(neil, own, start.Add(time.Hour+2*time.Minute)); submission.Outcome != models.FlagSubmissionDuplicate {
+		t.Errorf("Attempt after the window is rate limited: %+v", submission)
+	}
+
+	// Limits are per student
+	if submission := f.submit(buzz, "{FLAG-guess-0123abcd}", start.Add(2*time.Minute)); submission.Outcome != models.FlagSubmissionUnknown {
+		t.Errorf("Another student is rate limited: %+v", submission)
+	}
+}
@@ -10,10 +10,12 @@ import (
 	"github.com/bigredeye/notmanytask/internal/config"
 	"github.com/bigredeye/notmanytask/internal/database"
 	"github.com/bigredeye/notmanytask/internal/deadlines"
+	"github.com/bigredeye/notmanytask/internal/flags"
 	"github.com/bigredeye/notmanytask/internal/gitlab"
 	"github.com/bigredeye/notmanytask/internal/scorer"
 	zlog "github.com/bigredeye/notmanytask/pkg/log"
 	"github.com/pkg/errors"
+	"go.uber.org/zap"
 )
 
 func Run() error {
@@ -48,11 +50,9 @@ func Run() error {
 		return errors.Wrap(err, "Failed to open database")
 	}
 
-	deadlinesCtx, deadlinesCancel := context.WithCancel(ctx)
-	defer deadlinesCancel()
-	deadlines, err := deadlines.NewFetcher(config, logger.Named("deadlines.fetcher"))
+	views, err := config.CourseViews()
 	if err != nil {
-		return errors.Wrap(err, "Failed to create deadlines fetcher")
+		return errors.Wrap(err, "Invalid courses config")
 	}
 
 	git, err := gitlab.NewClient(config, logger.Named("gitlab"))
@@ -60,51 +60,138 @@ func Run() error {
 		return errors.Wrap(err, "Failed to create gitlab client")
 	}
 
-	projectsCtx, projectsCancel := context.WithCancel(ctx)
-	defer projectsCancel()
+	// Flags are not bound to students without the key, as it was before the key was introduced
+	var flagsSigner *flags.Signer
+	if config.Flags.Key != "" {
+		flagsSigner, err = flags.NewSigner(config.Flags.Key)
+		if err != nil {
+			return errors.Wrap(err, "Failed to create flags signer")
+		}
+	} else {
+		logger.Warn("Flags key is not configured, issuing unbound flags")
+	}
+
+	r, err := newEngine(config, logger.Named("server"))
+	if err != nil {
+		return errors.Wrap(err, "Failed to create server")
+	}
+
+	for _, view := range views {
+		s, err := startCourse(ctx, &wg, view, logger.With(zap.String("course", view.Course)), db, git, flagsSigner)
+		if err != nil {
+			return errors.Wrapf(err, "Failed to start course %q", view.Course)
+		}
+		if err = s.setup(r); err != nil {
+			return errors.Wrapf(err, "Failed to setup course %q", view.Course)
+		}
+	}
+	if len(config.Courses) > 0 {
+		setupCoursesPage(r, views)
+	}
+
+	sessionsCtx, sessionsCancel := context.WithCancel(ctx)
+	defer sessionsCancel()
+	sessions := newSessionsSweeper(config, logger.Named("sessions"), db)
+
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sessions.Run(sessionsCtx)
+	}()
+
+	logger.Info("Starting server", zap.String("bind_address", config.Server.ListenAddress))
+	return errors.Wrap(r.Run(config.Server.ListenAddress), "Server failed")
+}
+
+// startCourse runs background jobs of the course until ctx is cancelled
+func startCourse(
+	ctx context.Context,
+	wg *sync.WaitGroup,
+	config *config.Config,
+	logger *zap.Logger,
+	db *database.DataBase,
+	git *gitlab.Client,
+	flagsSigner *flags.Signer,
+) (*server, error) {
+	db = db.ForCourse(config.Course)
+	git = git.ForCourse(config)
+
+	if _, err := config.Location(""); err != nil {
+		return nil, err
+	}
+	for _, group := range config.Groups {
+		if _, err := config.Location(group.Name); err != nil {
+			return nil, errors.Wrapf(err, "Invalid time zone of group %s", group.Name)
+		}
+		if group.Grading == nil {
+			continue
+		}
+		if err := scorer.ValidateGrading(group.Grading); err != nil {
+			return nil, errors.Wrapf(err, "Invalid grading config of group %s", group.Name)
+		}
+	}
+
+	if err := gitlab.ValidateProjects(config); err != nil {
+		return nil, err
+	}
+
+	if err := db.SyncAdmins(config.Admins); err != nil {
+		return nil, errors.Wrap(err, "Failed to sync admins")
+	}
+
+	deadlines, err := deadlines.NewFetcher(config, logger.Named("deadlines.fetcher"), db, scorer.ValidateScoring, git)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create deadlines fetcher")
+	}
+
 	projects, err := gitlab.NewProjectsMaker(git, db)
 	if err != nil {
-		return errors.Wrap(err, "Failed to create projects maker")
+		return nil, errors.Wrap(err, "Failed to create projects maker")
 	}
 
-	pipelinesCtx, pipelinesCancel := context.WithCancel(ctx)
-	defer pipelinesCancel()
 	pipelines, err := gitlab.NewPipelinesFetcher(git, db)
 	if err != nil {
-		return errors.Wrap(err, "Failed to create projects maker")
+		return nil, errors.Wrap(err, "Failed to create projects maker")
 	}
 
-	mergeRequestsCtx, mergeRequestsCancel := context.WithCancel(ctx)
-	defer mergeRequestsCancel()
 	mergeRequests, err := gitlab.NewMergeRequestsUpdater(git, db)
 	if err != nil {
-		return errors.Wrap(err, "Failed to create merge requests updater")
+		return nil, errors.Wrap(err, "Failed to create merge requests updater")
+	}
+
+	templates, err := gitlab.NewTemplateUpdater(git, db)
+	if err != nil {
+		return nil, errors.Wrap(err, "Failed to create template updater")
 	}
 
-	scorer := scorer.NewScorer(db, deadlines, git)
+	scoring := scorer.NewScorer(config, db, deadlines, git)
+	snapshots := scorer.NewSnapshotter(config, logger.Named("snapshots"), scoring, db)
 
-	wg.Add(4)
+	wg.Add(6)
 	go func() {
 		defer wg.Done()
-		deadlines.Run(deadlinesCtx)
+		deadlines.Run(ctx)
 	}()
 	go func() {
 		defer wg.Done()
-		projects.Run(projectsCtx)
+		projects.Run(ctx)
 	}()
 	go func() {
 		defer wg.Done()
-		pipelines.Run(pipelinesCtx)
+		pipelines.Run(ctx)
 	}()
 	go func() {
 		defer wg.Done()
-		mergeRequests.Run(mergeRequestsCtx)
+		mergeRequests.Run(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		templates.Run(ctx)
+	}()
+	go func() {
+		defer wg.Done()
+		snapshots.Run(ctx)
 	}()
 
-	s, err := newServer(config, logger.Named("server"), db, deadlines, projects, pipelines, scorer, git)
-	if err != nil {
-		return errors.Wrap(err, "Failed to start server")
-	}
-
-	return errors.Wrap(s.run(), "Server failed")
+	return newServer(config, logger.Named("server"), db, deadlines, projects, pipelines, mergeRequests, templates, scoring, git, flagsSigner)
 }
@@ -16,6 +16,7 @@ import (
 	"github.com/bigredeye/notmanytask/internal/config"
 	"github.com/bigredeye/notmanytask/internal/database"
 	"github.com/bigredeye/notmanytask/internal/deadlines"
+	"github.com/bigredeye/notmanytask/internal/flags"
 	"github.com/bigredeye/notmanytask/internal/gitlab"
 	"github.com/bigredeye/notmanytask/internal/scorer"
 	_ "github.com/bigredeye/notmanytask/pkg/statik"
@@ -27,11 +28,16 @@ type server struct {
 
 	auth      *AuthClient
 	db        *database.DataBase
+	sessions  sessionsStorage
+	flagsDB   flagsStorage
 	deadlines *deadlines.Fetcher
 	projects  *gitlab.ProjectsMaker
 	pipelines *gitlab.PipelinesFetcher
+	mrs       *gitlab.MergeRequestsUpdater
+	templates *gitlab.TemplateUpdater
 	scorer    *scorer.Scorer
 	gitlab    *gitlab.Client
+	flags     *flags.Signer
 }
 
 func newServer(
@@ -41,19 +47,27 @@ func newServer(
 	deadlines *deadlines.Fetcher,
 	projects *gitlab.ProjectsMaker,
 	pipelines *gitlab.PipelinesFetcher,
+	mrs *gitlab.MergeRequestsUpdater,
+	templates *gitlab.TemplateUpdater,
 	scorer *scorer.Scorer,
 	gitlab *gitlab.Client,
+	flags *flags.Signer,
 ) (*server, error) {
 	return &server{
 		config:    config,
 		logger:    logger,
 		auth:      NewAuthClient(config),
 		db:        db,
+		sessions:  db,
+		flagsDB:   db,
 		deadlines: deadlines,
 		projects:  projects,
 		pipelines: pipelines,
+		mrs:       mrs,
+		templates: templates,
 		scorer:    scorer,
 		gitlab:    gitlab,
+		flags:     flags,
 	}, nil
 }
 
@@ -82,35 +96,64 @@ func buildHTMLTemplates(hfs http.FileSystem, funcMap template.FuncMap) (*templat
 	return tmpl, nil
 }
 
-func (s *server) run() error {
+var templateFuncs = template.FuncMap{
+	"inc": func(i int) int {
+		return i + 1
+	},
+	"formatDate": formatDate,
+}
+
+func newEngine(config *config.Config, logger *zap.Logger) (*gin.Engine, error) {
 	statikFS, err := statik.New()
 	if err != nil {
-		return errors.Wrap(err, "Failed to open statik fs")
-	}
-	funcs := template.FuncMap{
-		"inc": func(i int) int {
-			return i + 1
-		},
+		return nil, errors.Wrap(err, "Failed to open statik fs")
 	}
-	tmpl, err := buildHTMLTemplates(statikFS, funcs)
+	tmpl, err := buildHTMLTemplates(statikFS, templateFuncs)
 	if err != nil {
-		return errors.Wrap(err, "Failed to build html templates")
+		return nil, errors.Wrap(err, "Failed to build html templates")
 	}
 
 	gin.SetMode(gin.ReleaseMode)
 	r := gin.New()
 
-	r.Use(ginzap.Ginzap(s.logger, time.RFC3339, true))
-	r.Use(ginzap.RecoveryWithZap(s.logger, true))
+	r.Use(logRequests(logger))
+	r.Use(ginzap.RecoveryWithZap(logger, true))
 
 	r.SetHTMLTemplate(tmpl)
 
 	// TODO(BigRedEye): Move cookies to the separate file
-	err = setupAuth(s, r)
+	err = setupAuth(config, r)
 	if err != nil {
-		return errors.Wrap(err, "Failed to setup auth")
+		return nil, errors.Wrap(err, "Failed to setup auth")
+	}
+
+	r.GET("/ping", func(c *gin.Context) {
+		c.String(http.StatusOK, "pong "+fmt.Sprint(time.Now().Unix()))
+	})
+	r.GET("/private/solutions/:task", handleChuckNorris)
+
+	r.StaticFS("/static", statikFS)
+
+	return r, nil
+}
+
+// setupCoursesPage lists courses at the root unless some course is served there
+func setupCoursesPage(r *gin.Engine, views []*config.Config) {
+	for _, view := range views {
+		if view.Endpoints.Home == "/" {
+			return
+		}
 	}
-	err = setupLoginService(s, r)
+	r.GET("/", func(c *gin.Context) {
+		c.HTML(http.StatusOK, "/courses.tmpl", gin.H{
+			"Title":   "Courses",
+			"Courses": views,
+		})
+	})
+}
+
+func (s *server) setup(r *gin.Engine) error {
+	err := setupLoginService(s, r)
 	if err != nil {
 		return errors.Wrap(err, "Failed to setup login service")
 	}
@@ -119,20 +162,36 @@ func (s *server) run() error {
 		return errors.Wrap(err, "Failed to setup api service")
 	}
 
-	r.GET("/ping", func(c *gin.Context) {
-		c.String(http.StatusOK, "pong "+fmt.Sprint(time.Now().Unix()))
-	})
-
 	r.GET(s.config.Endpoints.Home, s.validateSession, s.RenderHomePage)
 	r.GET(s.config.Endpoints.Flag, s.validateSession, s.RenderSubmitFlagPage)
+	r.GET(s.config.Endpoints.Task, s.validateSession, s.RenderTaskPage)
 	r.GET(s.config.Endpoints.Standings, s.validateSession, s.RedirectToStandingsPage)
 	r.GET(s.config.Endpoints.GroupStandings, s.validateSession, s.RenderStandingsPage)
 	r.GET(s.config.Endpoints.SubgroupStandings, s.validateSession, s.RenderSubgroupStandingsPage)
 	r.POST(s.config.Endpoints.Flag, s.validateSession, s.handleFlagSubmit)
-	r.GET("/private/solutions/:task", s.handleChuckNorris)
-
-	r.StaticFS("/static", statikFS)
-
-	s.logger.Info("Starting server", zap.String("bind_address", s.config.Server.ListenAddress))
-	return r.Run(s.config.Server.ListenAddress)
+	r.GET(s.config.Endpoints.ApiToken, s.validateSession, s.RenderApiTokenPage)
+	r.POST(s.config.Endpoints.ApiToken, s.validateSession, s.handleApiTokenReset)
+	r.GET(s.config.Endpoints.Calendar, s.validateSession, s.RenderCalendarPage)
+	r.POST(s.config.Endpoints.Calendar, s.validateSession, s.handleFeedTokenReset)
+	r.GET(s.config.Endpoints.CalendarFeed, s.handleCalendarFeed)
+	r.GET(s.config.Endpoints.GroupCalendarFeed, s.handleGroupCalendarFeed)
+	r.GET(s.config.Endpoints.Sessions, s.validateSession, s.RenderSessionsPage)
+	r.POST(s.config.Endpoints.Sessions, s.validateSession, s.handleSessionRevoke)
+	r.POST(s.config.Endpoints.TimeZone, s.validateSession, s.handleTimeZone)
+	r.GET(s.config.Endpoints.Admin.Users, s.validateSession, s.requireAdmin, s.RenderAdminUsersPage)
+	r.GET(s.config.Endpoints.Admin.User, s.validateSession, s.requireAdmin, s.RenderCheaterPage)
+	r.GET(s.config.Endpoints.Admin.Standings, s.validateSession, s.requireAdmin, s.RenderStandingsCheaterPage)
+	r.POST(s.config.Endpoints.Admin.Extensions, s.validateSession, s.requireAdmin, s.handleGrantExtension)
+	r.GET(s.config.Endpoints.Admin.Overrides, s.validateSession, s.requireAdmin, s.RenderScoreOverridesPage)
+	r.POST(s.config.Endpoints.Admin.Overrides, s.validateSession, s.requireAdmin, s.handleScoreOverride)
+	r.GET(s.config.Endpoints.Admin.Export, s.validateSession, s.requireAdmin, s.handleStandingsExport)
+	r.GET(s.config.Endpoints.Admin.Flags, s.validateSession, s.requireAdmin, s.RenderForeignFlagsPage)
+	r.POST(s.config.Endpoints.Admin.SyncTemplate, s.validateSession, s.requireAdmin, s.handleSyncTemplate)
+	r.GET(s.config.Endpoints.Admin.TemplateUpdates, s.validateSession, s.requireAdmin, s.RenderTemplateUpdatesPage)
+	r.POST(s.config.Endpoints.Admin.TemplateUpdates, s.validateSession, s.requireAdmin, s.handleTemplateUpdatesTrigger)
+	r.GET(s.config.Endpoints.Admin.Provisioning, s.validateSession, s.requireAdmin, s.RenderProvisioningPage)
+	r.POST(s.config.Endpoints.Admin.Provisioning, s.validateSession, s.requireAdmin, s.handleProvisioningRetry)
+	r.GET(s.config.Endpoints.Admin.Deadlines, s.validateSession, s.requireAdmin, s.RenderDeadlinesStatusPage)
+
+	return nil
 }
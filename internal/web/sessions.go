@@ -0,0 +1,179 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/bigredeye/notmanytask/internal/config"
+	lf "github.com/bigredeye/notmanytask/internal/logfield"
+	"github.com/bigredeye/notmanytask/internal/models"
+)
+
+const (
+	defaultSessionTtl           = time.Hour * 24 * 30
+	defaultSessionSweepInterval = time.Hour
+
+	// Do not write to the database on each request
+	sessionTouchInterval = time.Minute
+
+	revokeAllSessions = "all"
+)
+
+type sessionsStorage interface {
+	FindUserBySession(token string) (*models.User, *models.Session, error)
+	TouchSession(session *models.Session, ip string, ttl time.Duration) error
+	DeleteSessionByToken(token string) error
+	BackfillSessions(now time.Time, ttl time.Duration) (int64, error)
+	DeleteExpiredSessions(now time.Time) (int64, error)
+}
+
+func sessionTtl(config *config.Config) time.Duration {
+	if config.Server.Sessions.Ttl > 0 {
+		return config.Server.Sessions.Ttl
+	}
+	return defaultSessionTtl
+}
+
+func (s *server) touchSession(c *gin.Context, session *models.Session) {
+	if time.Since(session.LastSeenAt) < sessionTouchInterval {
+		return
+	}
+	if err := s.sessions.TouchSession(session, c.ClientIP(), sessionTtl(s.config)); err != nil {
+		s.logger.Error("Failed to touch session", zap.Error(err), zap.Uint("session_id", session.ID))
+	}
+}
+
+type sessionsSweeper struct {
+	config *config.Config
+	logger *zap.Logger
+	db     sessionsStorage
+}
+
+func newSessionsSweeper(config *config.Config, logger *zap.Logger, db sessionsStorage) *sessionsSweeper {
+	return &sessionsSweeper{config, logger, db}
+}
+
+func (s sessionsSweeper) Run(ctx context.Context) {
+	interval := s.config.Server.Sessions.SweepInterval
+	if interval <= 0 {
+		interval = defaultSessionSweepInterval
+	}
+
+	s.sweep()
+	tick := time.Tick(interval)
+	for {
+		select {
+		case <-tick:
+			s.sweep()
+		case <-ctx.Done():
+			s.logger.Info("Stopping sessions sweeper")
+			return
+		}
+	}
+}
+
+func (s sessionsSweeper) sweep() {
+	s.sweepAt(time.Now())
+}
+
+// sweepAt deletes sessions expired by now, sessions without expiration get it first
+func (s sessionsSweeper) sweepAt(now time.Time) {
+	backfilled, err := s.db.BackfillSessions(now, sessionTtl(s.config))
+	if err != nil {
+		s.logger.Error("Failed to backfill sessions expiration", zap.Error(err))
+		return
+	}
+	if backfilled > 0 {
+		s.logger.Info("Backfilled sessions expiration", zap.Int64("count", backfilled))
+	}
+
+	deleted, err := s.db.DeleteExpiredSessions(now)
+	if err != nil {
+		s.logger.Error("Failed to delete expired sessions", zap.Error(err))
+		return
+	}
+	s.logger.Info("Deleted expired sessions", zap.Int64("count", deleted))
+}
+
+type SessionInfo struct {
+	models.Session
+
+	Current bool
+}
+
+func (s *server) RenderSessionsPage(c *gin.Context) {
+	s.RenderSessionsPageDetails(c, "")
+}
+
+func (s *server) RenderSessionsPageDetails(c *gin.Context, errorMessage string) {
+	user := s.getUser(c)
+	current := s.getSession(c)
+
+	sessions, err := s.db.ListUserSessions(user.ID)
+	if err != nil {
+		s.logger.Error("Failed to list sessions", zap.Error(err), lf.UserID(user.ID))
+		errorMessage = "Failed to list sessions"
+	}
+
+	infos := make([]SessionInfo, len(sessions))
+	for i, session := range sessions {
+		infos[i] = SessionInfo{
+			Session: session,
+			Current: session.ID == current.ID,
+		}
+	}
+
+	c.HTML(http.StatusOK, "/sessions.tmpl", gin.H{
+		"CourseName":   s.config.Title,
+		"Title":        s.config.Title,
+		"Config":       s.config,
+		"Links":        s.makeLinks(user),
+		"Location":     s.viewerLocation(user),
+		"TimeZone":     user.TimeZone,
+		"Sessions":     infos,
+		"ErrorMessage": errorMessage,
+	})
+}
+
+func (s *server) handleSessionRevoke(c *gin.Context) {
+	user := s.getUser(c)
+	current := s.getSession(c)
+	target := c.PostForm("session")
+	log := s.logger.With(lf.UserID(user.ID), zap.String("target", target))
+
+	if target == revokeAllSessions {
+		if err := s.db.DeleteUserSessions(user.ID); err != nil {
+			log.Error("Failed to revoke sessions", zap.Error(err))
+			s.RenderSessionsPageDetails(c, "Failed to revoke sessions")
+			return
+		}
+		log.Info("Revoked all sessions")
+		c.Redirect(http.StatusFound, s.config.Endpoints.Logout)
+		return
+	}
+
+	id, err := strconv.ParseUint(target, 10, 64)
+	if err != nil {
+		log.Warn("Invalid session id", zap.Error(err))
+		s.RenderSessionsPageDetails(c, "Invalid session")
+		return
+	}
+
+	if err = s.db.DeleteUserSession(user.ID, uint(id)); err != nil {
+		log.Warn("Failed to revoke session", zap.Error(err))
+		s.RenderSessionsPageDetails(c, "Failed to revoke session")
+		return
+	}
+	log.Info("Revoked session")
+
+	if uint(id) == current.ID {
+		c.Redirect(http.StatusFound, s.config.Endpoints.Logout)
+		return
+	}
+	c.Redirect(http.StatusFound, s.config.Endpoints.Sessions)
+}
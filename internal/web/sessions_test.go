@@ -0,0 +1,146 @@
+package web
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/bigredeye/notmanytask/internal/config"
+	"github.com/bigredeye/notmanytask/internal/models"
+)
+
+// fakeSessions keeps sessions by token, zero ExpiresAt stands for NULL like in the database
+type fakeSessions struct {
+	sessions map[string]*models.Session
+}
+
+func (f *fakeSessions) FindUserBySession(token string) (*models.User, *models.Session, error) {
+	session, found := f.sessions[token]
+	if !found {
+		return nil, nil, errors.New("record not found")
+	}
+	user := &models.User{}
+	user.ID = session.UserID
+	return user, session, nil
+}
+
+func (f *fakeSessions) TouchSession(session *models.Session, ip string, ttl time.Duration) error {
+	session.LastSeenAt = time.Now()
+	session.ExpiresAt = session.LastSeenAt.Add(ttl)
+	return nil
+}
+
+func (f *fakeSessions) DeleteSessionByToken(token string) error {
+	delete(f.sessions, token)
+	return nil
+}
+
+func (f *fakeSessions) BackfillSessions(now time.Time, ttl time.Duration) (int64, error) {
+	count := int64(0)
+	for _, session := range f.sessions {
+		if !session.ExpiresAt.IsZero() {
+			continue
+		}
+		if session.LastSeenAt.IsZero() {
+			session.LastSeenAt = session.CreatedAt
+		}
+		session.ExpiresAt = now.Add(ttl)
+		count++
+	}
+	return count, nil
+}
+
+func (f *fakeSessions) DeleteExpiredSessions(now time.Time) (int64, error) {
+	count := int64(0)
+	for token, session := range f.sessions {
+		// NULL <= now is not true
+		if !session.ExpiresAt.IsZero() && !session.ExpiresAt.After(now) {
+			delete(f.sessions, token)
+			count++
+		}
+	}
+	return count, nil
+}
+
+var sessionsNow = time.Date(1969, 7, 20, 20, 17, 0, 0, time.UTC)
+
+func TestSessionExpired(t *testing.T) {
+	for _, tc := range []struct {
+		expiresAt time.Time
+		expected  bool
+	}{
+		{expiresAt: sessionsNow.Add(time.Second), expected: false},
+		{expiresAt: sessionsNow, expected: true},
+		{expiresAt: sessionsNow.Add(-time.Second), expected: true},
+		// Not backfilled yet
+		{expected: false},
+	} {
+		session := models.Session{ExpiresAt: tc.expiresAt}
+		if expired := session.Expired(sessionsNow); expired != tc.expected {
+			t.Errorf("Unexpected expiration of session expiring at %v: %v", tc.expiresAt, expired)
+		}
+	}
+}
+
+func TestFindSessionUser(t *testing.T) {
+	storage := &fakeSessions{sessions: map[string]*models.Session{
+		"alive":   {ID: 1, UserID: 10, ExpiresAt: sessionsNow.Add(time.Hour)},
+		"expired": {ID: 2, UserID: 20, ExpiresAt: sessionsNow},
+		"legacy":  {ID: 3, UserID: 30},
+	}}
+	s := &server{logger: zap.NewNop(), sessions: storage}
+
+	for token, userID := range map[string]uint{"alive": 10, "legacy": 30} {
+		user, session, err := s.findSessionUser(token, sessionsNow)
+		if err != nil || session == nil || user.ID != userID {
+			t.Errorf("Session %s is not found: %+v, %v", token, session, err)
+		}
+	}
+
+	user, session, err := s.findSessionUser("expired", sessionsNow)
+	if err != nil || user != nil || session != nil {
+		t.Errorf("Expired session is found: %+v, %v", session, err)
+	}
+	if _, found := storage.sessions["expired"]; found {
+		t.Errorf("Expired session is not deleted")
+	}
+
+	if _, _, err = s.findSessionUser("unknown", sessionsNow); err == nil {
+		t.Errorf("Unknown session is found")
+	}
+}
+
+func TestSweepSessions(t *testing.T) {
+	storage := &fakeSessions{sessions: map[string]*models.Session{
+		"alive":   {ID: 1, ExpiresAt: sessionsNow.Add(time.Hour)},
+		"expired": {ID: 2, ExpiresAt: sessionsNow.Add(-time.Hour)},
+		"legacy":  {ID: 3, CreatedAt: sessionsNow.Add(-365 * 24 * time.Hour)},
+	}}
+	conf := &config.Config{}
+	conf.Server.Sessions.Ttl = 24 * time.Hour
+	sweeper := newSessionsSweeper(conf, zap.NewNop(), storage)
+
+	sweeper.sweepAt(sessionsNow)
+	if _, found := storage.sessions["expired"]; found {
+		t.Errorf("Expired session is not swept")
+	}
+	if _, found := storage.sessions["alive"]; !found {
+		t.Errorf("Alive session is swept")
+	}
+
+	legacy, found := storage.sessions["legacy"]
+	if !found {
+		t.Fatalf("Backfilled session is swept")
+	}
+	if !legacy.ExpiresAt.Equal(sessionsNow.Add(24*time.Hour)) || !legacy.LastSeenAt.Equal(legacy.CreatedAt) {
+		t.Errorf("Unexpected backfilled session %+v", legacy)
+	}
+
+	// Backfilled sessions expire after the ttl like others
+	sweeper.sweepAt(sessionsNow.Add(24 * time.Hour))
+	if len(storage.sessions) != 0 {
+		t.Errorf("Sessions are not swept after the ttl: %v", storage.sessions)
+	}
+}
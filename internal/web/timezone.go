@@ -0,0 +1,100 @@
+package web
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+
+	"github.com/bigredeye/notmanytask/internal/deadlines"
+	lf "github.com/bigredeye/notmanytask/internal/logfield"
+	"github.com/bigredeye/notmanytask/internal/models"
+)
+
+const defaultDateFormat = "02-01-2006 15:04"
+
+// time.LoadLocation reads tzdata on every call
+var locations sync.Map
+
+func loadLocation(name string) (*time.Location, error) {
+	if loc, found := locations.Load(name); found {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	locations.Store(name, loc)
+	return loc, nil
+}
+
+// viewerLocation returns the time zone chosen by the user, the group time zone by default
+func (s *server) viewerLocation(user *models.User) *time.Location {
+	if user.TimeZone != "" {
+		if loc, err := loadLocation(user.TimeZone); err == nil {
+			return loc
+		}
+	}
+	loc, err := s.config.Location(user.GroupName)
+	if err != nil {
+		s.logger.Error("Invalid time zone", zap.Error(err), zap.String("group", user.GroupName))
+		return deadlines.DefaultLocation()
+	}
+	return loc
+}
+
+// formatDate is a template func showing the date in the viewer time zone
+func formatDate(loc *time.Location, value interface{}, layout ...string) string {
+	var t time.Time
+	switch value := value.(type) {
+	case time.Time:
+		t = value
+	case *time.Time:
+		if value != nil {
+			t = *value
+		}
+	case deadlines.Date:
+		t = value.Time
+	case *deadlines.Date:
+		if value != nil {
+			t = value.Time
+		}
+	}
+	if t.IsZero() {
+		return ""
+	}
+
+	format := defaultDateFormat
+	if len(layout) > 0 {
+		format = layout[0]
+	}
+	if loc != nil {
+		t = t.In(loc)
+	}
+	return t.Format(format)
+}
+
+func (s *server) handleTimeZone(c *gin.Context) {
+	user := s.getUser(c)
+	timeZone := strings.TrimSpace(c.PostForm("timezone"))
+	log := s.logger.With(lf.UserID(user.ID), zap.String("time_zone", timeZone))
+
+	if timeZone != "" {
+		if _, err := loadLocation(timeZone); err != nil {
+			log.Warn("Unknown time zone", zap.Error(err))
+			s.RenderSessionsPageDetails(c, "Unknown time zone")
+			return
+		}
+	}
+
+	if err := s.db.SetUserTimeZone(user.ID, timeZone); err != nil {
+		log.Error("Failed to set time zone", zap.Error(err))
+		s.RenderSessionsPageDetails(c, "Failed to set time zone")
+		return
+	}
+	log.Info("Changed time zone")
+	c.Redirect(http.StatusFound, s.config.Endpoints.Sessions)
+}
@@ -8,7 +8,7 @@ import (
 
 
 func init() {
-	data := "PK\x03\x04\x14\x00\x08\x00\x00\x00\xbaJ0T\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00	\x00	\x00flag.tmplUT\x05\x00\x01\xb1\xe3\xe3a<!doctype html>\n<html lang=\"en\">\n  <head>\n    <meta charset=\"utf-8\">\n    <meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n    <link href=\"https://cdn.jsdelivr.net/npm/bootstrap@5.0.2/dist/css/bootstrap.min.css\" rel=\"stylesheet\">\n\n    <title>HSE Basic C&#43;&#43;</title>\n    <style>\n.navbar-brand {\n  font-size: 3rem;\n  font-weight: 300\n}\n\n#floatingFlag {\n  font-family: monospace;\n}\n    </style>\n  </head>\n  <body>\n      <nav class=\"navbar navbar-light bg-light\">\n          <div class=\"container\">\n              <span class=\"navbar-brand mb-0 h1\"><a href=\"/\" class=\"text-decoration-none text-dark\">Basic C++</a></span>\n              <div class=\"row\">\n                  <div class=\"col-auto\">\n                      <a class=\"nav-link\" href=\"{{ .Links.Deadlines }}\"><h5>Tasks</h5></a>\n                  </div>\n                  <div class=\"col-auto\">\n                      <a class=\"nav-link\" href=\"{{ .Links.Standings }}\"><h5>Standings</h5></a>\n                  </div>\n                  <div class=\"col-auto\">\n                      <a class=\"nav-link\" href=\"{{ .Links.SubmitFlag }}\"><h5>Submit flag</h5></a>\n                  </div>\n                  <div class=\"col-auto\">\n                      <a class=\"nav-link\" href=\"{{ .Links.Repository }}\"><h5>My Repo</h5></a>\n                  </div>\n                  <div class=\"col-auto\">\n                      <a class=\"nav-link\" href=\"{{ .Links.Submits }}\"><h5>Submits</h5></a>\n                  </div>\n                  <div class=\"col-auto\">\n                      <a class=\"nav-link\" href=\"{{ .Links.Logout }}\"><h5>Logout</h5></a>\n                  </div>\n              </div>\n          </div>\n          </div>\n      </nav>\n\n    <div class=\"container p-2 my-2\">\n      <div class=\"row p-2\">\n        <div class=\"col col-lg-6 offset-lg-3 col-md-10 offset-md-1\">\n          <div class=\"card\">\n            <div class=\"card-body\">\n              <form method=\"post\" action=\"{{ .Links.SubmitFlag }}\" class=\"needs-validation was-validated\">\n                <div class=\"form-floating mb-3\">\n                  <input type=\"text\" class=\"form-control\" id=\"floatingFlag\" placeholder=\"Flag\" name=\"flag\" required pattern=\"\\{FLAG(-[a-z0-9_]+)+(-[0-9a-f]+)+\\}\">\n                  <label for=\"floatingFlag\">Flag value</label>\n                  <div class=\"invalid-feedback\">\n                    Flag should be in form <code>{FLAG-crashme-d18736e-9287-ffaa-8bqe-4e6d891516ef}</code>\n                  </div>\n                </div>\n\n              {{ if .ErrorMessage }}\n              <div class=\"alert alert-danger\" role=\"alert\">\n                {{ .ErrorMessage }}\n              </div>\n              {{ end }}\n\n              {{ if .SuccessMessage }}\n              <div class=\"alert alert-success\" role=\"alert\">\n                {{ .SuccessMessage }}\n              </div>\n              {{ end }}\n\n                <div class=\"d-grid\">\n                  <button type=\"submit\" class=\"btn btn-outline-success\">Submit flag</button>\n                </div>\n              </form>\n\n            </div>\n          </div>\n        </div>\n      </div>\n    </div>\n\n  </body>\n</html>\n\n\nPK\x07\x08\xd5\x8c\x8am,\x0c\x00\x00,\x0c\x00\x00PK\x03\x04\x14\x00\x08\x00\x00\x00\xbaJ0T\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00	\x00	\x00home.tmplUT\x05\x00\x01\xb1\xe3\xe3a<!doctype html>\n<html lang=\"en\">\n    <head>\n        <meta charset=\"utf-8\">\n        <meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n        <link href=\"https://cdn.jsdelivr.net/npm/bootstrap@5.0.2/dist/css/bootstrap.min.css\" rel=\"stylesheet\">\n\n        <title>{{ .Title }}</title>\n        <style>\n.shadow-hover:hover {\n    transition: all 0.1s ease;\n    box-shadow:0 .5rem 1rem rgba(0,0,0,.15)!important\n}\n.shadow-hover {\n    -webkit-transition: all 0.1s ease;\n    -moz-transition: all 0.1s ease;\n    -o-transition: all 0.1s ease;\n    transition: all 0.1s ease;\n    box-shadow:0 .125rem .25rem rgba(0,0,0,.075)!important\n}\n\n.task {\n    overflow: hidden;\n}\n\n.task-success {\n    background-color: #a6e9d5;\n    border-color: #4dd4ac;\n}\n\n.task-failed {\n    background-color: #f8d7da;\n    border-color: #f1aeb5;\n}\n\n.task-checking {\n    border-color: #0d6efd;\n    background-color:#9ec5fe;\n}\n\n.task-assigned {\n    background-color: #f8f9fa;\n}\n\n.navbar-brand {\n  font-size: 3rem;\n  font-weight: 300\n}\n\n.nav-link {\n  color: rgba(0, 0, 0, 0.9);\n}\n        </style>\n    </head>\n    <body>\n        <nav class=\"navbar navbar-light bg-light\">\n            <div class=\"container\">\n                <span class=\"navbar-brand mb-0 h1\"><a href=\"/\" class=\"text-decoration-none text-dark\">Basic C++</a></span>\n                <div class=\"row\">\n                  <div class=\"col-auto\">\n                      <a class=\"nav-link\" href=\"{{ .Links.Deadlines }}\"><h5>Tasks</h5></a>\n                  </div>\n                  <div class=\"col-auto\">\n                      <a class=\"nav-link\" href=\"{{ .Links.Standings }}\"><h5>Standings</h5></a>\n                  </div>\n                  <div class=\"col-auto\">\n                      <a class=\"nav-link\" href=\"{{ .Links.SubmitFlag }}\"><h5>Submit flag</h5></a>\n                  </div>\n                  <div class=\"col-auto\">\n                      <a class=\"nav-link\" href=\"{{ .Links.Repository }}\"><h5>My Repo</h5></a>\n                  </div>\n                  <div class=\"col-auto\">\n                      <a class=\"nav-link\" href=\"{{ .Links.Submits }}\"><h5>Submits</h5></a>\n                  </div>\n                  <div class=\"col-auto\">\n                      <a class=\"nav-link\" href=\"{{ .Links.Logout }}\"><h5>Logout</h5></a>\n                  </div>\n                </div>\n            </div>\n            </div>\n        </nav>\n\n        {{ if .Scores }}\n            {{ range .Scores.Groups }}\n                <div class=\"container p-2 my-2\">\n                    <div class=\"p-2\">\n                        <a name=\"{{ .PrettyTitle }}\" href=\"#{{ .PrettyTitle }}\" class=\"text-decoration-none text-dark\">\n                            <h1>{{ .PrettyTitle }} <span class=\"text-muted\">{{ .Deadline.String }}</span></h1>\n                        </a>\n                    </div>\n                    <div class=\"row row-cols-1 row-cols-sm-2 row-cols-md-3 row-cols-lg-4 row-cols-xl-5 g-4 text-center\">\n                        {{ range .Tasks }}\n                            <div class=\"col\">\n                                <a href=\"{{ .TaskUrl }}\" class=\"text-decoration-none text-dark\">\n                                    <div class=\"card h-100 task task-{{ .Status }} shadow-hover\">\n                                        <div class=\"card-body\">\n                                            <h3 class=\"card-title text-nowrap text-dark\">{{ .ShortName }}</h3>\n                                            {{ if .PipelineUrl }}\n                                                <a href=\"{{ .PipelineUrl }}\" class=\"text-decoration-none\">\n                                            {{ end }}\n                                                <p class=\"card-text fs-1 text-decoration-none text-dark\">\n                                                    {{.Score}} / {{.MaxScore}}\n                                                </p>\n                                            {{ if .PipelineUrl }}\n                                                </a>\n                                            {{ end }}\n                                        </div>\n                                    </div>\n                                </a>\n                            </div>\n                        {{ end }}\n                    </div>\n\n                    <div class=\"p-2\">\n                        <h1>Total score: {{ .Score }} / {{ .MaxScore }}</h1>\n                    </div>\n                </div>\n            {{ end }}\n        {{ end}}\n    </body>\n</html>\nPK\x07\x08\x86;\x12\x8f|\x11\x00\x00|\x11\x00\x00PK\x03\x04\x14\x00\x08\x00\x00\x00<c)T\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x08\x00	\x00kek.htmlUT\x05\x00\x01U\xd4\xdaakek!\nPK\x07\x08Ln\xf0\x0c\x05\x00\x00\x00\x05\x00\x00\x00PK\x03\x04\x14\x00\x08\x00\x00\x00\xbaJ0T\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x0b\x00	\x00signup.tmplUT\x05\x00\x01\xb1\xe3\xe3a<!doctype html>\n<html lang=\"en\">\n  <head>\n    <meta charset=\"utf-8\">\n    <meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n    <link href=\"https://cdn.jsdelivr.net/npm/bootstrap@5.0.2/dist/css/bootstrap.min.css\" rel=\"stylesheet\">\n\n    <title>HSE Basic C&#43;&#43;</title>\n    <style>\n.navbar-brand {\n  font-size: 3rem;\n  font-weight: 300\n}\n    </style>\n  </head>\n  <body>\n    <nav class=\"navbar navbar-light bg-light\">\n      <div class=\"container\">\n        <div class=\"col col-xxl-4 offset-xxl-4 col-lg-6 offset-lg-3 col-md-10 offset-md-1\">\n          <p class=\"navbar-brand mb-0 h1 text-center\">Basic C++</p>\n        </div>\n      </div>\n    </nav>\n\n    <div class=\"container p-2 my-2\">\n      <div class=\"row p-2\">\n        <div class=\"col col-xxl-4 offset-xxl-4 col-lg-6 offset-lg-3 col-md-10 offset-md-1\">\n          <div class=\"card\">\n            <div class=\"card-body\">\n              <form method=\"post\" action=\"{{ .Config.Endpoints.Signup }}\" class=\"needs-validation was-validated\">\n                <div class=\"form-floating mb-3\">\n                  <input type=\"text\" class=\"form-control\" id=\"floatingFirstName\" placeholder=\"Ivan\" name=\"firstname\" required pattern=\"[A-Za-z-]+\">\n                  <label for=\"floatingFirstName\">First name</label>\n                  <div class=\"invalid-feedback\">\n                    Please use only Latin letters\n                  </div>\n                </div>\n                <div class=\"form-floating mb-3\">\n                  <input type=\"text\" class=\"form-control\" id=\"floatingLastName\" placeholder=\"Petrov\" name=\"lastname\" required pattern=\"[A-Za-z-]+\">\n                  <label for=\"floatingLastName\">Last name</label>\n                  <div class=\"invalid-feedback\">\n                    Please use only Latin letters\n                  </div>\n                </div>\n                <div class=\"form-floating mb-3\">\n                  <input type=\"text\" class=\"form-control\" id=\"floatingSecretCode\" placeholder=\"LolKekCheburek\" name=\"secret\" required pattern=\"[A-Za-z0-9-_]+\">\n                  <label for=\"floatingSecretCode\">Secret code</label>\n                  <div class=\"invalid-feedback\">\n                    Ask your teacher\n                  </div>\n                </div>\n\n                {{ if .ErrorMessage }}\n                <div class=\"alert alert-danger\" role=\"alert\">\n                    {{ .ErrorMessage }}\n                </div>\n                {{ end }}\n\n                <div class=\"d-grid mb-3\">\n                  <button type=\"submit\" class=\"btn btn-outline-success\">Sign up via GitLab</button>\n                </div>\n              </form>\n\n              <div class=\"d-grid\">\n                <a class=\"btn btn-outline-primary btn-block\" href=\"{{ .Config.Endpoints.Login }}\">Login via GitLab</a>\n              </div>\n            </div>\n          </div>\n        </div>\n      </div>\n    </div>\n\n  </body>\n</html>\n\nPK\x07\x08uN\x07\xf9I\x0b\x00\x00I\x0b\x00\x00PK\x03\x04\x14\x00\x08\x00\x00\x00\x10L0T\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x0e\x00	\x00standings.tmplUT\x05\x00\x010\xe6\xe3a<!doctype html>\n<html lang=\"en\">\n    <head>\n        <meta charset=\"utf-8\">\n        <meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n        <link href=\"https://cdn.jsdelivr.net/npm/bootstrap@5.0.2/dist/css/bootstrap.min.css\" rel=\"stylesheet\">\n\n        <title>{{ .Title }}</title>\n        <style>\n.shadow-hover:hover {\n    transition: all 0.1s ease;\n    box-shadow:0 .5rem 1rem rgba(0,0,0,.15)!important\n}\n.shadow-hover {\n    -webkit-transition: all 0.1s ease;\n    -moz-transition: all 0.1s ease;\n    -o-transition: all 0.1s ease;\n    transition: all 0.1s ease;\n    box-shadow:0 .125rem .25rem rgba(0,0,0,.075)!important\n}\n\n.task-success {\n    background-color: #a6e9d5;\n    border-color: #4dd4ac;\n}\n\n.task-failed {\n    background-color: #f8d7da;\n    border-color: #f1aeb5;\n}\n\n.task-checking {\n    border-color: #0d6efd;\n    background-color:#9ec5fe;\n}\n\n.task-assigned {\n    background-color: #f8f9fa;\n}\n\n.navbar-brand {\n    font-size: 3rem;\n    font-weight: 300\n}\n\n.nav-link {\n    color: rgba(0, 0, 0, 0.9);\n}\n\n.task {\n    width: 120px;\n    max-width: 120px;\n    overflow: hidden;\n}\n        </style>\n    </head>\n    <body>\n      <nav class=\"navbar navbar-light bg-light\">\n          <div class=\"container\">\n              <span class=\"navbar-brand mb-0 h1\"><a href=\"/\" class=\"text-decoration-none text-dark\">Basic C++</a></span>\n              <div class=\"row\">\n                  <div class=\"col-auto\">\n                      <a class=\"nav-link\" href=\"{{ .Links.Deadlines }}\"><h5>Tasks</h5></a>\n                  </div>\n                  <div class=\"col-auto\">\n                      <a class=\"nav-link\" href=\"{{ .Links.Standings }}\"><h5>Standings</h5></a>\n                  </div>\n                  <div class=\"col-auto\">\n                      <a class=\"nav-link\" href=\"{{ .Links.SubmitFlag }}\"><h5>Submit flag</h5></a>\n                  </div>\n                  <div class=\"col-auto\">\n                      <a class=\"nav-link\" href=\"{{ .Links.Repository }}\"><h5>My Repo</h5></a>\n                  </div>\n                  <div class=\"col-auto\">\n                      <a class=\"nav-link\" href=\"{{ .Links.Submits }}\"><h5>Submits</h5></a>\n                  </div>\n                  <div class=\"col-auto\">\n                      <a class=\"nav-link\" href=\"{{ .Links.Logout }}\"><h5>Logout</h5></a>\n                  </div>\n              </div>\n          </div>\n          </div>\n      </nav>\n\n        <div class=\"container p-2 my-2\">\n            <div class=\"container row\">\n                {{ range .Groups }}\n                    <div class=\"col-auto\">\n                      <a class=\"nav-link\" href=\"{{ .Link }}\"><h5>{{ .Name }}</h5></a>\n                    </div>\n                {{ end }}\n            </div>\n            <div class=\"table-responsive\">\n                <table class=\"table table-hover\">\n                    <thead>\n                        <tr>\n                            <th scope=\"col\" class=\"num\">#</th>\n                            <th scope=\"col\" class=\"name\">Student</th>\n                            <th scope=\"col\" class=\"name\">Group</th>\n                            <th scope=\"col\">Score</th>\n                            {{ range .Standings.Deadlines }}\n                                {{ range .Tasks }}\n                                    <th scope=\"col\" class=\"task\">{{ .Task }}</th>\n                                {{ end }}\n                            {{ end }}\n                        </tr>\n                    </thead>\n                    <tbody>\n                        {{ with index .Standings.Users 0 }}\n                            <tr>\n                                <th scope=\"row\" class=\"num\">0</th>\n                                <th scope=\"row\" class=\"name\">Chuck Norris</th>\n                                <th scope=\"row\" class=\"subgroup\"></th>\n                                <td>{{ .MaxScore }}</td>\n                                {{ range .Groups }}\n                                    {{ range .Tasks }}\n                                        <td class=\"task table-success\"><a href=\"/private/solutions/{{ .Task }}\" class=\"text-decoration-none text-dark\">{{ .MaxScore }}</a></td>\n                                    {{ end }}\n                                {{ end }}\n                            </tr>\n                        {{ end }}\n                        {{ range $index, $user := .Standings.Users }}\n                            <tr>\n                                <th scope=\"row\" class=\"num\">{{ inc $index }}</th>\n                                <th scope=\"row\" class=\"name\">{{ $user.User.FirstName }} {{ $user.User.LastName }}</th>\n                                <th scope=\"row\" class=\"subgroup\">\n                                    <a href=\"/standings/{{ $user.User.Group }}/{{ $user.User.Subgroup }}\" class=\"text-decoration-none text-dark\">\n                                        {{ $user.User.Subgroup }}\n                                    </a>\n                                </th>\n                                <td>{{ $user.Score }}</td>\n                                {{ range $user.Groups }}\n                                    {{ range .Tasks }}\n                                        {{ if eq .Status \"success\"}}\n                                            <td class=\"task table-success\">\n                                        {{ else if eq .Status \"failed\"}}\n                                            <td class=\"task table-danger\">\n                                        {{ else if eq .Status \"pending\"}}\n                                            <td class=\"task table-warning\">\n                                        {{ else if eq .Status \"on_review\"}}\n                                            <td class=\"task table-info\">\n                                        {{ else }}\n                                            <td class=\"task\">\n                                        {{ end }}\n                                        {{ if .PipelineUrl }}\n                                            <a href=\"{{ .PipelineUrl }}\" class=\"text-decoration-none text-dark\">\n                                        {{ end }}\n                                        {{ .Score }}\n                                        {{ if .PipelineUrl }}\n                                            </a>\n                                        {{ end }}\n                                        </td>\n                                    {{ end }}\n                                {{ end }}\n                            </tr>\n                        {{ end }}\n                    </tbody>\n                </table>\n            </div>\n        </div>\n    </body>\n</html>\nPK\x07\x08UR\xe6\xb6\xe5\x19\x00\x00\xe5\x19\x00\x00PK\x03\x04\x14\x00\x08\x00\x00\x00<c)T\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00	\x00	\x00style.cssUT\x05\x00\x01U\xd4\xdaabody {\n    margin: 0;\n    font-family: 'Source Code Pro', monospace;\n    display: flex;\n}\n\n.site {\n    max-width: 1200px;\n    width: 100%;\n\n    margin: 0 auto;\n    padding-left: 4em;\n    padding-right: 4em;\n\n    display: flex;\n    flex-direction: column;\n    align-items: center;\n}\n\n.header-container {\n    margin: 0 auto;\n    margin-top: 2em;\n\n    display: flex;\n}\n\n/* ========================================================================== */\n\n.main-menu {\n    padding: 0;\n    display: flex;\n    list-style: none;\n    color: #455a64;\n}\n\n.main-menu a {\n    text-decoration: none;\n    color: #455a64;\n}\n\n.main-menu li {\n    font-size: 1em;\n    text-transform: uppercase;\n    margin-left: 0.66em;\n}\n\n.main-menu li .current {\n    font-weight: bold;\n}\n\n/* ========================================================================== */\n\n.main {\n    width: 100%;\n    display: flex;\n    flex-direction: column;\n    align-items: center;\n}\n\n/* ========================================================================== */\n\n.flag-submit {\n    display: flex;\n    align-content: center;\n    margin: auto;\n}\n\n/* ========================================================================== */\n\n.group {\n    display: flex;\n    flex-direction: column;\n    width: 100%;\n}\n\n.group a {\n    text-decoration: none;\n}\n\n.group-header {\n    display: flex;\n}\n\n.group-header h1 {\n    white-space: pre;\n    margin: 0em;\n}\n\n.group-tasks {\n    display: flex;\n    flex-wrap: wrap;\n}\n\n.task {\n    width: 200px;\n    height: 120px;\n    margin: 10px;\n\n    display: flex;\n    flex-direction: column;\n    align-items: center;\n}\n\n.unsolved {\n    background-color: #1e3250;\n    color: white;\n}\n\n.solved {\n    background-color: #66cda3;\n    color: black;\n}\n\n.task .name {\n    margin: 0 auto;\n    margin-top: 0.33em;\n    font-size: 1.5em;\n    white-space: nowrap;\n}\n\n.task .score {\n    margin: 0 auto;\n    font-size: 3em;\n    font-weight: bold;\n}\n\n/* ========================================================================== */\n\n.signup {\n    width: 100%;\n    \n    display: flex;\n    flex-direction: column;\n    justify-content: center;\n    align-items: center;\n    margin: 2em;\n}\n\n.signup .login {\n    padding-top: 2em;\n    padding-bottom: 2em;\n\n    display: flex;\n}\n\n.login-button {\n    display: flex;\n\n    font-size: 2em;\n\n    margin: auto;\n    height: 80px;\n    width: 300px;\n\n    border: solid;\n    border-width: 1px;\n    border-color: #168f48;\n    background-color: #1aaa55;\n\n    text-decoration: none;\n}\n\n.login-button .text {\n    margin: auto;\n    color: white;\n}\n\n.signup .or {\n    display: flex;\n    min-width: 100px;\n}\n\n.or .text {\n    font-size: 1em;\n    margin: auto;\n}\n\n.signup .register {\n    display: flex;\n    padding-top: 2em;\n    padding-bottom: 2em;\n}\n\n.form {\n    width: 500px;\n\n    display: flex;\n    flex-direction: column;\n    \n    border: 1px solid #e5e5e5;\n}\n\n.form-header {\n    display: flex;\n    align-items: center;\n}\n\n.form-header h1 {\n    margin: 0 auto;\n    padding-top: 0.33em;\n    padding-bottom: 0.33em;\n    font-weight: normal;\n    font-size: 2em;\n}\n\n.form .form-element {\n    flex: 1;\n\n    margin: 0.33em;\n    margin-bottom: 0;\n\n    padding: 0.33em;\n    padding-bottom: 0;\n\n    display: flex;\n    flex-direction: column;\n}\n\n.form .form-element.last {\n    padding-bottom: 0.33em;\n    margin-bottom: 0.33em;\n}\n\n.form-element input {\n    flex: 1;\n    height: 40px;\n\n    font-size: 1.5em;\n    padding-left: 0.1em;\n    border: 1px solid #e5e5e5;\n}\n\n.form-element .button {\n    background-color: #1f78d1;\n    border-color: #1b69b6;\n    color: white;\n    cursor: pointer;\n    font-family: 'Source Code Pro', monospace;\n    font-size: 1em;\n}\n\n.form-element .name {\n    margin-left: 0.33em;\n    margin-bottom: 0.33em;\n    color: #555555;\n}\n\n.form .form-error {\n    background-color: #db3b21;\n}\n\n.form-error .error-message {\n    margin-left: 0.33em;\n    margin-bottom: 0.33em;\n    \n    color: white;\n}\n\n/* ========================================================================== */\n\n.status {\n    display: flex;\n    flex-direction: column;\n    width: 400px;\n    margin-right: 60px;\n}\n\n.status h1 {\n    margin-left: auto;\n    margin-right: auto;\n}\n\ntable {\n    border-spacing: 0.66em;\n}\n\ntable td {\n    text-align: center;\n}\n\ntable th {\n    text-align: center;\n}\nPK\x07\x08\xff\x8bCA\x9d\x10\x00\x00\x9d\x10\x00\x00PK\x01\x02\x14\x03\x14\x00\x08\x00\x00\x00\xbaJ0T\xd5\x8c\x8am,\x0c\x00\x00,\x0c\x00\x00	\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xb4\x81\x00\x00\x00\x00flag.tmplUT\x05\x00\x01\xb1\xe3\xe3aPK\x01\x02\x14\x03\x14\x00\x08\x00\x00\x00\xbaJ0T\x86;\x12\x8f|\x11\x00\x00|\x11\x00\x00	\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xb4\x81l\x0c\x00\x00home.tmplUT\x05\x00\x01\xb1\xe3\xe3aPK\x01\x02\x14\x03\x14\x00\x08\x00\x00\x00<c)TLn\xf0\x0c\x05\x00\x00\x00\x05\x00\x00\x00\x08\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xb4\x81(\x1e\x00\x00kek.htmlUT\x05\x00\x01U\xd4\xdaaPK\x01\x02\x14\x03\x14\x00\x08\x00\x00\x00\xbaJ0TuN\x07\xf9I\x0b\x00\x00I\x0b\x00\x00\x0b\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xb4\x81l\x1e\x00\x00signup.tmplUT\x05\x00\x01\xb1\xe3\xe3aPK\x01\x02\x14\x03\x14\x00\x08\x00\x00\x00\x10L0TUR\xe6\xb6\xe5\x19\x00\x00\xe5\x19\x00\x00\x0e\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xb4\x81\xf7)\x00\x00standings.tmplUT\x05\x00\x010\xe6\xe3aPK\x01\x02\x14\x03\x14\x00\x08\x00\x00\x00<c)T\xff\x8bCA\x9d\x10\x00\x00\x9d\x10\x00\x00	\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xb4\x81!D\x00\x00style.cssUT\x05\x00\x01U\xd4\xdaaPK\x05\x06\x00\x00\x00\x00\x06\x00\x06\x00\x86\x01\x00\x00\xfeT\x00\x00\x00\x00"
+	data := "PK\x03\x04\x14\x00\x08\x00\x00\x00JYR]\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\n\x00	\x00admin.tmplUT\x05\x00\x01\x1d\xa9\xd4j<!doctype html>\n<html lang=\"en\">\n    <head>\n        <meta charset=\"utf-8\">\n        <meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n        <link href=\"https://cdn.jsdelivr.net/npm/bootstrap@5.0.2/dist/css/bootstrap.min.css\" rel=\"stylesheet\">\n\n        <title>{{ .Title }}</title>\n        <style>\n.navbar-brand {\n    font-size: 3rem;\n    font-weight: 300\n}\n\n.nav-link {\n    color: rgba(0, 0, 0, 0.9);\n}\n        </style>\n    </head>\n    <body>\n        {{ template \"navbar\" . }}\n\n        <div class=\"container p-2 my-2\">\n            <div class=\"container row\">\n                <div class=\"col-auto\">\n                    <a class=\"nav-link\" href=\"{{ .Config.Endpoints.Admin.Overrides }}\"><h5>Score overrides</h5></a>\n                </div>\n                <div class=\"col-auto\">\n                    <a class=\"nav-link\" href=\"{{ .Config.Endpoints.Admin.Flags }}\"><h5>Foreign flags</h5></a>\n                </div>\n                <div class=\"col-auto\">\n                    <a class=\"nav-link\" href=\"{{ .Config.Endpoints.Admin.TemplateUpdates }}\"><h5>Template updates</h5></a>\n                </div>\n                <div class=\"col-auto\">\n                    <a class=\"nav-link\" href=\"{{ .Config.Endpoints.Admin.Provisioning }}\"><h5>Provisioning</h5></a>\n                </div>\n                <div class=\"col-auto\">\n                    <a class=\"nav-link\" href=\"{{ .Config.Endpoints.Admin.Deadlines }}\"><h5>Deadlines</h5></a>\n                </div>\n                <div class=\"col-auto\">\n                    <a class=\"nav-link\" href=\"{{ .Config.Endpoints.Admin.Users }}\"><h5>All</h5></a>\n                </div>\n                {{ range .Groups }}\n                    <div class=\"col-auto\">\n                        <a class=\"nav-link\" href=\"{{ .Link }}\"><h5>{{ .Name }}</h5></a>\n                    </div>\n                {{ end }}\n            </div>\n\n            {{ if .Exports }}\n            <div class=\"container row py-2\">\n                {{ range .Exports }}\n                    <div class=\"col-auto\">\n                        <a href=\"{{ .Link }}\" class=\"btn btn-sm btn-outline-success\">Export {{ .Format }}</a>\n                    </div>\n                {{ end }}\n            </div>\n            {{ end }}\n\n            {{ if .ErrorMessage }}\n            <div class=\"alert alert-danger\" role=\"alert\">\n                {{ .ErrorMessage }}\n            </div>\n            {{ end }}\n\n            <div class=\"table-responsive\">\n                <table class=\"table table-hover\">\n                    <thead>\n                        <tr>\n                            <th scope=\"col\">Student</th>\n                            <th scope=\"col\">GitLab</th>\n                            <th scope=\"col\">Group</th>\n                            <th scope=\"col\">Subgroup</th>\n                            <th scope=\"col\">Repository</th>\n                            <th scope=\"col\"></th>\n                        </tr>\n                    </thead>\n                    <tbody>\n                        {{ range .Users }}\n                            <tr>\n                                <th scope=\"row\">\n                                    {{ .FirstName }} {{ .LastName }}\n                                    {{ if .IsAdmin }}<span class=\"badge bg-secondary\">admin</span>{{ end }}\n                                </th>\n                                <td>{{ if .GitlabLogin }}{{ .GitlabLogin }}{{ end }}</td>\n                                <td>{{ .GroupName }}</td>\n                                <td>{{ .SubgroupName }}</td>\n                                <td>{{ if .Repository }}<a href=\"{{ .Repository }}\" class=\"text-decoration-none\">{{ .Repository }}</a>{{ end }}</td>\n                                <td>\n                                    {{ if .HomeLink }}\n                                        <a href=\"{{ .HomeLink }}\" class=\"btn btn-sm btn-outline-primary\">Home</a>\n                                        <a href=\"{{ .StandingsLink }}\" class=\"btn btn-sm btn-outline-secondary\">Standings</a>\n                                    {{ end }}\n                                </td>\n                            </tr>\n                        {{ end }}\n                    </tbody>\n                </table>\n            </div>\n        </div>\n    </body>\n</html>\nPK\x07\x08a\xb5\x97\xdc\xa3\x10\x00\x00\xa3\x10\x00\x00PK\x03\x04\x14\x00\x08\x00\x00\x00MYR]\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x0d\x00	\x00calendar.tmplUT\x05\x00\x01#\xa9\xd4j<!doctype html>\n<html lang=\"en\">\n    <head>\n        <meta charset=\"utf-8\">\n        <meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n        <link href=\"https://cdn.jsdelivr.net/npm/bootstrap@5.0.2/dist/css/bootstrap.min.css\" rel=\"stylesheet\">\n\n        <title>{{ .Title }}</title>\n        <style>\n.navbar-brand {\n    font-size: 3rem;\n    font-weight: 300\n}\n\n.nav-link {\n    color: rgba(0, 0, 0, 0.9);\n}\n        </style>\n    </head>\n    <body>\n        {{ template \"navbar\" . }}\n\n        <div class=\"container p-2 my-2\">\n            <div class=\"row p-2\">\n                <div class=\"col col-lg-8 offset-lg-2\">\n                    <div class=\"card\">\n                        <div class=\"card-body\">\n                            <h3 class=\"card-title\">Deadlines calendar</h3>\n                            <p class=\"card-text\">\n                                Subscribe to the feed in your calendar app to get deadlines with personal extensions and reminders a day before.\n                                Anyone with the link can see your deadlines, regenerate it if it leaked.\n                            </p>\n                            {{ if .Token }}\n                                <p class=\"card-text\">Personal deadlines:</p>\n                                <p class=\"card-text\"><code class=\"text-break\">{{ .FeedUrl }}</code></p>\n                                <p class=\"card-text\"><a href=\"{{ .WebcalUrl }}\" class=\"btn btn-sm btn-outline-primary\">Subscribe</a></p>\n                                <p class=\"card-text\">Deadlines of the group:</p>\n                                <p class=\"card-text\"><code class=\"text-break\">{{ .GroupFeedUrl }}</code></p>\n                                <p class=\"card-text\"><a href=\"{{ .GroupWebcalUrl }}\" class=\"btn btn-sm btn-outline-primary\">Subscribe</a></p>\n                            {{ else }}\n                                <p class=\"card-text text-muted\">You do not have a calendar link yet</p>\n                            {{ end }}\n                            <form method=\"post\" action=\"{{ .Links.Calendar }}\">\n                                <div class=\"d-grid\">\n                                    <button type=\"submit\" class=\"btn btn-outline-danger\">{{ if .Token }}Regenerate{{ else }}Generate{{ end }} link</button>\n                                </div>\n                            </form>\n                        </div>\n                    </div>\n                </div>\n            </div>\n        </div>\n    </body>\n</html>\nPK\x07\x08\xdbz\xa2\x16\xc0	\x00\x00\xc0	\x00\x00PK\x03\x04\x14\x00\x08\x00\x00\x00DYR]\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\n\x00	\x00chart.tmplUT\x05\x00\x01\x10\xa9\xd4j{{ define \"scoresChart\" }}\n{{ if .HistoryUrl }}\n<div class=\"container p-2 my-2\">\n    <h3>Progress</h3>\n    <canvas id=\"scores-chart\" height=\"80\"></canvas>\n</div>\n<script src=\"https://cdn.jsdelivr.net/npm/chart.js@3.5.1/dist/chart.min.js\"></script>\n<script>\nfetch({{ .HistoryUrl }}, {credentials: \"same-origin\"})\n    .then(response => response.json())\n    .then(body => {\n        const canvas = document.getElementById(\"scores-chart\");\n        const histories = body.users || (body.history ? [body.history] : []);\n        const datasets = histories.filter(h => h.points.length > 0).map(h => ({\n            label: h.login,\n            data: h.points.map(p => ({x: Date.parse(p.time), y: p.score})),\n            fill: false,\n        }));\n        if (!body.ok || datasets.length === 0) {\n            canvas.parentElement.remove();\n            return;\n        }\n        new Chart(canvas, {\n            type: \"line\",\n            data: {datasets: datasets},\n            options: {\n                plugins: {legend: {display: datasets.length <= 30}},\n                scales: {x: {type: \"linear\", ticks: {callback: value => new Date(value).toLocaleDateString()}}},\n            },\n        });\n    });\n</script>\n{{ end }}\n{{ end }}\nPK\x07\x08\x92\xe5\xef%\xc5\x04\x00\x00\xc5\x04\x00\x00PK\x03\x04\x14\x00\x08\x00\x00\x00\x15YR]\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x0c\x00	\x00courses.tmplUT\x05\x00\x01\xbb\xa8\xd4j<!doctype html>\n<html lang=\"en\">\n    <head>\n        <meta charset=\"utf-8\">\n        <meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n        <link href=\"https://cdn.jsdelivr.net/npm/bootstrap@5.0.2/dist/css/bootstrap.min.css\" rel=\"stylesheet\">\n\n        <title>{{ .Title }}</title>\n    </head>\n    <body>\n        <div class=\"container p-2 my-2\">\n            <h1>{{ .Title }}</h1>\n            <div class=\"list-group\">\n                {{ range .Courses }}\n                <a href=\"{{ .Endpoints.Home }}\" class=\"list-group-item list-group-item-action\">{{ .Title }}</a>\n                {{ end }}\n            </div>\n        </div>\n    </body>\n</html>\nPK\x07\x08%S\x13\x8d\x98\x02\x00\x00\x98\x02\x00\x00PK\x03\x04\x14\x00\x08\x00\x00\x00LYR]\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x0e\x00	\x00deadlines.tmplUT\x05\x00\x01 \xa9\xd4j<!doctype html>\n<html lang=\"en\">\n    <head>\n        <meta charset=\"utf-8\">\n        <meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n        <link href=\"https://cdn.jsdelivr.net/npm/bootstrap@5.0.2/dist/css/bootstrap.min.css\" rel=\"stylesheet\">\n\n        <title>{{ .Title }}</title>\n        <style>\n.navbar-brand {\n    font-size: 3rem;\n    font-weight: 300\n}\n\n.nav-link {\n    color: rgba(0, 0, 0, 0.9);\n}\n        </style>\n    </head>\n    <body>\n        {{ template \"navbar\" . }}\n\n\n        <div class=\"container p-2 my-2\">\n            <h1>Deadlines</h1>\n            <p class=\"text-muted\">Groups whose deadlines failed to load or validate keep serving the last good version</p>\n\n            {{ if .ErrorMessage }}\n            <div class=\"alert alert-danger\" role=\"alert\">\n                {{ .ErrorMessage }}\n            </div>\n            {{ end }}\n\n            <div class=\"table-responsive\">\n                <table class=\"table table-hover\">\n                    <thead>\n                        <tr>\n                            <th scope=\"col\">Group</th>\n                            <th scope=\"col\">Source</th>\n                            <th scope=\"col\">Tasks</th>\n                            <th scope=\"col\">Loaded</th>\n                            <th scope=\"col\">Checked</th>\n                            <th scope=\"col\">Error</th>\n                        </tr>\n                    </thead>\n                    <tbody>\n                        {{ range .Statuses }}\n                            <tr{{ if .Error }} class=\"table-danger\"{{ end }}>\n                                <td>{{ .Group }}</td>\n                                <td class=\"text-break\">{{ .Source }}</td>\n                                <td>{{ .Tasks }}</td>\n                                <td>{{ formatDate $.Location .LoadedAt \"02-01-2006 15:04:05\" }}</td>\n                                <td>{{ formatDate $.Location .CheckedAt \"02-01-2006 15:04:05\" }}</td>\n                                <td class=\"text-break\">{{ .Error }}</td>\n                            </tr>\n                        {{ end }}\n                    </tbody>\n                </table>\n            </div>\n\n            <h3>Revisions</h3>\n            <div class=\"table-responsive\">\n                <table class=\"table table-hover\">\n                    <thead>\n                        <tr>\n                            <th scope=\"col\">Created</th>\n                            <th scope=\"col\">Group</th>\n                            <th scope=\"col\">Changes</th>\n                        </tr>\n                    </thead>\n                    <tbody>\n                        {{ range .Revisions }}\n                            <tr>\n                                <td>{{ formatDate $.Location .CreatedAt \"02-01-2006 15:04:05\" }}</td>\n                                <td>{{ .GroupName }}</td>\n                                <td>\n                                    {{ range .ChangeList }}\n                                    <div class=\"font-monospace small\">{{ . }}</div>\n                                    {{ else }}\n                                    <span class=\"text-muted\">No changes</span>\n                                    {{ end }}\n                                </td>\n                            </tr>\n                        {{ end }}\n                    </tbody>\n                </table>\n            </div>\n        </div>\n    </body>\n</html>\nPK\x07\x08G\xf57\xb4L\x0d\x00\x00L\x0d\x00\x00PK\x03\x04\x14\x00\x08\x00\x00\x00IYR]\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00	\x00	\x00flag.tmplUT\x05\x00\x01\x1a\xa9\xd4j<!doctype html>\n<html lang=\"en\">\n  <head>\n    <meta charset=\"utf-8\">\n    <meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n    <link href=\"https://cdn.jsdelivr.net/npm/bootstrap@5.0.2/dist/css/bootstrap.min.css\" rel=\"stylesheet\">\n\n    <title>{{ .CourseName }}</title>\n    <style>\n.navbar-brand {\n  font-size: 3rem;\n  font-weight: 300\n}\n\n#floatingFlag {\n  font-family: monospace;\n}\n    </style>\n  </head>\n  <body>\n      {{ template \"navbar\" . }}\n\n    <div class=\"container p-2 my-2\">\n      <div class=\"row p-2\">\n        <div class=\"col col-lg-6 offset-lg-3 col-md-10 offset-md-1\">\n          <div class=\"card\">\n            <div class=\"card-body\">\n              <form method=\"post\" action=\"{{ .Links.SubmitFlag }}\" class=\"needs-validation was-validated\">\n                <div class=\"form-floating mb-3\">\n                  <input type=\"text\" class=\"form-control\" id=\"floatingFlag\" placeholder=\"Flag\" name=\"flag\" required pattern=\"\\{FLAG(-[a-z0-9_]+)+(-[0-9a-f]+)+\\}\">\n                  <label for=\"floatingFlag\">Flag value</label>\n                  <div class=\"invalid-feedback\">\n                    Flag should be in form <code>{FLAG-crashme-61e1a0c4-9287ffaa8b0e4e6d891516ef0a1b2c3d}</code>\n                  </div>\n                </div>\n\n              {{ if .ErrorMessage }}\n              <div class=\"alert alert-danger\" role=\"alert\">\n                {{ .ErrorMessage }}\n              </div>\n              {{ end }}\n\n              {{ if .SuccessMessage }}\n              <div class=\"alert alert-success\" role=\"alert\">\n                {{ .SuccessMessage }}\n              </div>\n              {{ end }}\n\n                <div class=\"d-grid\">\n                  <button type=\"submit\" class=\"btn btn-outline-success\">Submit flag</button>\n                </div>\n              </form>\n\n            </div>\n          </div>\n        </div>\n      </div>\n\n      {{ if .Submissions }}\n      <div class=\"row p-2\">\n        <div class=\"col col-lg-6 offset-lg-3 col-md-10 offset-md-1\">\n          <h5>Your submissions</h5>\n          <div class=\"table-responsive\">\n            <table class=\"table table-sm table-hover\">\n              <thead>\n                <tr>\n                  <th scope=\"col\">Time</th>\n                  <th scope=\"col\">Task</th>\n                  <th scope=\"col\">Result</th>\n                </tr>\n              </thead>\n              <tbody>\n                {{ range .Submissions }}\n                  <tr>\n                    <td>{{ formatDate $.Location .CreatedAt }}</td>\n                    <td>{{ .Task }}</td>\n                    {{ if eq .Outcome \"accepted\" }}\n                      <td class=\"table-success\">{{ .Outcome }}</td>\n                    {{ else if eq .Outcome \"duplicate\" }}\n                      <td class=\"table-warning\">{{ .Outcome }}</td>\n                    {{ else }}\n                      <td class=\"table-danger\">{{ .Outcome }}</td>\n                    {{ end }}\n                  </tr>\n                {{ end }}\n              </tbody>\n            </table>\n          </div>\n        </div>\n      </div>\n      {{ end }}\n    </div>\n\n  </body>\n</html>\n\n\nPK\x07\x08\xaf\xbf\x13\x8c\x18\x0c\x00\x00\x18\x0c\x00\x00PK\x03\x04\x14\x00\x08\x00\x00\x00IYR]\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x12\x00	\x00foreign_flags.tmplUT\x05\x00\x01\x1a\xa9\xd4j<!doctype html>\n<html lang=\"en\">\n    <head>\n        <meta charset=\"utf-8\">\n        <meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n        <link href=\"https://cdn.jsdelivr.net/npm/bootstrap@5.0.2/dist/css/bootstrap.min.css\" rel=\"stylesheet\">\n\n        <title>{{ .Title }}</title>\n        <style>\n.navbar-brand {\n    font-size: 3rem;\n    font-weight: 300\n}\n\n.nav-link {\n    color: rgba(0, 0, 0, 0.9);\n}\n        </style>\n    </head>\n    <body>\n        {{ template \"navbar\" . }}\n\n\n        <div class=\"container p-2 my-2\">\n            <h1>Foreign flags</h1>\n            <p class=\"text-muted\">Flags submitted by a student other than the one they were issued for</p>\n\n            {{ if .ErrorMessage }}\n            <div class=\"alert alert-danger\" role=\"alert\">\n                {{ .ErrorMessage }}\n            </div>\n            {{ end }}\n\n            <div class=\"table-responsive\">\n                <table class=\"table table-hover\">\n                    <thead>\n                        <tr>\n                            <th scope=\"col\">Time</th>\n                            <th scope=\"col\">Submitted by</th>\n                            <th scope=\"col\">Issued for</th>\n                            <th scope=\"col\">Task</th>\n                            <th scope=\"col\">Result</th>\n                            <th scope=\"col\">IP</th>\n                        </tr>\n                    </thead>\n                    <tbody>\n                        {{ range .Submissions }}\n                            <tr>\n                                <td>{{ formatDate $.Location .CreatedAt }}</td>\n                                <td>{{ .GitlabLogin }}</td>\n                                <td>{{ if .FlagOwner }}{{ .FlagOwner }}{{ end }}</td>\n                                <td>{{ .Task }}</td>\n                                <td>{{ .Outcome }}</td>\n                                <td>{{ .IP }}</td>\n                            </tr>\n                        {{ end }}\n                    </tbody>\n                </table>\n            </div>\n        </div>\n    </body>\n</html>\nPK\x07\x08\x8dZ\xda\xa3\x18\x08\x00\x00\x18\x08\x00\x00PK\x03\x04\x14\x00\x08\x00\x00\x00U[R]\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00	\x00	\x00home.tmplUT\x05\x00\x01\xf3\xac\xd4j<!doctype html>\n<html lang=\"en\">\n    <head>\n        <meta charset=\"utf-8\">\n        <meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n        <link href=\"https://cdn.jsdelivr.net/npm/bootstrap@5.0.2/dist/css/bootstrap.min.css\" rel=\"stylesheet\">\n\n        <title>{{ .Title }}</title>\n        <style>\n.shadow-hover:hover {\n    transition: all 0.1s ease;\n    box-shadow:0 .5rem 1rem rgba(0,0,0,.15)!important\n}\n.shadow-hover {\n    -webkit-transition: all 0.1s ease;\n    -moz-transition: all 0.1s ease;\n    -o-transition: all 0.1s ease;\n    transition: all 0.1s ease;\n    box-shadow:0 .125rem .25rem rgba(0,0,0,.075)!important\n}\n\n.task {\n    overflow: hidden;\n}\n\n.task-success {\n    background-color: #a6e9d5;\n    border-color: #4dd4ac;\n}\n\n.task-failed {\n    background-color: #f8d7da;\n    border-color: #f1aeb5;\n}\n\n.task-checking {\n    border-color: #0d6efd;\n    background-color:#9ec5fe;\n}\n\n.task-assigned {\n    background-color: #f8f9fa;\n}\n\n.task-exempt {\n    background-color: #e2e3e5;\n    border-color: #c4c8cb;\n}\n\n.navbar-brand {\n  font-size: 3rem;\n  font-weight: 300\n}\n\n.nav-link {\n  color: rgba(0, 0, 0, 0.9);\n}\n        </style>\n    </head>\n    <body>\n        {{ template \"navbar\" . }}\n\n        {{ if .Student }}\n            <div class=\"container p-2 my-2\">\n                <div class=\"card\">\n                    <div class=\"card-body\">\n                        <h3 class=\"card-title\">{{ .Student.FirstName }} {{ .Student.LastName }} <span class=\"text-muted\">{{ .Student.GitlabLogin }}, {{ .Student.GroupName }}/{{ .Student.SubgroupName }}</span></h3>\n                        {{ if .Extensions }}\n                            <table class=\"table table-sm\">\n                                <thead>\n                                    <tr>\n                                        <th scope=\"col\">Target</th>\n                                        <th scope=\"col\">Deadline</th>\n                                        <th scope=\"col\">Reason</th>\n                                        <th scope=\"col\">Granted by</th>\n                                    </tr>\n                                </thead>\n                                <tbody>\n                                    {{ range .Extensions }}\n                                        <tr>\n                                            <td>{{ .TaskGroup }}{{ .Task }}</td>\n                                            <td>{{ if .Exempt }}Exempt{{ else }}{{ formatDate $.Location .Deadline }}{{ end }}</td>\n                                            <td>{{ .Reason }}</td>\n                                            <td>{{ .GrantedBy }}</td>\n                                        </tr>\n                                    {{ end }}\n                                </tbody>\n                            </table>\n                        {{ end }}\n                        {{ if .Scores }}\n                            <form method=\"post\" action=\"{{ .Config.Endpoints.Admin.Extensions }}\" class=\"row g-2\">\n                                <input type=\"hidden\" name=\"login\" value=\"{{ .Student.GitlabLogin }}\">\n                                <div class=\"col-md-4\">\n                                    <select class=\"form-select\" name=\"target\" required>\n                                        {{ range .Scores.Groups }}\n                                            <optgroup label=\"{{ .PrettyTitle }}\">\n                                                <option value=\"group:{{ .Title }}\">Whole group</option>\n                                                {{ range .Tasks }}\n                                                    <option value=\"task:{{ .Task }}\">{{ .Task }}</option>\n                                                {{ end }}\n                                            </optgroup>\n                                        {{ end }}\n                                    </select>\n                                </div>\n                                <div class=\"col-md-2\">\n                                    <input type=\"datetime-local\" class=\"form-control\" name=\"deadline\">\n                                </div>\n                                <div class=\"col-md-1 d-flex align-items-center\">\n                                    <div class=\"form-check\">\n                                        <input class=\"form-check-input\" type=\"checkbox\" name=\"exempt\" value=\"1\" id=\"exempt\">\n                                        <label class=\"form-check-label\" for=\"exempt\">Exempt</label>\n                                    </div>\n                                </div>\n                                <div class=\"col-md-3\">\n                                    <input type=\"text\" class=\"form-control\" name=\"reason\" placeholder=\"Reason\">\n                                </div>\n                                <div class=\"col-md-2 d-grid\">\n                                    <button type=\"submit\" class=\"btn btn-outline-primary\">Grant extension</button>\n                                </div>\n                            </form>\n                            <form method=\"post\" action=\"{{ .Config.Endpoints.Admin.Overrides }}\" class=\"row g-2 mt-1\">\n                                <input type=\"hidden\" name=\"login\" value=\"{{ .Student.GitlabLogin }}\">\n                                <div class=\"col-md-3\">\n                                    <select class=\"form-select\" name=\"task\" required>\n                                        {{ range .Scores.Groups }}\n                                            <optgroup label=\"{{ .PrettyTitle }}\">\n                                                {{ range .Tasks }}\n                                                    <option value=\"{{ .Task }}\">{{ .Task }}</option>\n                                                {{ end }}\n                                            </optgroup>\n                                        {{ end }}\n                                    </select>\n                                </div>\n                                <div class=\"col-md-2\">\n                                    <select class=\"form-select\" name=\"kind\" required>\n                                        <option value=\"set\">Set score</option>\n                                        <option value=\"delta\">Add to score</option>\n                                        <option value=\"reset\">Reset override</option>\n                                    </select>\n                                </div>\n                                <div class=\"col-md-2\">\n                                    <input type=\"number\" class=\"form-control\" name=\"score\" placeholder=\"Score\">\n                                </div>\n                                <div class=\"col-md-3\">\n                                    <input type=\"text\" class=\"form-control\" name=\"comment\" placeholder=\"Comment\" required>\n                                </div>\n                                <div class=\"col-md-2 d-grid\">\n                                    <button type=\"submit\" class=\"btn btn-outline-danger\">Override score</button>\n                                </div>\n                            </form>\n                        {{ end }}\n                        {{ if .CanSyncTemplate }}\n                            <form method=\"post\" action=\"{{ .Config.Endpoints.Admin.SyncTemplate }}\" class=\"row g-2 mt-1\">\n                                <input type=\"hidden\" name=\"login\" value=\"{{ .Student.GitlabLogin }}\">\n                                <div class=\"col-md-2 d-grid\">\n                                    <button type=\"submit\" class=\"btn btn-outline-secondary\">Sync template</button>\n                                </div>\n                            </form>\n                        {{ end }}\n                    </div>\n                </div>\n            </div>\n        {{ end }}\n\n        {{ if .Scores }}\n            {{ with .Scores.Upcoming }}\n                <div class=\"container p-2 my-2\">\n                    <div class=\"alert alert-info mb-0\">\n                        <strong>{{ .PrettyTitle }}</strong> starts in\n                        <span data-countdown=\"{{ .Start.Format \"2006-01-02T15:04:05Z07:00\" }}\">{{ formatDate $.Location .Start }}</span>\n                    </div>\n                </div>\n            {{ end }}\n            {{ range .Scores.Groups }}\n                <div class=\"container p-2 my-2\">\n                    <div class=\"p-2\">\n                        <a name=\"{{ .PrettyTitle }}\" href=\"#{{ .PrettyTitle }}\" class=\"text-decoration-none text-dark\">\n                            <h1>{{ .PrettyTitle }} <span class=\"text-muted\">{{ formatDate $.Location .Deadline }}</span></h1>\n                        </a>\n                        {{ if .Extension }}\n                            <span class=\"badge bg-info text-dark fs-6\">{{ if .Extension.Exempt }}Exempt{{ else }}Extended until {{ formatDate $.Location .Extension.Deadline }}{{ end }}{{ if .Extension.Reason }}: {{ .Extension.Reason }}{{ end }}</span>\n                        {{ end }}\n                        {{ if not .Released }}\n                            <span class=\"badge bg-secondary fs-6\">Hidden from students until {{ formatDate $.Location .Start }}</span>\n                        {{ end }}\n                    </div>\n                    <div class=\"row row-cols-1 row-cols-sm-2 row-cols-md-3 row-cols-lg-4 row-cols-xl-5 g-4 text-center\">\n                        {{ range .Tasks }}\n                            <div class=\"col\">\n                                <a href=\"{{ .TaskUrl }}\" class=\"text-decoration-none text-dark\">\n                                    <div class=\"card h-100 task task-{{ .Status }} shadow-hover\">\n                                        <div class=\"card-body\">\n                                            <h3 class=\"card-title text-nowrap text-dark\">{{ .ShortName }}</h3>\n                                            {{ if .PipelineUrl }}\n                                                <a href=\"{{ .PipelineUrl }}\" class=\"text-decoration-none\">\n                                            {{ end }}\n                                                <p class=\"card-text fs-1 text-decoration-none text-dark\">\n                                                    {{.Score}} / {{.MaxScore}}{{ if .Override }}<sup title=\"{{ .Override.Comment }} ({{ .Override.Author }})\">*</sup>{{ end }}\n                                                </p>\n                                            {{ if .PipelineUrl }}\n                                                </a>\n                                            {{ end }}\n                                            {{ if .Extension }}\n                                                <p class=\"card-text text-muted\">{{ if .Extension.Exempt }}Exempt{{ else }}Extended until {{ formatDate $.Location .Extension.Deadline }}{{ end }}</p>\n                                            {{ end }}\n                                            <a href=\"{{ $.Config.Endpoints.Task }}?task={{ .Task }}{{ if $.Student }}&login={{ $.Student.GitlabLogin }}{{ end }}\" class=\"card-link text-muted\">Attempts</a>\n                                        </div>\n                                    </div>\n                                </a>\n                            </div>\n                        {{ end }}\n                    </div>\n\n                    <div class=\"p-2\">\n                        <h1>Total score: {{ .Score }} / {{ .MaxScore }}</h1>\n                    </div>\n                </div>\n            {{ end }}\n            {{ with .Scores.FinalGrade }}\n                <div class=\"container p-2\">\n                    <h1>Final grade: {{ .Grade }}</h1>\n                    {{ range .Failures }}\n                        <p class=\"text-danger\">{{ . }}</p>\n                    {{ end }}\n                </div>\n            {{ end }}\n        {{ end}}\n        {{ template \"scoresChart\" . }}\n        <script>\nfor (const element of document.querySelectorAll(\"[data-countdown]\")) {\n    const start = new Date(element.dataset.countdown);\n    const reloadsKey = \"countdown-reloads-\" + element.dataset.countdown;\n    const update = () => {\n        const left = Math.max(0, Math.floor((start - Date.now()) / 1000));\n        if (left === 0) {\n            // Clocks of the server and the browser differ, so reload with a growing delay and give up after a few tries\n            const reloads = Number(sessionStorage.getItem(reloadsKey) || 0);\n            if (reloads >= 5) {\n                element.textContent = \"a moment, refresh the page later\";\n                return;\n            }\n            sessionStorage.setItem(reloadsKey, reloads + 1);\n            element.textContent = \"a moment\";\n            setTimeout(() => location.reload(), 2000 * 2 ** reloads);\n            return;\n        }\n        const days = Math.floor(left / 86400);\n        const hours = String(Math.floor(left % 86400 / 3600)).padStart(2, \"0\");\n        const minutes = String(Math.floor(left % 3600 / 60)).padStart(2, \"0\");\n        const seconds = String(left % 60).padStart(2, \"0\");\n        element.textContent = (days > 0 ? days + \"d \" : \"\") + hours + \":\" + minutes + \":\" + seconds;\n        setTimeout(update, 1000);\n    };\n    update();\n}\n        </script>\n    </body>\n</html>\nPK\x07\x08\xd8\xa3\xf5._3\x00\x00_3\x00\x00PK\x03\x04\x14\x00\x08\x00\x00\x00\xb6L0T\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x08\x00	\x00kek.htmlUT\x05\x00\x01i\xe7\xe3akek!\nPK\x07\x08Ln\xf0\x0c\x05\x00\x00\x00\x05\x00\x00\x00PK\x03\x04\x14\x00\x08\x00\x00\x00MYR]\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x0b\x00	\x00navbar.tmplUT\x05\x00\x01#\xa9\xd4j{{ define \"navbar\" }}\n<nav class=\"navbar navbar-light bg-light\">\n    <div class=\"container\">\n        <span class=\"navbar-brand mb-0 h1\"><a href=\"{{ .Config.Endpoints.Home }}\" class=\"text-decoration-none text-dark\">{{ .CourseName }}</a></span>\n        <div class=\"row\">\n            <div class=\"col-auto\">\n                <a class=\"nav-link\" href=\"{{ .Links.Deadlines }}\"><h5>Tasks</h5></a>\n            </div>\n            <div class=\"col-auto\">\n                <a class=\"nav-link\" href=\"{{ .Links.Standings }}\"><h5>Standings</h5></a>\n            </div>\n            <div class=\"col-auto\">\n                <a class=\"nav-link\" href=\"{{ .Links.SubmitFlag }}\"><h5>Submit flag</h5></a>\n            </div>\n            <div class=\"col-auto\">\n                <a class=\"nav-link\" href=\"{{ .Links.Repository }}\"><h5>My Repo</h5></a>\n            </div>\n            <div class=\"col-auto\">\n                <a class=\"nav-link\" href=\"{{ .Links.Submits }}\"><h5>Submits</h5></a>\n            </div>\n            <div class=\"col-auto\">\n                <a class=\"nav-link\" href=\"{{ .Links.ApiToken }}\"><h5>API</h5></a>\n            </div>\n            <div class=\"col-auto\">\n                <a class=\"nav-link\" href=\"{{ .Links.Calendar }}\"><h5>Calendar</h5></a>\n            </div>\n            <div class=\"col-auto\">\n                <a class=\"nav-link\" href=\"{{ .Links.Sessions }}\"><h5>Sessions</h5></a>\n            </div>\n            {{ if .Links.Admin }}\n            <div class=\"col-auto\">\n                <a class=\"nav-link\" href=\"{{ .Links.Admin }}\"><h5>Admin</h5></a>\n            </div>\n            {{ end }}\n            <div class=\"col-auto\">\n                <a class=\"nav-link\" href=\"{{ .Links.Logout }}\"><h5>Logout</h5></a>\n            </div>\n        </div>\n    </div>\n</nav>\n{{ end }}\nPK\x07\x08\x04\x1a:\n\xe7\x06\x00\x00\xe7\x06\x00\x00PK\x03\x04\x14\x00\x08\x00\x00\x00IYR]\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x0e\x00	\x00overrides.tmplUT\x05\x00\x01\x1a\xa9\xd4j<!doctype html>\n<html lang=\"en\">\n    <head>\n        <meta charset=\"utf-8\">\n        <meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n        <link href=\"https://cdn.jsdelivr.net/npm/bootstrap@5.0.2/dist/css/bootstrap.min.css\" rel=\"stylesheet\">\n\n        <title>{{ .Title }}</title>\n        <style>\n.navbar-brand {\n    font-size: 3rem;\n    font-weight: 300\n}\n\n.nav-link {\n    color: rgba(0, 0, 0, 0.9);\n}\n        </style>\n    </head>\n    <body>\n        {{ template \"navbar\" . }}\n\n        <div class=\"container p-2 my-2\">\n            <h1>Score overrides</h1>\n\n            {{ if .ErrorMessage }}\n            <div class=\"alert alert-danger\" role=\"alert\">\n                {{ .ErrorMessage }}\n            </div>\n            {{ end }}\n\n            <div class=\"table-responsive\">\n                <table class=\"table table-hover\">\n                    <thead>\n                        <tr>\n                            <th scope=\"col\">Time</th>\n                            <th scope=\"col\">Student</th>\n                            <th scope=\"col\">Task</th>\n                            <th scope=\"col\">Change</th>\n                            <th scope=\"col\">Comment</th>\n                            <th scope=\"col\">Author</th>\n                        </tr>\n                    </thead>\n                    <tbody>\n                        {{ range .Overrides }}\n                            <tr>\n                                <td>{{ formatDate $.Location .CreatedAt \"02-01-2006 15:04:05\" }}</td>\n                                <td>\n                                    {{ if .Student }}\n                                        <a href=\"{{ .HomeLink }}\" class=\"text-decoration-none\">{{ .Student.FirstName }} {{ .Student.LastName }}</a>\n                                    {{ else }}\n                                        #{{ .UserID }}\n                                    {{ end }}\n                                </td>\n                                <td>{{ .Task }}</td>\n                                <td>\n                                    {{ if eq .Kind \"set\" }}\n                                        = {{ .Score }}\n                                    {{ else if eq .Kind \"delta\" }}\n                                        {{ if ge .Score 0 }}+{{ end }}{{ .Score }}\n                                    {{ else }}\n                                        reset\n                                    {{ end }}\n                                </td>\n                                <td>{{ .Comment }}</td>\n                                <td>{{ .Author }}</td>\n                            </tr>\n                        {{ end }}\n                    </tbody>\n                </table>\n            </div>\n        </div>\n    </body>\n</html>\nPK\x07\x08\xa2\xb2\xe7\xb2\xb8\n\x00\x00\xb8\n\x00\x00PK\x03\x04\x14\x00\x08\x00\x00\x00IYR]\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x11\x00	\x00provisioning.tmplUT\x05\x00\x01\x1a\xa9\xd4j<!doctype html>\n<html lang=\"en\">\n    <head>\n        <meta charset=\"utf-8\">\n        <meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n        <link href=\"https://cdn.jsdelivr.net/npm/bootstrap@5.0.2/dist/css/bootstrap.min.css\" rel=\"stylesheet\">\n\n        <title>{{ .Title }}</title>\n        <style>\n.navbar-brand {\n    font-size: 3rem;\n    font-weight: 300\n}\n\n.nav-link {\n    color: rgba(0, 0, 0, 0.9);\n}\n        </style>\n    </head>\n    <body>\n        {{ template \"navbar\" . }}\n\n\n        <div class=\"container p-2 my-2\">\n            <h1>Provisioning</h1>\n            <p class=\"text-muted\">Jobs creating GitLab projects of the students, failed attempts are retried with exponential backoff</p>\n\n            {{ if .ErrorMessage }}\n            <div class=\"alert alert-danger\" role=\"alert\">\n                {{ .ErrorMessage }}\n            </div>\n            {{ end }}\n\n            <div class=\"table-responsive\">\n                <table class=\"table table-hover\">\n                    <thead>\n                        <tr>\n                            <th scope=\"col\">Student</th>\n                            <th scope=\"col\">State</th>\n                            <th scope=\"col\">Attempts</th>\n                            <th scope=\"col\">Next attempt</th>\n                            <th scope=\"col\">Last error</th>\n                            <th scope=\"col\"></th>\n                        </tr>\n                    </thead>\n                    <tbody>\n                        {{ range .Jobs }}\n                            <tr>\n                                <td>\n                                    {{ if .Student }}\n                                    <a href=\"{{ .HomeLink }}\" class=\"text-decoration-none\">{{ .Student.FirstName }} {{ .Student.LastName }}</a>\n                                    {{ else }}\n                                    <span class=\"text-muted\">user {{ .UserID }}</span>\n                                    {{ end }}\n                                </td>\n                                <td>{{ .State }}</td>\n                                <td>{{ .Attempts }}</td>\n                                <td>{{ if eq .State \"pending\" }}{{ formatDate $.Location .NextAttemptAt \"02-01-2006 15:04:05\" }}{{ end }}</td>\n                                <td class=\"text-break\">{{ .LastError }}</td>\n                                <td>\n                                    {{ if eq .State \"failed\" }}\n                                    <form method=\"post\" action=\"{{ $.Config.Endpoints.Admin.Provisioning }}\">\n                                        <input type=\"hidden\" name=\"id\" value=\"{{ .ID }}\">\n                                        <button type=\"submit\" class=\"btn btn-sm btn-outline-primary\">Retry</button>\n                                    </form>\n                                    {{ end }}\n                                </td>\n                            </tr>\n                        {{ end }}\n                    </tbody>\n                </table>\n            </div>\n        </div>\n    </body>\n</html>\nPK\x07\x08\xf4\xdd\x05\xbc\xe2\x0b\x00\x00\xe2\x0b\x00\x00PK\x03\x04\x14\x00\x08\x00\x00\x00IYR]\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x0d\x00	\x00sessions.tmplUT\x05\x00\x01\x1a\xa9\xd4j<!doctype html>\n<html lang=\"en\">\n    <head>\n        <meta charset=\"utf-8\">\n        <meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n        <link href=\"https://cdn.jsdelivr.net/npm/bootstrap@5.0.2/dist/css/bootstrap.min.css\" rel=\"stylesheet\">\n\n        <title>{{ .Title }}</title>\n        <style>\n.navbar-brand {\n    font-size: 3rem;\n    font-weight: 300\n}\n\n.nav-link {\n    color: rgba(0, 0, 0, 0.9);\n}\n        </style>\n    </head>\n    <body>\n        {{ template \"navbar\" . }}\n\n        <div class=\"container p-2 my-2\">\n            {{ if .ErrorMessage }}\n            <div class=\"alert alert-danger\" role=\"alert\">\n                {{ .ErrorMessage }}\n            </div>\n            {{ end }}\n\n            <form method=\"post\" action=\"{{ .Config.Endpoints.TimeZone }}\" class=\"row g-2 mb-3\">\n                <label for=\"timezone\" class=\"col-auto col-form-label\">Time zone</label>\n                <div class=\"col-md-4\">\n                    <input type=\"text\" class=\"form-control\" id=\"timezone\" name=\"timezone\" value=\"{{ .TimeZone }}\" placeholder=\"{{ .Location }}\">\n                </div>\n                <div class=\"col-auto\">\n                    <button type=\"button\" class=\"btn btn-outline-secondary\" onclick=\"document.getElementById('timezone').value = Intl.DateTimeFormat().resolvedOptions().timeZone\">Use browser time zone</button>\n                </div>\n                <div class=\"col-auto\">\n                    <button type=\"submit\" class=\"btn btn-outline-primary\">Save</button>\n                </div>\n                <div class=\"form-text\">Dates are shown in {{ .Location }}, leave empty for the course time zone.</div>\n            </form>\n\n            <div class=\"table-responsive\">\n                <table class=\"table table-hover\">\n                    <thead>\n                        <tr>\n                            <th scope=\"col\">Device</th>\n                            <th scope=\"col\">IP</th>\n                            <th scope=\"col\">Signed in</th>\n                            <th scope=\"col\">Last seen</th>\n                            <th scope=\"col\">Expires</th>\n                            <th scope=\"col\"></th>\n                        </tr>\n                    </thead>\n                    <tbody>\n                        {{ range .Sessions }}\n                            <tr>\n                                <td>\n                                    {{ .UserAgent }}\n                                    {{ if .Current }}<span class=\"badge bg-success\">current</span>{{ end }}\n                                </td>\n                                <td>{{ .IP }}</td>\n                                <td>{{ formatDate $.Location .CreatedAt }}</td>\n                                <td>{{ formatDate $.Location .LastSeenAt }}</td>\n                                <td>{{ formatDate $.Location .ExpiresAt }}</td>\n                                <td>\n                                    <form method=\"post\" action=\"{{ $.Links.Sessions }}\">\n                                        <input type=\"hidden\" name=\"session\" value=\"{{ .ID }}\">\n                                        <button type=\"submit\" class=\"btn btn-sm btn-outline-danger\">Revoke</button>\n                                    </form>\n                                </td>\n                            </tr>\n                        {{ end }}\n                    </tbody>\n                </table>\n            </div>\n\n            <form method=\"post\" action=\"{{ .Links.Sessions }}\">\n                <input type=\"hidden\" name=\"session\" value=\"all\">\n                <div class=\"d-grid\">\n                    <button type=\"submit\" class=\"btn btn-danger\">Log out everywhere</button>\n                </div>\n            </form>\n        </div>\n    </body>\n</html>\nPK\x07\x08,y\x87\xda\x99\x0e\x00\x00\x99\x0e\x00\x00PK\x03\x04\x14\x00\x08\x00\x00\x00\x15YR]\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x0b\x00	\x00signup.tmplUT\x05\x00\x01\xbb\xa8\xd4j<!doctype html>\n<html lang=\"en\">\n  <head>\n    <meta charset=\"utf-8\">\n    <meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n    <link href=\"https://cdn.jsdelivr.net/npm/bootstrap@5.0.2/dist/css/bootstrap.min.css\" rel=\"stylesheet\">\n\n    <title>{{ .CourseName }}</title>\n    <style>\n.navbar-brand {\n  font-size: 3rem;\n  font-weight: 300\n}\n    </style>\n  </head>\n  <body>\n    <nav class=\"navbar navbar-light bg-light\">\n      <div class=\"container\">\n        <div class=\"col col-xxl-4 offset-xxl-4 col-lg-6 offset-lg-3 col-md-10 offset-md-1\">\n          <p class=\"navbar-brand mb-0 h1 text-center\">{{ .CourseName }}</p>\n        </div>\n      </div>\n    </nav>\n\n    <div class=\"container p-2 my-2\">\n      <div class=\"row p-2\">\n        <div class=\"col col-xxl-4 offset-xxl-4 col-lg-6 offset-lg-3 col-md-10 offset-md-1\">\n          <div class=\"card\">\n            <div class=\"card-body\">\n              <form method=\"post\" action=\"{{ .Config.Endpoints.Signup }}\" class=\"needs-validation was-validated\">\n                <div class=\"form-floating mb-3\">\n                  <input type=\"text\" class=\"form-control\" id=\"floatingFirstName\" placeholder=\"Ivan\" name=\"firstname\" required pattern=\"[A-Za-z-]+\">\n                  <label for=\"floatingFirstName\">First name</label>\n                  <div class=\"invalid-feedback\">\n                    Please use only Latin letters\n                  </div>\n                </div>\n                <div class=\"form-floating mb-3\">\n                  <input type=\"text\" class=\"form-control\" id=\"floatingLastName\" placeholder=\"Petrov\" name=\"lastname\" required pattern=\"[A-Za-z-]+\">\n                  <label for=\"floatingLastName\">Last name</label>\n                  <div class=\"invalid-feedback\">\n                    Please use only Latin letters\n                  </div>\n                </div>\n                <div class=\"form-floating mb-3\">\n                  <input type=\"text\" class=\"form-control\" id=\"floatingSecretCode\" placeholder=\"LolKekCheburek\" name=\"secret\" required pattern=\"[A-Za-z0-9-_]+\">\n                  <label for=\"floatingSecretCode\">Secret code</label>\n                  <div class=\"invalid-feedback\">\n                    Ask your teacher\n                  </div>\n                </div>\n\n                {{ if .ErrorMessage }}\n                <div class=\"alert alert-danger\" role=\"alert\">\n                    {{ .ErrorMessage }}\n                </div>\n                {{ end }}\n\n                <div class=\"d-grid mb-3\">\n                  <button type=\"submit\" class=\"btn btn-outline-success\">Sign up via GitLab</button>\n                </div>\n              </form>\n\n              <div class=\"d-grid\">\n                <a class=\"btn btn-outline-primary btn-block\" href=\"{{ .Config.Endpoints.Login }}\">Login via GitLab</a>\n              </div>\n            </div>\n          </div>\n        </div>\n      </div>\n    </div>\n\n  </body>\n</html>\n\nPK\x07\x08@c6|M\x0b\x00\x00M\x0b\x00\x00PK\x03\x04\x14\x00\x08\x00\x00\x00\xf6YR]\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x0e\x00	\x00standings.tmplUT\x05\x00\x01a\xaa\xd4j<!doctype html>\n<html lang=\"en\">\n    <head>\n        <meta charset=\"utf-8\">\n        <meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n        <link href=\"https://cdn.jsdelivr.net/npm/bootstrap@5.0.2/dist/css/bootstrap.min.css\" rel=\"stylesheet\">\n\n        <title>{{ .Title }}</title>\n        <style>\n.shadow-hover:hover {\n    transition: all 0.1s ease;\n    box-shadow:0 .5rem 1rem rgba(0,0,0,.15)!important\n}\n.shadow-hover {\n    -webkit-transition: all 0.1s ease;\n    -moz-transition: all 0.1s ease;\n    -o-transition: all 0.1s ease;\n    transition: all 0.1s ease;\n    box-shadow:0 .125rem .25rem rgba(0,0,0,.075)!important\n}\n\n.task-success {\n    background-color: #a6e9d5;\n    border-color: #4dd4ac;\n}\n\n.task-failed {\n    background-color: #f8d7da;\n    border-color: #f1aeb5;\n}\n\n.task-checking {\n    border-color: #0d6efd;\n    background-color:#9ec5fe;\n}\n\n.task-assigned {\n    background-color: #f8f9fa;\n}\n\n.navbar-brand {\n    font-size: 3rem;\n    font-weight: 300\n}\n\n.nav-link {\n    color: rgba(0, 0, 0, 0.9);\n}\n\n.task {\n    width: 120px;\n    max-width: 120px;\n    overflow: hidden;\n}\n        </style>\n    </head>\n    <body>\n      {{ template \"navbar\" . }}\n\n        <div class=\"container p-2 my-2\">\n            <div class=\"container row\">\n                {{ range .Groups }}\n                    <div class=\"col-auto\">\n                      <a class=\"nav-link\" href=\"{{ .Link }}\"><h5>{{ .Name }}</h5></a>\n                    </div>\n                {{ end }}\n            </div>\n            <div class=\"table-responsive\">\n                <table class=\"table table-hover\">\n                    <thead>\n                        <tr>\n                            <th scope=\"col\" class=\"num\">#</th>\n                            <th scope=\"col\" class=\"name\">Student</th>\n                            <th scope=\"col\" class=\"name\">Group</th>\n                            <th scope=\"col\">Score</th>\n                            {{ if .Standings.Graded }}\n                                <th scope=\"col\">Grade</th>\n                            {{ end }}\n                            {{ range .Standings.Deadlines }}\n                                {{ range .Tasks }}\n                                    <th scope=\"col\" class=\"task\">{{ .Task }}</th>\n                                {{ end }}\n                            {{ end }}\n                        </tr>\n                    </thead>\n                    <tbody>\n                        {{ with index .Standings.Users 0 }}\n                            <tr>\n                                <th scope=\"row\" class=\"num\">0</th>\n                                <th scope=\"row\" class=\"name\">Chuck Norris</th>\n                                <th scope=\"row\" class=\"subgroup\"></th>\n                                <td>{{ .MaxScore }}</td>\n                                {{ if $.Standings.Graded }}\n                                    <td></td>\n                                {{ end }}\n                                {{ range .Groups }}\n                                    {{ range .Tasks }}\n                                        <td class=\"task table-success\"><a href=\"/private/solutions/{{ .Task }}\" class=\"text-decoration-none text-dark\">{{ .MaxScore }}</a></td>\n                                    {{ end }}\n                                {{ end }}\n                            </tr>\n                        {{ end }}\n                        {{ range $index, $user := .Standings.Users }}\n                            <tr>\n                                <th scope=\"row\" class=\"num\">{{ inc $index }}</th>\n                                <th scope=\"row\" class=\"name\">{{ $user.User.FirstName }} {{ $user.User.LastName }}</th>\n                                <th scope=\"row\" class=\"subgroup\">\n                                    <a href=\"{{ $.Config.Endpoints.Standings }}/{{ $user.User.Group }}/{{ $user.User.Subgroup }}\" class=\"text-decoration-none text-dark\">\n                                        {{ $user.User.Subgroup }}\n                                    </a>\n                                </th>\n                                <td>{{ $user.Score }}</td>\n                                {{ if $.Standings.Graded }}\n                                    {{ with $user.FinalGrade }}\n                                        <td{{ if .Failures }} class=\"table-danger\" title=\"{{ range .Failures }}{{ . }}&#10;{{ end }}\"{{ end }}>{{ .Grade }}</td>\n                                    {{ else }}\n                                        <td></td>\n                                    {{ end }}\n                                {{ end }}\n                                {{ range $user.Groups }}\n                                    {{ range .Tasks }}\n                                        {{ if eq .Status \"success\"}}\n                                            <td class=\"task table-success\">\n                                        {{ else if eq .Status \"failed\"}}\n                                            <td class=\"task table-danger\">\n                                        {{ else if eq .Status \"pending\"}}\n                                            <td class=\"task table-warning\">\n                                        {{ else if eq .Status \"on_review\"}}\n                                            <td class=\"task table-info\">\n                                        {{ else if eq .Status \"exempt\"}}\n                                            <td class=\"task table-secondary\">\n                                        {{ else }}\n                                            <td class=\"task\">\n                                        {{ end }}\n                                        {{ if .PipelineUrl }}\n                                            <a href=\"{{ .PipelineUrl }}\" class=\"text-decoration-none text-dark\">\n                                        {{ end }}\n                                        {{ .Score }}{{ if .Override }}<sup{{ if .Override.Comment }} title=\"{{ .Override.Comment }}\"{{ end }}>*</sup>{{ end }}\n                                        {{ if .PipelineUrl }}\n                                            </a>\n                                        {{ end }}\n                                        </td>\n                                    {{ end }}\n                                {{ end }}\n                            </tr>\n                        {{ end }}\n                    </tbody>\n                </table>\n            </div>\n        </div>\n        {{ template \"scoresChart\" . }}\n    </body>\n</html>\nPK\x07\x08\x88\xc5\xf7\x14]\x19\x00\x00]\x19\x00\x00PK\x03\x04\x14\x00\x08\x00\x00\x00\xb6L0T\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00	\x00	\x00style.cssUT\x05\x00\x01i\xe7\xe3abody {\n    margin: 0;\n    font-family: 'Source Code Pro', monospace;\n    display: flex;\n}\n\n.site {\n    max-width: 1200px;\n    width: 100%;\n\n    margin: 0 auto;\n    padding-left: 4em;\n    padding-right: 4em;\n\n    display: flex;\n    flex-direction: column;\n    align-items: center;\n}\n\n.header-container {\n    margin: 0 auto;\n    margin-top: 2em;\n\n    display: flex;\n}\n\n/* ========================================================================== */\n\n.main-menu {\n    padding: 0;\n    display: flex;\n    list-style: none;\n    color: #455a64;\n}\n\n.main-menu a {\n    text-decoration: none;\n    color: #455a64;\n}\n\n.main-menu li {\n    font-size: 1em;\n    text-transform: uppercase;\n    margin-left: 0.66em;\n}\n\n.main-menu li .current {\n    font-weight: bold;\n}\n\n/* ========================================================================== */\n\n.main {\n    width: 100%;\n    display: flex;\n    flex-direction: column;\n    align-items: center;\n}\n\n/* ========================================================================== */\n\n.flag-submit {\n    display: flex;\n    align-content: center;\n    margin: auto;\n}\n\n/* ========================================================================== */\n\n.group {\n    display: flex;\n    flex-direction: column;\n    width: 100%;\n}\n\n.group a {\n    text-decoration: none;\n}\n\n.group-header {\n    display: flex;\n}\n\n.group-header h1 {\n    white-space: pre;\n    margin: 0em;\n}\n\n.group-tasks {\n    display: flex;\n    flex-wrap: wrap;\n}\n\n.task {\n    width: 200px;\n    height: 120px;\n    margin: 10px;\n\n    display: flex;\n    flex-direction: column;\n    align-items: center;\n}\n\n.unsolved {\n    background-color: #1e3250;\n    color: white;\n}\n\n.solved {\n    background-color: #66cda3;\n    color: black;\n}\n\n.task .name {\n    margin: 0 auto;\n    margin-top: 0.33em;\n    font-size: 1.5em;\n    white-space: nowrap;\n}\n\n.task .score {\n    margin: 0 auto;\n    font-size: 3em;\n    font-weight: bold;\n}\n\n/* ========================================================================== */\n\n.signup {\n    width: 100%;\n    \n    display: flex;\n    flex-direction: column;\n    justify-content: center;\n    align-items: center;\n    margin: 2em;\n}\n\n.signup .login {\n    padding-top: 2em;\n    padding-bottom: 2em;\n\n    display: flex;\n}\n\n.login-button {\n    display: flex;\n\n    font-size: 2em;\n\n    margin: auto;\n    height: 80px;\n    width: 300px;\n\n    border: solid;\n    border-width: 1px;\n    border-color: #168f48;\n    background-color: #1aaa55;\n\n    text-decoration: none;\n}\n\n.login-button .text {\n    margin: auto;\n    color: white;\n}\n\n.signup .or {\n    display: flex;\n    min-width: 100px;\n}\n\n.or .text {\n    font-size: 1em;\n    margin: auto;\n}\n\n.signup .register {\n    display: flex;\n    padding-top: 2em;\n    padding-bottom: 2em;\n}\n\n.form {\n    width: 500px;\n\n    display: flex;\n    flex-direction: column;\n    \n    border: 1px solid #e5e5e5;\n}\n\n.form-header {\n    display: flex;\n    align-items: center;\n}\n\n.form-header h1 {\n    margin: 0 auto;\n    padding-top: 0.33em;\n    padding-bottom: 0.33em;\n    font-weight: normal;\n    font-size: 2em;\n}\n\n.form .form-element {\n    flex: 1;\n\n    margin: 0.33em;\n    margin-bottom: 0;\n\n    padding: 0.33em;\n    padding-bottom: 0;\n\n    display: flex;\n    flex-direction: column;\n}\n\n.form .form-element.last {\n    padding-bottom: 0.33em;\n    margin-bottom: 0.33em;\n}\n\n.form-element input {\n    flex: 1;\n    height: 40px;\n\n    font-size: 1.5em;\n    padding-left: 0.1em;\n    border: 1px solid #e5e5e5;\n}\n\n.form-element .button {\n    background-color: #1f78d1;\n    border-color: #1b69b6;\n    color: white;\n    cursor: pointer;\n    font-family: 'Source Code Pro', monospace;\n    font-size: 1em;\n}\n\n.form-element .name {\n    margin-left: 0.33em;\n    margin-bottom: 0.33em;\n    color: #555555;\n}\n\n.form .form-error {\n    background-color: #db3b21;\n}\n\n.form-error .error-message {\n    margin-left: 0.33em;\n    margin-bottom: 0.33em;\n    \n    color: white;\n}\n\n/* ========================================================================== */\n\n.status {\n    display: flex;\n    flex-direction: column;\n    width: 400px;\n    margin-right: 60px;\n}\n\n.status h1 {\n    margin-left: auto;\n    margin-right: auto;\n}\n\ntable {\n    border-spacing: 0.66em;\n}\n\ntable td {\n    text-align: center;\n}\n\ntable th {\n    text-align: center;\n}\nPK\x07\x08\xff\x8bCA\x9d\x10\x00\x00\x9d\x10\x00\x00PK\x03\x04\x14\x00\x08\x00\x00\x00IYR]\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00	\x00	\x00task.tmplUT\x05\x00\x01\x1a\xa9\xd4j<!doctype html>\n<html lang=\"en\">\n    <head>\n        <meta charset=\"utf-8\">\n        <meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n        <link href=\"https://cdn.jsdelivr.net/npm/bootstrap@5.0.2/dist/css/bootstrap.min.css\" rel=\"stylesheet\">\n\n        <title>{{ .Title }}</title>\n        <style>\n.navbar-brand {\n    font-size: 3rem;\n    font-weight: 300\n}\n\n.nav-link {\n    color: rgba(0, 0, 0, 0.9);\n}\n        </style>\n    </head>\n    <body>\n        {{ template \"navbar\" . }}\n\n        <div class=\"container p-2 my-2\">\n            {{ if .Error }}\n            <div class=\"alert alert-danger\" role=\"alert\">\n                Failed to load task {{ .TaskName }}\n            </div>\n            {{ end }}\n\n            {{ with .Timeline }}\n            <h1>{{ .Task.ShortName }} <span class=\"text-muted\">{{ .Task.Score }} / {{ .Task.MaxScore }}</span></h1>\n            <p class=\"text-muted\">\n                {{ .Group }}, deadline {{ formatDate $.Location .Deadline }}\n                {{ if .Task.Extension }}(extended){{ end }}\n                {{ if .Task.TaskUrl }}<a href=\"{{ .Task.TaskUrl }}\">statement</a>{{ end }}\n            </p>\n            {{ with .Task.Override }}\n            <div class=\"alert alert-warning\" role=\"alert\">\n                Score is overridden by {{ .Author }} ({{ .Kind }} {{ .Score }}): {{ .Comment }}\n            </div>\n            {{ end }}\n\n            {{ if .Entries }}\n            <div class=\"table-responsive\">\n                <table class=\"table table-hover\">\n                    <thead>\n                        <tr>\n                            <th scope=\"col\">Time</th>\n                            <th scope=\"col\">Attempt</th>\n                            <th scope=\"col\">Status</th>\n                            <th scope=\"col\">Score</th>\n                            <th scope=\"col\"></th>\n                        </tr>\n                    </thead>\n                    <tbody>\n                        {{ range .Entries }}\n                            <tr{{ if .Effective }} class=\"table-success\"{{ end }}>\n                                <td>{{ formatDate $.Location .Time \"02-01-2006 15:04:05\" }}</td>\n                                <td>{{ if .Url }}<a href=\"{{ .Url }}\">{{ .Kind }}</a>{{ else }}{{ .Kind }}{{ end }}</td>\n                                <td>{{ .Status }}</td>\n                                <td>{{ if ne .Kind \"merge_request\" }}{{ .Score }}{{ end }}</td>\n                                <td>{{ if .Effective }}<span class=\"badge bg-success\">counted</span>{{ end }}</td>\n                            </tr>\n                        {{ end }}\n                    </tbody>\n                </table>\n            </div>\n            <p class=\"text-muted\">Score of each attempt is computed by the current late submit policy</p>\n            {{ else }}\n            <p>No attempts yet</p>\n            {{ end }}\n            {{ end }}\n        </div>\n    </body>\n</html>\nPK\x07\x08\xc3\xe0\xee\xd5e\x0b\x00\x00e\x0b\x00\x00PK\x03\x04\x14\x00\x08\x00\x00\x00IYR]\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x15\x00	\x00template_updates.tmplUT\x05\x00\x01\x1a\xa9\xd4j<!doctype html>\n<html lang=\"en\">\n    <head>\n        <meta charset=\"utf-8\">\n        <meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n        <link href=\"https://cdn.jsdelivr.net/npm/bootstrap@5.0.2/dist/css/bootstrap.min.css\" rel=\"stylesheet\">\n\n        <title>{{ .Title }}</title>\n        <style>\n.navbar-brand {\n    font-size: 3rem;\n    font-weight: 300\n}\n\n.nav-link {\n    color: rgba(0, 0, 0, 0.9);\n}\n        </style>\n    </head>\n    <body>\n        {{ template \"navbar\" . }}\n\n\n        <div class=\"container p-2 my-2\">\n            <h1>Template updates</h1>\n            <p class=\"text-muted\">Students who have not merged the latest template update</p>\n\n            {{ if .ErrorMessage }}\n            <div class=\"alert alert-danger\" role=\"alert\">\n                {{ .ErrorMessage }}\n            </div>\n            {{ end }}\n\n            <div class=\"row g-2 mb-2\">\n                <div class=\"col-auto\">\n                    <form method=\"post\" action=\"{{ .Config.Endpoints.Admin.TemplateUpdates }}\">\n                        <button type=\"submit\" class=\"btn btn-outline-primary\">Publish template update</button>\n                    </form>\n                </div>\n                <div class=\"col-auto\">\n                    {{ if .ShowAll }}\n                    <a class=\"btn btn-outline-secondary\" href=\"{{ .Config.Endpoints.Admin.TemplateUpdates }}\">Not merged only</a>\n                    {{ else }}\n                    <a class=\"btn btn-outline-secondary\" href=\"{{ .Config.Endpoints.Admin.TemplateUpdates }}?all=1\">Show all</a>\n                    {{ end }}\n                </div>\n            </div>\n\n            <div class=\"table-responsive\">\n                <table class=\"table table-hover\">\n                    <thead>\n                        <tr>\n                            <th scope=\"col\">Student</th>\n                            <th scope=\"col\">Group</th>\n                            <th scope=\"col\">Status</th>\n                            <th scope=\"col\">Merge request</th>\n                            <th scope=\"col\">Updated</th>\n                        </tr>\n                    </thead>\n                    <tbody>\n                        {{ range .Entries }}\n                            <tr>\n                                <td><a href=\"{{ .HomeLink }}\" class=\"text-decoration-none\">{{ .Student.FirstName }} {{ .Student.LastName }}</a></td>\n                                <td>{{ .Student.GroupName }}/{{ .Student.SubgroupName }}</td>\n                                {{ if .Update }}\n                                <td title=\"{{ .Update.Error }}\">{{ .Update.Status }}</td>\n                                <td>{{ if .Update.MergeRequestURL }}<a href=\"{{ .Update.MergeRequestURL }}\">!{{ .Update.MergeRequestIID }}</a>{{ end }}</td>\n                                <td>{{ formatDate $.Location .Update.UpdatedAt }}</td>\n                                {{ else }}\n                                <td class=\"text-muted\">not published</td>\n                                <td></td>\n                                <td></td>\n                                {{ end }}\n                            </tr>\n                        {{ end }}\n                    </tbody>\n                </table>\n            </div>\n        </div>\n    </body>\n</html>\nPK\x07\x08\xf2/0}\xc3\x0c\x00\x00\xc3\x0c\x00\x00PK\x03\x04\x14\x00\x08\x00\x00\x00\x15YR]\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\n\x00	\x00token.tmplUT\x05\x00\x01\xbb\xa8\xd4j<!doctype html>\n<html lang=\"en\">\n    <head>\n        <meta charset=\"utf-8\">\n        <meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n        <link href=\"https://cdn.jsdelivr.net/npm/bootstrap@5.0.2/dist/css/bootstrap.min.css\" rel=\"stylesheet\">\n\n        <title>{{ .Title }}</title>\n        <style>\n.navbar-brand {\n    font-size: 3rem;\n    font-weight: 300\n}\n\n.nav-link {\n    color: rgba(0, 0, 0, 0.9);\n}\n        </style>\n    </head>\n    <body>\n        {{ template \"navbar\" . }}\n\n        <div class=\"container p-2 my-2\">\n            <div class=\"row p-2\">\n                <div class=\"col col-lg-8 offset-lg-2\">\n                    <div class=\"card\">\n                        <div class=\"card-body\">\n                            <h3 class=\"card-title\">Personal API token</h3>\n                            {{ if .Token }}\n                                <p class=\"card-text\"><code>{{ .Token }}</code></p>\n                            {{ else }}\n                                <p class=\"card-text text-muted\">You do not have a token yet</p>\n                            {{ end }}\n                            <p class=\"card-text\">\n                                Pass the token in the <code>Authorization: Bearer &lt;token&gt;</code> header:\n                            </p>\n                            <ul>\n                                <li><code>GET {{ .Config.Endpoints.Api.Scores }}?login=&lt;gitlab login&gt;</code></li>\n                                <li><code>GET {{ .Config.Endpoints.Api.Standings }}?group=&lt;group&gt;&amp;subgroup=&lt;subgroup&gt;</code></li>\n                            </ul>\n                            <form method=\"post\" action=\"{{ .Links.ApiToken }}\">\n                                <div class=\"d-grid\">\n                                    <button type=\"submit\" class=\"btn btn-outline-danger\">{{ if .Token }}Regenerate{{ else }}Generate{{ end }} token</button>\n                                </div>\n                            </form>\n                        </div>\n                    </div>\n                </div>\n            </div>\n        </div>\n    </body>\n</html>\nPK\x07\x08M\xd78\x94D\x08\x00\x00D\x08\x00\x00PK\x01\x02\x14\x03\x14\x00\x08\x00\x00\x00JYR]a\xb5\x97\xdc\xa3\x10\x00\x00\xa3\x10\x00\x00\n\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xa4\x81\x00\x00\x00\x00admin.tmplUT\x05\x00\x01\x1d\xa9\xd4jPK\x01\x02\x14\x03\x14\x00\x08\x00\x00\x00MYR]\xdbz\xa2\x16\xc0	\x00\x00\xc0	\x00\x00\x0d\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xa4\x81\xe4\x10\x00\x00calendar.tmplUT\x05\x00\x01#\xa9\xd4jPK\x01\x02\x14\x03\x14\x00\x08\x00\x00\x00DYR]\x92\xe5\xef%\xc5\x04\x00\x00\xc5\x04\x00\x00\n\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xa4\x81\xe8\x1a\x00\x00chart.tmplUT\x05\x00\x01\x10\xa9\xd4jPK\x01\x02\x14\x03\x14\x00\x08\x00\x00\x00\x15YR]%S\x13\x8d\x98\x02\x00\x00\x98\x02\x00\x00\x0c\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xa4\x81\xee\x1f\x00\x00courses.tmplUT\x05\x00\x01\xbb\xa8\xd4jPK\x01\x02\x14\x03\x14\x00\x08\x00\x00\x00LYR]G\xf57\xb4L\x0d\x00\x00L\x0d\x00\x00\x0e\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xa4\x81\xc9\"\x00\x00deadlines.tmplUT\x05\x00\x01 \xa9\xd4jPK\x01\x02\x14\x03\x14\x00\x08\x00\x00\x00IYR]\xaf\xbf\x13\x8c\x18\x0c\x00\x00\x18\x0c\x00\x00	\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xa4\x81Z0\x00\x00flag.tmplUT\x05\x00\x01\x1a\xa9\xd4jPK\x01\x02\x14\x03\x14\x00\x08\x00\x00\x00IYR]\x8dZ\xda\xa3\x18\x08\x00\x00\x18\x08\x00\x00\x12\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xa4\x81\xb2<\x00\x00foreign_flags.tmplUT\x05\x00\x01\x1a\xa9\xd4jPK\x01\x02\x14\x03\x14\x00\x08\x00\x00\x00U[R]\xd8\xa3\xf5._3\x00\x00_3\x00\x00	\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xa4\x81\x13E\x00\x00home.tmplUT\x05\x00\x01\xf3\xac\xd4jPK\x01\x02\x14\x03\x14\x00\x08\x00\x00\x00\xb6L0TLn\xf0\x0c\x05\x00\x00\x00\x05\x00\x00\x00\x08\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xb4\x81\xb2x\x00\x00kek.htmlUT\x05\x00\x01i\xe7\xe3aPK\x01\x02\x14\x03\x14\x00\x08\x00\x00\x00MYR]\x04\x1a:\n\xe7\x06\x00\x00\xe7\x06\x00\x00\x0b\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xa4\x81\xf6x\x00\x00navbar.tmplUT\x05\x00\x01#\xa9\xd4jPK\x01\x02\x14\x03\x14\x00\x08\x00\x00\x00IYR]\xa2\xb2\xe7\xb2\xb8\n\x00\x00\xb8\n\x00\x00\x0e\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xa4\x81\x1f\x80\x00\x00overrides.tmplUT\x05\x00\x01\x1a\xa9\xd4jPK\x01\x02\x14\x03\x14\x00\x08\x00\x00\x00IYR]\xf4\xdd\x05\xbc\xe2\x0b\x00\x00\xe2\x0b\x00\x00\x11\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xa4\x81\x1c\x8b\x00\x00provisioning.tmplUT\x05\x00\x01\x1a\xa9\xd4jPK\x01\x02\x14\x03\x14\x00\x08\x00\x00\x00IYR],y\x87\xda\x99\x0e\x00\x00\x99\x0e\x00\x00\x0d\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xa4\x81F\x97\x00\x00sessions.tmplUT\x05\x00\x01\x1a\xa9\xd4jPK\x01\x02\x14\x03\x14\x00\x08\x00\x00\x00\x15YR]@c6|M\x0b\x00\x00M\x0b\x00\x00\x0b\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xa4\x81#\xa6\x00\x00signup.tmplUT\x05\x00\x01\xbb\xa8\xd4jPK\x01\x02\x14\x03\x14\x00\x08\x00\x00\x00\xf6YR]\x88\xc5\xf7\x14]\x19\x00\x00]\x19\x00\x00\x0e\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xa4\x81\xb2\xb1\x00\x00standings.tmplUT\x05\x00\x01a\xaa\xd4jPK\x01\x02\x14\x03\x14\x00\x08\x00\x00\x00\xb6L0T\xff\x8bCA\x9d\x10\x00\x00\x9d\x10\x00\x00	\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xb4\x81T\xcb\x00\x00style.cssUT\x05\x00\x01i\xe7\xe3aPK\x01\x02\x14\x03\x14\x00\x08\x00\x00\x00IYR]\xc3\xe0\xee\xd5e\x0b\x00\x00e\x0b\x00\x00	\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xa4\x811\xdc\x00\x00task.tmplUT\x05\x00\x01\x1a\xa9\xd4jPK\x01\x02\x14\x03\x14\x00\x08\x00\x00\x00IYR]\xf2/0}\xc3\x0c\x00\x00\xc3\x0c\x00\x00\x15\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xa4\x81\xd6\xe7\x00\x00template_updates.tmplUT\x05\x00\x01\x1a\xa9\xd4jPK\x01\x02\x14\x03\x14\x00\x08\x00\x00\x00\x15YR]M\xd78\x94D\x08\x00\x00D\x08\x00\x00\n\x00	\x00\x00\x00\x00\x00\x00\x00\x00\x00\xa4\x81\xe5\xf4\x00\x00token.tmplUT\x05\x00\x01\xbb\xa8\xd4jPK\x05\x06\x00\x00\x00\x00\x13\x00\x13\x00\xfd\x04\x00\x00j\xfd\x00\x00\x00\x00"
 		fs.Register(data)
 	}
 	
\ No newline at end of file